@@ -0,0 +1,400 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/lucas/go-rest-api-mongo/internal/config"
+	"github.com/lucas/go-rest-api-mongo/internal/database"
+	"github.com/lucas/go-rest-api-mongo/internal/events"
+	"github.com/lucas/go-rest-api-mongo/internal/handlers"
+	"github.com/lucas/go-rest-api-mongo/internal/messaging"
+	"github.com/lucas/go-rest-api-mongo/internal/middleware"
+	"github.com/lucas/go-rest-api-mongo/internal/models"
+	"github.com/lucas/go-rest-api-mongo/internal/openapi"
+	"github.com/lucas/go-rest-api-mongo/internal/services"
+	"github.com/lucas/go-rest-api-mongo/pkg/i18n"
+	"github.com/lucas/go-rest-api-mongo/pkg/logging"
+	"github.com/lucas/go-rest-api-mongo/pkg/utils"
+)
+
+// Roles de um processo. A role api só enfileira os registros do /register-fast e a
+// role worker só os processa, então as duas escalam de forma independente; "all"
+// roda as duas no mesmo processo.
+const (
+	roleAPI    = "api"
+	roleWorker = "worker"
+	roleAll    = "all"
+)
+
+// app é a aplicação montada sobre um backend, com os componentes da sua role: o
+// router (a API completa, ou só /health e /metrics na role worker) e os processos
+// em background, que ainda precisam ser iniciados com Start.
+type app struct {
+	role              string
+	router            *gin.Engine
+	jobs              *services.JobRunner // nil na role api
+	registrationQueue *services.Queue[*models.User]
+	registrations     *services.WorkerPool[*models.User]
+	registrar         *services.RegistrationWorker
+	changeStream      *services.ChangeStreamPublisher // nil na role api e sem CDC_ENABLED
+	scheduler         *services.Scheduler             // nil na role api e sem SCHEDULER_ENABLED
+	rateLimits        *middleware.RateLimiters        // nil na role worker
+	rateLimitStore    middleware.RateLimitStore
+	validateAPI       atomic.Bool // OPENAPI_VALIDATE, só usado em development
+	logs              *logging.Writer
+	authService       *services.AuthService
+	mongo             *database.MongoDB // nil com o driver memory
+}
+
+// Start inicia os processos em background da aplicação.
+func (a *app) Start(ctx context.Context) {
+	if a.jobs != nil {
+		a.jobs.Start(ctx)
+	}
+	if a.changeStream != nil {
+		a.changeStream.Start(ctx)
+	}
+	if a.scheduler != nil {
+		a.scheduler.Start(ctx)
+	}
+}
+
+// Wait espera os processos iniciados por Start terminarem depois do cancelamento de ctx.
+func (a *app) Wait() {
+	if a.jobs != nil {
+		a.jobs.Wait()
+	}
+	if a.changeStream != nil {
+		a.changeStream.Wait()
+	}
+	if a.scheduler != nil {
+		a.scheduler.Wait()
+	}
+}
+
+// Reconfigure aplica uma configuração recarregada (os campos com a tag reload) aos
+// componentes já em execução.
+func (a *app) Reconfigure(old, cfg *config.Config) {
+	// Segredos rotacionados: tokens assinados com o segredo anterior continuam válidos
+	if cfg.JWT.SecretKey != old.JWT.SecretKey {
+		a.authService.RotateSecret(cfg.JWT.SecretKey)
+	}
+	if a.mongo != nil && (cfg.Database.URI != old.Database.URI || cfg.Database.Username != old.Database.Username || cfg.Database.Password != old.Database.Password) {
+		if err := a.mongo.Reconnect(cfg); err != nil {
+			log.Printf("⚠️  Keeping the current MongoDB connection: %v", err)
+		} else {
+			log.Println("✅ Reconnected to MongoDB with the new credentials")
+		}
+	}
+
+	if cfg.Workers.LaneHigh != old.Workers.LaneHigh || cfg.Workers.LaneNormal != old.Workers.LaneNormal || cfg.Workers.LaneLow != old.Workers.LaneLow {
+		a.registrationQueue.SetLanes(newLanes(cfg))
+	}
+	if a.jobs != nil {
+		a.registrations.Configure(cfg.Workers.BatchSize, cfg.Workers.BatchTimeout, newRetryPolicy(cfg))
+		a.registrar.Configure(newRetryPolicy(cfg))
+		// Só quando mudam, para não desfazer um ajuste feito pelo endpoint de admin
+		if scaling := newWorkerPoolScaling(cfg); scaling != newWorkerPoolScaling(old) {
+			a.registrations.SetScaling(scaling)
+		}
+	}
+	a.validateAPI.Store(cfg.Server.OpenAPIValidate)
+	if cfg.Server.LogLevel != old.Server.LogLevel {
+		a.logs.SetLevel(cfg.Server.LogLevel)
+		gin.SetMode(ginMode(cfg))
+	}
+
+	if a.rateLimits != nil && cfg.RateLimit != old.RateLimit {
+		handlers, err := rateLimitHandlers(cfg.RateLimit, a.rateLimitStore)
+		if err != nil {
+			log.Printf("⚠️  Keeping the previous rate limits: %v", err)
+			return
+		}
+		a.rateLimits.Replace(handlers)
+	}
+}
+
+// ginMode escolhe o modo do gin: LOG_LEVEL=debug mostra as rotas e os avisos do gin,
+// warn e error os escondem e, com info, o modo segue SERVER_MODE.
+func ginMode(cfg *config.Config) string {
+	switch cfg.Server.LogLevel {
+	case logging.Debug:
+		return gin.DebugMode
+	case logging.Warn, logging.Error:
+		return gin.ReleaseMode
+	}
+	switch cfg.Server.Mode {
+	case "production":
+		return gin.ReleaseMode
+	case "development":
+		return gin.DebugMode
+	default:
+		return gin.TestMode
+	}
+}
+
+func newApp(cfg *config.Config, b *backend) (*app, error) {
+	role := cfg.Server.Role
+	switch role {
+	case "":
+		role = roleAll
+	case roleAPI, roleWorker, roleAll:
+	default:
+		return nil, fmt.Errorf("unknown role %q", role)
+	}
+	if role != roleAll && !b.sharedQueue {
+		return nil, fmt.Errorf("role %q needs job queues shared between processes: use STORAGE_DRIVER=mongo", role)
+	}
+
+	authService := services.NewAuthService(cfg)
+
+	emitter, apiEmitter, err := newEmitters(cfg, b)
+	if err != nil {
+		return nil, err
+	}
+	retryPolicy := newRetryPolicy(cfg)
+
+	userService := services.NewUserService(b.users, authService, apiEmitter)
+	deadLetterService := services.NewDeadLetterService(b.deadLetters, userService, emitter, b.jobs)
+
+	a := &app{role: role, authService: authService, mongo: b.mongo}
+	health := handlers.NewHealthHandler(role)
+	if reporter, ok := b.events.(messaging.StatsReporter); ok {
+		health.AddMetrics("event_bus", func(context.Context) (interface{}, error) {
+			return reporter.Stats(), nil
+		})
+	}
+
+	registrations := services.NewQueue[*models.User](b.jobs, services.RegistrationJobType, newLanes(cfg)...)
+	a.registrationQueue = registrations
+
+	if role != roleAPI {
+		a.jobs = services.NewJobRunner()
+		a.registrar = services.NewRegistrationWorker(userService, apiEmitter, b.deadLetters, retryPolicy)
+		a.registrations = services.RegisterJob(a.jobs, services.JobHandler[*models.User]{
+			Queue:        registrations,
+			Handle:       a.registrar.Handle,
+			DeadLetter:   a.registrar.DeadLetter,
+			BatchSize:    cfg.Workers.BatchSize,
+			Timeout:      cfg.Workers.VisibilityTimeout,
+			PollInterval: cfg.Workers.BatchTimeout,
+			Concurrency:  cfg.Workers.PoolSize,
+			RetryPolicy:  retryPolicy,
+		}, b.deadLetters)
+		a.registrations.SetScaling(newWorkerPoolScaling(cfg))
+
+		health.AddCheck("jobs", func(context.Context) error {
+			return a.jobs.Healthy()
+		})
+		health.AddMetrics("jobs", func(ctx context.Context) (interface{}, error) {
+			return a.jobs.Stats(ctx)
+		})
+		health.AddMetrics("registration_worker", func(context.Context) (interface{}, error) {
+			return a.registrar.Stats(), nil
+		})
+
+		// Com CDC, as mudanças de estado são publicadas a partir do change stream
+		if cfg.CDC.Enabled {
+			elector := services.NewLeaderElector(b.leases, services.ChangeStreamCheckpoint, cfg.CDC.LeaseTTL)
+			changeStream := services.NewChangeStreamPublisher(b.userChanges, b.checkpoints, emitter, elector, retryPolicy)
+			health.AddMetrics("change_stream", func(context.Context) (interface{}, error) {
+				return changeStream.Stats(), nil
+			})
+			a.changeStream = changeStream
+		}
+
+		if cfg.Scheduler.Enabled {
+			if a.scheduler, err = newScheduler(cfg, b, deadLetterService); err != nil {
+				return nil, fmt.Errorf("configuring the scheduler: %w", err)
+			}
+			health.AddMetrics("scheduler", func(ctx context.Context) (interface{}, error) {
+				return a.scheduler.List(ctx)
+			})
+		}
+	}
+
+	a.logs = logging.NewWriter(os.Stderr, cfg.Server.LogLevel)
+	logging.Install(a.logs)
+	gin.SetMode(ginMode(cfg))
+
+	translator, err := i18n.New(cfg.I18n.DefaultLocale)
+	if err != nil {
+		return nil, fmt.Errorf("configuring i18n: %w", err)
+	}
+	utils.UseJSONFieldNames()
+	utils.UseTranslator(translator)
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		if err := translator.RegisterValidator(v); err != nil {
+			return nil, fmt.Errorf("configuring i18n: %w", err)
+		}
+	}
+
+	router := gin.New()
+	router.Use(gin.LoggerWithConfig(gin.LoggerConfig{
+		// O log de acesso é informativo: some com LOG_LEVEL=warn ou error
+		Skip: func(*gin.Context) bool { return !a.logs.Enabled(logging.Info) },
+	}), gin.Recovery())
+	router.Use(middleware.RequestID(), middleware.Locale(translator))
+	a.router = router
+
+	auth := middleware.AuthMiddleware(authService, userService)
+	workerPoolHandler := handlers.NewWorkerPoolHandler(a.jobs)
+	scheduleHandler := handlers.NewScheduleHandler(a.scheduler)
+	if role == roleWorker {
+		setupWorkerRoutes(router, health, workerPoolHandler, scheduleHandler, auth)
+		return a, nil
+	}
+
+	// Instalado sempre em development, para que OPENAPI_VALIDATE possa ser recarregado
+	if cfg.Server.Mode == "development" {
+		a.validateAPI.Store(cfg.Server.OpenAPIValidate)
+		validate := openapi.Validator(openapi.Spec())
+		router.Use(func(c *gin.Context) {
+			if a.validateAPI.Load() {
+				validate(c)
+				return
+			}
+			c.Next()
+		})
+	}
+
+	submitter := services.NewRegistrationSubmitter(userService, registrations)
+	health.AddMetrics("registrations", func(ctx context.Context) (interface{}, error) {
+		return submitter.Stats(ctx)
+	})
+
+	authHandler := handlers.NewAuthHandler(userService)
+	fairKey, err := middleware.KeyFuncByName(cfg.Workers.FairKey)
+	if err != nil {
+		return nil, fmt.Errorf("configuring the registration queue: %w", err)
+	}
+	userHandler := handlers.NewUserHandler(submitter, fairKey, cfg.Workers.PriorityAPIKeys())
+	adminHandler := handlers.NewAdminHandler(deadLetterService, b.events)
+
+	idempotency := middleware.Idempotency(b.idempotency, cfg.Idempotency.TTL)
+	docs := openapi.DocsHandler(cfg.Server.DocsScript, cfg.Server.DocsIntegrity)
+
+	if a.rateLimitStore, err = newRateLimitStore(cfg, b.rateLimits); err != nil {
+		return nil, fmt.Errorf("configuring rate limits: %w", err)
+	}
+	handlers, err := rateLimitHandlers(cfg.RateLimit, a.rateLimitStore)
+	if err != nil {
+		return nil, fmt.Errorf("configuring rate limits: %w", err)
+	}
+	a.rateLimits = middleware.NewRateLimiters(handlers)
+
+	setupRoutes(router, health, authHandler, userHandler, adminHandler, workerPoolHandler, scheduleHandler, auth, idempotency, docs, a.rateLimits, cfg.Events.SchemaBaseURL)
+	return a, nil
+}
+
+func newRetryPolicy(cfg *config.Config) services.RetryPolicy {
+	return services.RetryPolicy{
+		MaxAttempts: cfg.Workers.RetryMaxAttempts,
+		BaseDelay:   cfg.Workers.RetryBaseDelay,
+		MaxDelay:    cfg.Workers.RetryMaxDelay,
+	}
+}
+
+func newLanes(cfg *config.Config) []services.Lane {
+	return []services.Lane{
+		{Name: models.JobLaneHigh, Weight: cfg.Workers.LaneHigh.Weight, Capacity: cfg.Workers.LaneHigh.Capacity},
+		{Name: models.JobLaneNormal, Weight: cfg.Workers.LaneNormal.Weight, Capacity: cfg.Workers.LaneNormal.Capacity},
+		{Name: models.JobLaneLow, Weight: cfg.Workers.LaneLow.Weight, Capacity: cfg.Workers.LaneLow.Capacity},
+	}
+}
+
+// newScheduler registra os jobs de manutenção com uma expressão cron configurada.
+func newScheduler(cfg *config.Config, b *backend, deadLetters *services.DeadLetterService) (*services.Scheduler, error) {
+	location, err := time.LoadLocation(cfg.Scheduler.Timezone)
+	if err != nil {
+		return nil, err
+	}
+	scheduler := services.NewScheduler(b.schedules, b.leases, services.SchedulerOptions{
+		Location:         location,
+		Jitter:           cfg.Scheduler.Jitter,
+		LeaseTTL:         cfg.Scheduler.LeaseTTL,
+		Timeout:          cfg.Scheduler.Timeout,
+		HistoryRetention: cfg.Scheduler.HistoryRetention,
+	})
+
+	tasks := []services.ScheduledTask{
+		{
+			Name:     "retry-event-dead-letters",
+			Schedule: cfg.Scheduler.RetryEvents,
+			Run: func(ctx context.Context) (string, error) {
+				replayed, failed, err := deadLetters.RetryEvents(ctx, int64(cfg.Scheduler.RetryEventsLimit))
+				return fmt.Sprintf("%d events republished, %d failed again", replayed, failed), err
+			},
+		},
+		{
+			Name:     "purge-dead-letters",
+			Schedule: cfg.Scheduler.PurgeDeadLetters,
+			Run: func(ctx context.Context) (string, error) {
+				purged, err := deadLetters.Purge(ctx, time.Now().Add(-cfg.Scheduler.DeadLetterRetention))
+				return fmt.Sprintf("%d dead letters purged", purged), err
+			},
+		},
+	}
+	for _, task := range tasks {
+		if task.Schedule == "" {
+			continue
+		}
+		if err := scheduler.Add(task); err != nil {
+			return nil, err
+		}
+	}
+	return scheduler, nil
+}
+
+func newWorkerPoolScaling(cfg *config.Config) services.WorkerPoolScaling {
+	min, max := cfg.Workers.PoolBounds()
+	return services.WorkerPoolScaling{
+		Min:       min,
+		Max:       max,
+		Autoscale: cfg.Workers.Autoscale,
+		Interval:  cfg.Workers.AutoscaleInterval,
+	}
+}
+
+// newEmitters monta o emitter completo e o usado pela API e pelos comandos: com CDC,
+// as mudanças de estado são publicadas a partir do change stream, não por quem as faz.
+func newEmitters(cfg *config.Config, b *backend) (emitter, apiEmitter *services.EventEmitter, err error) {
+	registrations, lifecycle, err := newEventPublishers(cfg, b)
+	if err != nil {
+		return nil, nil, err
+	}
+	emitter = services.NewEventEmitter(registrations, lifecycle, b.deadLetters)
+	if cfg.CDC.Enabled {
+		return emitter, emitter.WithoutStateChanges(), nil
+	}
+	return emitter, emitter, nil
+}
+
+// newEventPublishers monta os publishers de eventos de usuário: registros vão também
+// para o tópico de eventos, que recebe todo o ciclo de vida.
+func newEventPublishers(cfg *config.Config, b *backend) (registrations, lifecycle *events.Publisher, err error) {
+	registry, err := events.NewFileSchemaRegistry(cfg.Events.RegistryPath, events.Compatibility(cfg.Events.Compatibility))
+	if err != nil {
+		return nil, nil, fmt.Errorf("configuring schema registry: %w", err)
+	}
+	serializer, err := events.NewSerializer(events.Format(cfg.Events.Format), registry)
+	if err != nil {
+		return nil, nil, fmt.Errorf("configuring events: %w", err)
+	}
+	encoder, err := events.NewEncoder(events.Mode(cfg.Events.Mode), cfg.Events.Source, cfg.Events.SchemaBaseURL, serializer)
+	if err != nil {
+		return nil, nil, fmt.Errorf("configuring events: %w", err)
+	}
+
+	registrations = events.NewPublisher(b.events, encoder, cfg.Kafka.TopicUserRegistration, cfg.Kafka.TopicUserEvents)
+	lifecycle = events.NewPublisher(b.events, encoder, cfg.Kafka.TopicUserEvents)
+	return registrations, lifecycle, nil
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lucas/go-rest-api-mongo/internal/config"
+	"github.com/lucas/go-rest-api-mongo/pkg/logging"
+)
+
+func TestNewAppRoles(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := memoryHarness{}.Config(t)
+	b, err := newBackend(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("newBackend() error = %v", err)
+	}
+	defer b.Close(context.Background())
+
+	// A fila em memória não é compartilhada entre processos
+	for _, role := range []string{roleAPI, roleWorker, "scheduler"} {
+		cfg.Server.Role = role
+		if _, err := newApp(cfg, b); err == nil {
+			t.Errorf("newApp(role %s) with the memory driver must fail", role)
+		}
+	}
+
+	cfg.Server.Role = roleAll
+	// Só os jobs agendados com uma expressão cron são registrados
+	cfg.Scheduler = config.SchedulerConfig{Enabled: true, Timezone: "UTC", LeaseTTL: time.Second, RetryEvents: "*/15 * * * *"}
+	a, err := newApp(cfg, b)
+	if err != nil {
+		t.Fatalf("newApp() error = %v", err)
+	}
+	if a.jobs == nil || a.changeStream != nil {
+		t.Fatal("role all must run the job workers, and the change stream only with CDC")
+	}
+	if schedules, err := a.scheduler.List(context.Background()); err != nil || len(schedules) != 1 || schedules[0].Name != "retry-event-dead-letters" {
+		t.Errorf("schedules = %+v, %v, want only the event retry", schedules, err)
+	}
+
+	w := httptest.NewRecorder()
+	a.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	var metrics struct {
+		Role    string                     `json:"role"`
+		Metrics map[string]json.RawMessage `json:"metrics"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &metrics); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("metrics status = %d, body = %s", w.Code, w.Body)
+	}
+	if metrics.Role != roleAll || metrics.Metrics["jobs"] == nil || metrics.Metrics["registrations"] == nil || metrics.Metrics["scheduler"] == nil {
+		t.Errorf("metrics = %s, want the jobs, the registrations and the scheduler of role all", w.Body)
+	}
+}
+
+func TestAppReconfigureRateLimits(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := memoryHarness{}.Config(t)
+	b, err := newBackend(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("newBackend() error = %v", err)
+	}
+	defer b.Close(context.Background())
+
+	a, err := newApp(cfg, b)
+	if err != nil {
+		t.Fatalf("newApp() error = %v", err)
+	}
+	login := func() int {
+		w := httptest.NewRecorder()
+		a.router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/login", nil))
+		return w.Code
+	}
+	if code := login(); code == http.StatusTooManyRequests {
+		t.Fatal("rate limits are disabled in the test config")
+	}
+
+	reloaded := *cfg
+	reloaded.RateLimit.Enabled = true
+	reloaded.RateLimit.Auth = config.RateLimitPolicyConfig{Requests: 1, Window: time.Minute, KeyBy: "ip"}
+	a.Reconfigure(cfg, &reloaded)
+
+	login()
+	if code := login(); code != http.StatusTooManyRequests {
+		t.Errorf("second login after the reload = %d, want 429", code)
+	}
+}
+
+func TestAppReconfigureLogLevel(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := memoryHarness{}.Config(t)
+	b, err := newBackend(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("newBackend() error = %v", err)
+	}
+	defer b.Close(context.Background())
+
+	a, err := newApp(cfg, b)
+	if err != nil {
+		t.Fatalf("newApp() error = %v", err)
+	}
+	defer gin.SetMode(gin.TestMode)
+	if !a.logs.Enabled(logging.Info) {
+		t.Fatal("info logs are disabled with the default level")
+	}
+
+	reloaded := *cfg
+	reloaded.Server.LogLevel = logging.Error
+	a.Reconfigure(cfg, &reloaded)
+	if a.logs.Enabled(logging.Warn) || gin.Mode() != gin.ReleaseMode {
+		t.Errorf("after LOG_LEVEL=error: warn logs enabled = %t, gin mode %q", a.logs.Enabled(logging.Warn), gin.Mode())
+	}
+
+	debug := reloaded
+	debug.Server.LogLevel = logging.Debug
+	a.Reconfigure(&reloaded, &debug)
+	if !a.logs.Enabled(logging.Debug) || gin.Mode() != gin.DebugMode {
+		t.Errorf("after LOG_LEVEL=debug: debug logs enabled = %t, gin mode %q", a.logs.Enabled(logging.Debug), gin.Mode())
+	}
+}
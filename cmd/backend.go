@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/lucas/go-rest-api-mongo/internal/config"
+	"github.com/lucas/go-rest-api-mongo/internal/database"
+	"github.com/lucas/go-rest-api-mongo/internal/messaging"
+	"github.com/lucas/go-rest-api-mongo/internal/middleware"
+	"github.com/lucas/go-rest-api-mongo/internal/repositories"
+)
+
+// backend agrupa os stores e o event bus escolhidos por STORAGE_DRIVER e
+// EVENT_BUS_DRIVER. Com "memory" nos dois, a aplicação roda sem Mongo e sem Kafka.
+type backend struct {
+	users       repositories.UserStore
+	deadLetters repositories.DeadLetterStore
+	checkpoints repositories.CheckpointStore
+	// jobs são as filas de jobs entre a API e os workers; só as do Mongo são
+	// compartilhadas entre processos
+	jobs        repositories.JobStore
+	sharedQueue bool
+	leases      repositories.LeaseStore
+	schedules   repositories.ScheduleStore
+	userChanges repositories.UserChangeStream
+	idempotency middleware.IdempotencyStore
+	rateLimits  middleware.RateLimitStore // nil quando não há store compartilhado
+	events      messaging.EventBus
+	closers     []func(ctx context.Context) error
+
+	// Só com o driver mongo: a conexão, trocada quando as credenciais mudam, e os stores
+	// com índices e a coleção de usuários, usados por migrate
+	mongo          *database.MongoDB
+	indexed        []interface{ EnsureIndexes(context.Context) error }
+	userRepository *repositories.UserRepository
+}
+
+func newBackend(ctx context.Context, cfg *config.Config) (*backend, error) {
+	b := &backend{}
+
+	switch cfg.Database.Driver {
+	case "", "mongo":
+		db, err := database.NewMongoDB(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("connecting to database: %w", err)
+		}
+		b.mongo = db
+		b.closers = append(b.closers, db.Close)
+		log.Println("✅ Connected to MongoDB")
+
+		userRepository := repositories.NewUserRepository(db)
+		idempotencyRepository := repositories.NewIdempotencyRepository(db)
+		rateLimitRepository := repositories.NewRateLimitRepository(db)
+		jobRepository := repositories.NewJobRepository(db)
+		scheduleRepository := repositories.NewScheduleRepository(db)
+		b.userRepository = userRepository
+		b.indexed = []interface{ EnsureIndexes(context.Context) error }{
+			userRepository, idempotencyRepository, rateLimitRepository, jobRepository, scheduleRepository,
+		}
+
+		if cfg.Database.AutoMigrate {
+			indexCtx, indexCancel := context.WithTimeout(ctx, cfg.Database.Timeout)
+			err := b.EnsureIndexes(indexCtx)
+			indexCancel()
+			if err != nil {
+				b.Close(ctx)
+				return nil, err
+			}
+		}
+		if cfg.CDC.Enabled {
+			if err := userRepository.EnableChangeStreamPreImages(ctx); err != nil {
+				log.Printf("⚠️  Change stream pre-images unavailable, deleted users will have no email in events: %v\n", err)
+			}
+		}
+
+		b.users = userRepository
+		b.userChanges = userRepository
+		b.leases = repositories.NewLeaseRepository(db)
+		b.schedules = scheduleRepository
+		b.deadLetters = repositories.NewDeadLetterRepository(db)
+		b.checkpoints = repositories.NewCheckpointRepository(db)
+		b.jobs = jobRepository
+		b.sharedQueue = true
+		b.idempotency = idempotencyRepository
+		b.rateLimits = rateLimitRepository
+
+	case "memory":
+		userRepository := repositories.NewMemoryUserRepository()
+		b.users = userRepository
+		b.userChanges = userRepository
+		b.leases = repositories.NewMemoryLeaseRepository()
+		b.schedules = repositories.NewMemoryScheduleRepository()
+		b.deadLetters = repositories.NewMemoryDeadLetterRepository()
+		b.checkpoints = repositories.NewMemoryCheckpointRepository()
+		// Acima da capacidade de um tipo, o Enqueue falha (o /register-fast responde 503)
+		b.jobs = repositories.NewMemoryJobRepository(cfg.Workers.QueueCapacity)
+		b.idempotency = repositories.NewMemoryIdempotencyRepository()
+		log.Println("⚠️  Using in-memory storage: data is lost on restart")
+
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q", cfg.Database.Driver)
+	}
+
+	switch cfg.Events.Driver {
+	case "", "kafka":
+		bus, err := messaging.NewKafkaBus(cfg)
+		if err != nil {
+			b.Close(ctx)
+			return nil, fmt.Errorf("configuring kafka: %w", err)
+		}
+		b.events = bus
+		log.Printf("✅ Kafka event bus initialized (acks=%s, compression=%s, async=%t)\n", cfg.Kafka.Acks, cfg.Kafka.Compression, cfg.Kafka.Async)
+
+	case "memory":
+		b.events = messaging.NewMemoryBus(cfg.Events.MemoryRetention)
+		log.Println("⚠️  Using in-process event bus: events are not delivered outside this process")
+
+	case "file":
+		bus, err := messaging.NewFileBus(cfg.Events.FilePath)
+		if err != nil {
+			b.Close(ctx)
+			return nil, err
+		}
+		b.events = bus
+		log.Printf("⚠️  Using file event bus: events are appended to %s\n", cfg.Events.FilePath)
+
+	default:
+		b.Close(ctx)
+		return nil, fmt.Errorf("unknown event bus driver %q", cfg.Events.Driver)
+	}
+	b.closers = append(b.closers, func(context.Context) error { return b.events.Close() })
+
+	return b, nil
+}
+
+// EnsureIndexes cria os índices de todos os stores do Mongo. Sem Mongo, não faz nada.
+func (b *backend) EnsureIndexes(ctx context.Context) error {
+	for _, store := range b.indexed {
+		if err := store.EnsureIndexes(ctx); err != nil {
+			return fmt.Errorf("creating indexes: %w", err)
+		}
+	}
+	return nil
+}
+
+// Close fecha as conexões na ordem inversa da abertura.
+func (b *backend) Close(ctx context.Context) {
+	for i := len(b.closers) - 1; i >= 0; i-- {
+		if err := b.closers[i](ctx); err != nil {
+			log.Printf("Error closing backend: %v\n", err)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/lucas/go-rest-api-mongo/internal/config"
+)
+
+// command é um subcomando do binário. run recebe os argumentos depois do nome.
+type command struct {
+	name    string
+	summary string
+	run     func(args []string) error
+}
+
+// commands é a tabela de subcomandos, na ordem em que aparecem na ajuda.
+var commands []command
+
+func init() {
+	commands = []command{
+		{"serve", "Start the process of a role: api, worker or all (default)", runServe},
+		{"worker", "Start the worker role: background workers without the HTTP API", runWorker},
+		{"migrate", "Create the MongoDB indexes and enable change stream pre-images", runMigrate},
+		{"user", "Manage users: create, disable, enable, set-role, reset-password", runUser},
+		{"token", "Mint or inspect JWTs: mint, inspect", runToken},
+		{"config", "Show the effective configuration: print", runConfig},
+		{"secrets", "Manage the encrypted secrets keystore: set, list, rm", runSecrets},
+		{"backfill", "Republish lifecycle events of existing users", runBackfill},
+	}
+}
+
+// errUsage indica que a ajuda já foi exibida; o processo termina com código 2.
+var errUsage = errors.New("usage")
+
+// run executa o subcomando de args. Sem argumentos (ou começando com uma flag), o
+// servidor é iniciado, como antes dos subcomandos existirem.
+func run(args []string) error {
+	if len(args) == 0 || (strings.HasPrefix(args[0], "-") && !isHelp(args[0])) {
+		return runServe(args)
+	}
+	if isHelp(args[0]) || args[0] == "help" {
+		printUsage(os.Stdout)
+		return nil
+	}
+
+	for _, cmd := range commands {
+		if cmd.name == args[0] {
+			return cmd.run(args[1:])
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "unknown command %q\n\n", args[0])
+	printUsage(os.Stderr)
+	return errUsage
+}
+
+func isHelp(arg string) bool {
+	return arg == "-h" || arg == "-help" || arg == "--help"
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprint(w, "Usage: app <command> [flags]\n\nCommands:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-10s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprint(w, "\nRun \"app <command> -h\" for the flags of a command.\n")
+}
+
+// runGroup executa o subcomando de um grupo (ex.: "user create"); usage lista os
+// subcomandos do grupo.
+func runGroup(group, usage string, args []string, subcommands map[string]func(args []string) error) error {
+	if len(args) == 0 || isHelp(args[0]) {
+		fmt.Fprint(os.Stderr, usage)
+		return errUsage
+	}
+	run, ok := subcommands[args[0]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown %s command %q\n\n%s", group, args[0], usage)
+		return errUsage
+	}
+	return run(args[1:])
+}
+
+// newFlagSet cria as flags de um subcomando; -h mostra usage seguido das flags.
+func newFlagSet(name, usage string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), usage)
+		flags.PrintDefaults()
+	}
+	return flags
+}
+
+// configFlags registra as flags que escolhem as camadas da configuração: -config,
+// -profile e -set, que tem precedência sobre os arquivos e as variáveis de ambiente.
+func configFlags(flags *flag.FlagSet) *config.Options {
+	opts := &config.Options{Overrides: map[string]string{}}
+	flags.StringVar(&opts.File, "config", "", "config `file`, YAML or TOML (default: CONFIG_FILE or ./config.yaml if present)")
+	flags.StringVar(&opts.Profile, "profile", "", "`profile` merged over the config file, e.g. production for config.production.yaml (default: CONFIG_PROFILE)")
+	flags.Func("set", "override a setting by its environment variable, e.g. -set WORKER_POOL_SIZE=10 (repeatable)", func(value string) error {
+		name, setting, ok := strings.Cut(value, "=")
+		if !ok || name == "" {
+			return fmt.Errorf("expected NAME=VALUE, got %q", value)
+		}
+		opts.Overrides[name] = setting
+		return nil
+	})
+	return opts
+}
+
+// signalContext é cancelado com SIGINT ou SIGTERM.
+func signalContext() (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+}
+
+// openBackend carrega a configuração e conecta ao backend. closeBackend deve ser
+// chamado ao final, mesmo depois de um cancelamento de ctx.
+func openBackend(ctx context.Context, opts *config.Options) (cfg *config.Config, b *backend, closeBackend func(), err error) {
+	cfg, err = config.Load(*opts)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("loading config: %w", err)
+	}
+	b, closeBackend, err = connectBackend(ctx, cfg)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return cfg, b, closeBackend, nil
+}
+
+// connectBackend conecta ao backend de cfg; closeBackend deve ser chamado ao final.
+func connectBackend(ctx context.Context, cfg *config.Config) (b *backend, closeBackend func(), err error) {
+	b, err = newBackend(ctx, cfg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("initializing backend: %w", err)
+	}
+
+	closeBackend = func() {
+		closeCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		b.Close(closeCtx)
+	}
+	return b, closeBackend, nil
+}
+
+// printJSON escreve v indentado na saída padrão, para ser lido por pessoas e por jq.
+func printJSON(v interface{}) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/lucas/go-rest-api-mongo/internal/config"
+)
+
+const configUsage = `Usage: app config print
+
+Prints the effective configuration as JSON, with secrets masked. Each setting
+comes from the first layer that sets it: -set flags, environment variables, .env,
+the profile file, the config file, then the defaults.
+
+`
+
+// runConfig implementa o subcomando "config".
+func runConfig(args []string) error {
+	return runGroup("config", configUsage, args, map[string]func(args []string) error{
+		"print": runConfigPrint,
+	})
+}
+
+func runConfigPrint(args []string) error {
+	flags := newFlagSet("config print", configUsage+"Flags:\n")
+	opts := configFlags(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	cfg, err := config.Load(*opts)
+	if err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+	return printJSON(cfg.Redacted())
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lucas/go-rest-api-mongo/internal/events"
+)
+
+var e2eSeq atomic.Int64
+
+// uniqueEmail evita colisões entre execuções contra um backend real compartilhado.
+func uniqueEmail(prefix string) string {
+	return fmt.Sprintf("%s.%d.%d@example.com", prefix, time.Now().UnixNano(), e2eSeq.Add(1))
+}
+
+func registerUser(t *testing.T, a *e2eApp, email string) {
+	t.Helper()
+	w := a.do(http.MethodPost, "/api/v1/register", map[string]string{
+		"name": "E2E User", "email": email, "password": "secret123",
+	}, nil)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("register status = %d, body = %s", w.Code, w.Body)
+	}
+}
+
+func loginToken(t *testing.T, a *e2eApp, email string) string {
+	t.Helper()
+	w := a.do(http.MethodPost, "/api/v1/login", map[string]string{"email": email, "password": "secret123"}, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("login status = %d, body = %s", w.Code, w.Body)
+	}
+	token, _ := w.JSON(t)["token"].(string)
+	if token == "" {
+		t.Fatalf("login returned no token: %s", w.Body)
+	}
+	return token
+}
+
+func assertProblem(t *testing.T, w e2eResponse, status int, code string) map[string]interface{} {
+	t.Helper()
+	if w.Code != status {
+		t.Fatalf("status = %d, want %d, body = %s", w.Code, status, w.Body)
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/problem+json") {
+		t.Fatalf("Content-Type = %q, want application/problem+json", ct)
+	}
+	body := w.JSON(t)
+	if code != "" && body["code"] != code {
+		t.Fatalf("code = %v, want %s", body["code"], code)
+	}
+	return body
+}
+
+func TestE2E(t *testing.T) {
+	harness := newE2EHarness()
+	t.Logf("running end-to-end suite against the %s backend", harness.Name())
+	a := startE2EApp(t, harness)
+
+	t.Run("health", func(t *testing.T) {
+		w := a.do(http.MethodGet, "/health", nil, nil)
+		body := w.JSON(t)
+		if w.Code != http.StatusOK || body["status"] != "ok" || body["role"] != "all" {
+			t.Fatalf("health status = %d, body = %s", w.Code, w.Body)
+		}
+		checks, _ := body["checks"].(map[string]interface{})
+		if checks["jobs"] != "ok" {
+			t.Fatalf("health checks = %v, want the job workers", body["checks"])
+		}
+	})
+
+	t.Run("register and reject duplicate email", func(t *testing.T) {
+		email := uniqueEmail("dup")
+		registerUser(t, a, email)
+
+		w := a.do(http.MethodPost, "/api/v1/register", map[string]string{
+			"name": "E2E User", "email": email, "password": "secret123",
+		}, nil)
+		assertProblem(t, w, http.StatusConflict, "conflict")
+	})
+
+	t.Run("login and profile", func(t *testing.T) {
+		email := uniqueEmail("login")
+		registerUser(t, a, email)
+		token := loginToken(t, a, email)
+
+		w := a.do(http.MethodGet, "/api/v1/profile", nil, bearer(token))
+		if w.Code != http.StatusOK {
+			t.Fatalf("profile status = %d, body = %s", w.Code, w.Body)
+		}
+		if w.JSON(t)["email"] != email {
+			t.Fatalf("profile = %s, want email %s", w.Body, email)
+		}
+	})
+
+	t.Run("login with wrong password", func(t *testing.T) {
+		email := uniqueEmail("wrong")
+		registerUser(t, a, email)
+
+		w := a.do(http.MethodPost, "/api/v1/login", map[string]string{"email": email, "password": "nope12345"}, nil)
+		assertProblem(t, w, http.StatusUnauthorized, "")
+	})
+
+	t.Run("profile without or with invalid token", func(t *testing.T) {
+		assertProblem(t, a.do(http.MethodGet, "/api/v1/profile", nil, nil), http.StatusUnauthorized, "")
+		assertProblem(t, a.do(http.MethodGet, "/api/v1/profile", nil, bearer("not-a-jwt")), http.StatusUnauthorized, "")
+	})
+
+	t.Run("validation errors", func(t *testing.T) {
+		w := a.do(http.MethodPost, "/api/v1/register", `{"name":"","email":"invalid","password":"1"}`, nil)
+		body := assertProblem(t, w, http.StatusBadRequest, "")
+		errs, _ := body["errors"].([]interface{})
+		if len(errs) != 3 {
+			t.Fatalf("errors = %v, want 3 field errors", body["errors"])
+		}
+	})
+
+	t.Run("async registration publishes event", func(t *testing.T) {
+		email := uniqueEmail("fast")
+		w := a.do(http.MethodPost, "/api/v1/register-fast", map[string]string{
+			"name": "E2E User", "email": email, "password": "secret123",
+		}, map[string]string{"X-Priority": "high"})
+		if w.Code != http.StatusAccepted {
+			t.Fatalf("register-fast status = %d, body = %s", w.Code, w.Body)
+		}
+
+		w = a.do(http.MethodPost, "/api/v1/register-fast", map[string]string{
+			"name": "E2E User", "email": uniqueEmail("fast-urgent"), "password": "secret123",
+		}, map[string]string{"X-Priority": "urgent"})
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("register-fast with an unknown priority status = %d, body = %s", w.Code, w.Body)
+		}
+
+		event := a.WaitForEvent(t, email)
+		if event["type"] != events.TypeUserRegistered || event["user_id"] == "" {
+			t.Fatalf("event = %v", event)
+		}
+
+		// O evento é publicado depois da persistência, então o login já deve funcionar
+		loginToken(t, a, email)
+	})
+
+	t.Run("user lifecycle is published to the events topic", func(t *testing.T) {
+		email := uniqueEmail("lifecycle")
+		registerUser(t, a, email)
+		a.do(http.MethodPost, "/api/v1/login", map[string]string{"email": email, "password": "wrong-password"}, nil)
+		token := loginToken(t, a, email)
+
+		newEmail := uniqueEmail("lifecycle-renamed")
+		w := a.do(http.MethodPatch, "/api/v1/profile", map[string]string{"email": newEmail}, bearer(token))
+		if w.Code != http.StatusOK || w.JSON(t)["email"] != newEmail {
+			t.Fatalf("update profile status = %d, body = %s", w.Code, w.Body)
+		}
+
+		w = a.do(http.MethodPut, "/api/v1/profile/password", map[string]string{"current_password": "wrong-password", "new_password": "secret456"}, bearer(token))
+		assertProblem(t, w, http.StatusForbidden, "forbidden")
+		w = a.do(http.MethodPut, "/api/v1/profile/password", map[string]string{"current_password": "secret123", "new_password": "secret456"}, bearer(token))
+		if w.Code != http.StatusOK {
+			t.Fatalf("change password status = %d, body = %s", w.Code, w.Body)
+		}
+
+		w = a.do(http.MethodDelete, "/api/v1/profile", nil, bearer(token))
+		if w.Code != http.StatusNoContent {
+			t.Fatalf("delete profile status = %d, body = %s", w.Code, w.Body)
+		}
+		// O token do usuário apagado deixa de valer
+		w = a.do(http.MethodGet, "/api/v1/profile", nil, bearer(token))
+		assertProblem(t, w, http.StatusUnauthorized, "unauthorized")
+
+		want := []string{
+			events.TypeUserRegistered,
+			events.TypeUserLoginFailed,
+			events.TypeUserLoggedIn,
+			events.TypeUserUpdated,
+			events.TypeUserPasswordChanged,
+			events.TypeUserDeleted,
+		}
+		var userID interface{}
+		got := a.harness.WaitForEvents(t, a, a.cfg.Kafka.TopicUserEvents, len(want), func(event map[string]interface{}) bool {
+			if event["type"] == events.TypeUserRegistered && event["email"] == email {
+				userID = event["user_id"]
+			}
+			return userID != nil && event["user_id"] == userID
+		})
+		for i, event := range got {
+			if event["type"] != want[i] {
+				t.Errorf("event %d type = %v, want %s", i, event["type"], want[i])
+			}
+		}
+	})
+
+	t.Run("idempotent replay", func(t *testing.T) {
+		body := map[string]string{"name": "E2E User", "email": uniqueEmail("idem"), "password": "secret123"}
+		headers := map[string]string{"Idempotency-Key": uniqueEmail("key")}
+
+		first := a.do(http.MethodPost, "/api/v1/register", body, headers)
+		if first.Code != http.StatusCreated {
+			t.Fatalf("first status = %d, body = %s", first.Code, first.Body)
+		}
+
+		second := a.do(http.MethodPost, "/api/v1/register", body, headers)
+		if second.Code != http.StatusCreated || second.Header().Get("Idempotency-Replayed") != "true" {
+			t.Fatalf("replay status = %d, headers = %v", second.Code, second.Header())
+		}
+		if second.Body.String() != first.Body.String() {
+			t.Fatalf("replayed body = %s, want %s", second.Body, first.Body)
+		}
+	})
+
+	t.Run("admin routes require admin role", func(t *testing.T) {
+		email := uniqueEmail("admin")
+		registerUser(t, a, email)
+		token := loginToken(t, a, email)
+
+		assertProblem(t, a.do(http.MethodGet, "/api/v1/admin/dead-letters", nil, bearer(token)), http.StatusForbidden, "")
+	})
+
+	t.Run("event schemas", func(t *testing.T) {
+		w := a.do(http.MethodGet, "/schemas/events/"+events.SchemaName(events.TypeUserRegistered), nil, nil)
+		if w.Code != http.StatusOK || w.JSON(t)["title"] != "UserRegistered" {
+			t.Fatalf("schema status = %d, body = %s", w.Code, w.Body)
+		}
+		assertProblem(t, a.do(http.MethodGet, "/schemas/events/nope", nil, nil), http.StatusNotFound, "not_found")
+	})
+
+	t.Run("unknown route", func(t *testing.T) {
+		assertProblem(t, a.do(http.MethodGet, "/api/v1/nope", nil, nil), http.StatusNotFound, "not_found")
+	})
+}
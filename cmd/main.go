@@ -2,98 +2,152 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
-	"os/signal"
-	"syscall"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/lucas/go-rest-api-mongo/internal/config"
-	"github.com/lucas/go-rest-api-mongo/internal/database"
+	"github.com/lucas/go-rest-api-mongo/internal/events"
 	"github.com/lucas/go-rest-api-mongo/internal/handlers"
-	"github.com/lucas/go-rest-api-mongo/internal/messaging"
 	"github.com/lucas/go-rest-api-mongo/internal/middleware"
-	"github.com/lucas/go-rest-api-mongo/internal/repositories"
+	"github.com/lucas/go-rest-api-mongo/internal/models"
+	"github.com/lucas/go-rest-api-mongo/internal/openapi"
 	"github.com/lucas/go-rest-api-mongo/internal/services"
+	"github.com/lucas/go-rest-api-mongo/pkg/utils"
 )
 
 func main() {
-	cfg, err := config.Load()
-	if err != nil {
-		log.Fatalf("Error loading config: %v\n", err)
+	if err := run(os.Args[1:]); err != nil {
+		if errors.Is(err, errUsage) {
+			os.Exit(2)
+		}
+		log.Fatalf("Error: %v\n", err)
 	}
+}
 
-	db, err := database.NewMongoDB(cfg)
-	if err != nil {
-		log.Fatalf("Error connecting to database: %v\n", err)
+const serveUsage = `Usage: app serve [-role api|worker|all]
+
+Starts the process of a role, by default APP_ROLE:
+  api     the HTTP API; /register-fast only enqueues registrations
+  worker  the job worker pools, the maintenance scheduler and, with CDC_ENABLED, the
+          change stream publisher, with only /health, /metrics,
+          /api/v1/admin/workers[/:type] and /api/v1/admin/schedules[/...] on SERVER_PORT
+  all     both in one process (the default)
+api and worker need STORAGE_DRIVER=mongo, where the shared job queues live. This is
+the default command.
+
+Changes to the config files are applied without a restart when they only touch
+settings that can be reloaded (worker batching, retries and pool bounds, rate
+limits, secrets, OPENAPI_VALIDATE and LOG_LEVEL); other changes are rejected and
+logged.
+
+Flags:
+`
+
+// runServe implementa o subcomando "serve".
+func runServe(args []string) error {
+	flags := newFlagSet("serve", serveUsage)
+	opts := configFlags(flags)
+	role := flags.String("role", "", "process role (default: APP_ROLE)")
+	if err := flags.Parse(args); err != nil {
+		return err
 	}
-	defer func() {
-		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-		defer cancel()
-		if err := db.Close(ctx); err != nil {
-			log.Printf("Error closing database: %v\n", err)
-		}
-	}()
+	return serve(*role, opts)
+}
 
-	log.Println("✅ Connected to MongoDB")
+const workerUsage = `Usage: app worker
 
-	kafkaProducer := messaging.NewKafkaProducer(cfg)
-	defer kafkaProducer.Close()
+Same as "app serve -role worker".
 
-	log.Println("✅ Kafka Producer initialized")
+Flags:
+`
 
-	userRepository := repositories.NewUserRepository(db)
-	authService := services.NewAuthService(cfg)
-	userService := services.NewUserService(userRepository, authService)
+// runWorker implementa o subcomando "worker".
+func runWorker(args []string) error {
+	flags := newFlagSet("worker", workerUsage)
+	opts := configFlags(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	return serve(roleWorker, opts)
+}
 
-	workerPool := services.NewWorkerPool(
-		userService,
-		kafkaProducer,
-		cfg.Workers.PoolSize,
-		cfg.Workers.BatchSize,
-		cfg.Workers.BatchTimeout,
-	)
+// serve inicia o processo da role (a de APP_ROLE quando role é vazia) e espera um
+// SIGINT ou SIGTERM para parar: primeiro o servidor HTTP, depois os workers.
+func serve(role string, opts *config.Options) error {
+	ctx, stop := signalContext()
+	defer stop()
 
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+	loader, err := config.NewLoader(*opts)
+	if err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+	cfg := loader.Config()
 
-	workerPool.Start(ctx)
-	log.Printf("✅ Worker Pool started with %d workers\n", cfg.Workers.PoolSize)
+	b, closeBackend, err := connectBackend(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer closeBackend()
 
-	authHandler := handlers.NewAuthHandler(userService)
-	userHandler := handlers.NewUserHandler(workerPool)
+	if role != "" {
+		cfg.Server.Role = role
+	}
+	a, err := newApp(cfg, b)
+	if err != nil {
+		return fmt.Errorf("initializing app: %w", err)
+	}
 
-	if cfg.Server.Mode == "production" {
-		gin.SetMode(gin.ReleaseMode)
+	// Recarrega as opções seguras quando os arquivos de configuração mudam
+	loader.Subscribe(a.Reconfigure)
+	loader.Watch()
+	if files := loader.Files(); len(files) > 0 {
+		log.Printf("✅ Config loaded from %s, watching for changes\n", strings.Join(files, ", "))
 	}
 
-	router := gin.Default()
+	// Os workers não usam ctx: eles param só depois do servidor
+	workerCtx, cancelWorkers := context.WithCancel(context.Background())
+	defer cancelWorkers()
 
-	setupRoutes(router, authHandler, userHandler, authService)
+	a.Start(workerCtx)
+	if a.jobs != nil {
+		log.Printf("✅ Job workers started with %d %s workers\n", a.registrations.Size(), services.RegistrationJobType)
+	}
+	if a.changeStream != nil {
+		log.Println("✅ Change stream publisher started")
+	}
+	if a.scheduler != nil {
+		log.Printf("✅ Scheduler started (%s)\n", cfg.Scheduler.Timezone)
+	}
 
 	srv := &http.Server{
 		Addr:    fmt.Sprintf("%s:%s", cfg.Server.Host, cfg.Server.Port),
-		Handler: router,
+		Handler: a.router,
 	}
 
+	serveErr := make(chan error, 1)
 	go func() {
-		log.Printf("🚀 Server starting on %s:%s\n", cfg.Server.Host, cfg.Server.Port)
+		log.Printf("🚀 Server starting on %s:%s (role %s)\n", cfg.Server.Host, cfg.Server.Port, a.role)
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Error starting server: %v\n", err)
+			serveErr <- err
 		}
 	}()
 
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
+	select {
+	case <-ctx.Done():
+	case err := <-serveErr:
+		cancelWorkers()
+		a.Wait()
+		return fmt.Errorf("starting server: %w", err)
+	}
 
 	log.Println("🛑 Shutting down server...")
 
-	cancel()
-
 	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer shutdownCancel()
 
@@ -101,29 +155,133 @@ func main() {
 		log.Printf("Error during server shutdown: %v\n", err)
 	}
 
+	// Para os workers depois do servidor, para que o último lote seja processado
+	cancelWorkers()
+	a.Wait()
+
 	log.Println("✅ Server stopped gracefully")
+	return nil
 }
 
-func setupRoutes(router *gin.Engine, authHandler *handlers.AuthHandler, userHandler *handlers.UserHandler, authService *services.AuthService) {
-	// Health check
-	router.GET("/health", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+func setupRoutes(router *gin.Engine, health *handlers.HealthHandler, authHandler *handlers.AuthHandler, userHandler *handlers.UserHandler, adminHandler *handlers.AdminHandler, workerPoolHandler *handlers.WorkerPoolHandler, scheduleHandler *handlers.ScheduleHandler, auth gin.HandlerFunc, idempotency gin.HandlerFunc, docs gin.HandlerFunc, rateLimits *middleware.RateLimiters, schemaBaseURL string) {
+	router.NoRoute(func(c *gin.Context) {
+		utils.SendError(c, http.StatusNotFound, "not_found", "route not found")
 	})
 
+	// Health check e métricas da role
+	router.GET("/health", health.Health)
+	router.GET("/metrics", health.Metrics)
+
+	// Documentação (cada rota abaixo precisa de uma entrada em openapi.Spec)
+	router.GET("/openapi.json", openapi.Handler(openapi.Spec()))
+	router.GET("/docs", docs)
+
+	// JSON Schemas dos eventos publicados (atributo dataschema dos CloudEvents)
+	router.GET("/schemas/events", events.SchemaIndexHandler(schemaBaseURL))
+	router.GET("/schemas/events/:name", events.SchemaHandler())
+
 	// Rotas públicas (sem autenticação)
 	public := router.Group("/api/v1")
+	public.Use(rateLimits.For("auth"))
 	{
-		public.POST("/register", authHandler.Register)
+		public.POST("/register", idempotency, authHandler.Register)
 		public.POST("/login", authHandler.Login)
-		public.POST("/register-fast", userHandler.Register) // Assíncrono com Worker Pool
+		public.POST("/register-fast", idempotency, userHandler.Register) // Assíncrono com Worker Pool
 	}
 
 	// Rotas protegidas (com autenticação)
 	protected := router.Group("/api/v1")
-	protected.Use(middleware.AuthMiddleware(authService))
+	protected.Use(auth, rateLimits.For("api"))
 	{
 		protected.GET("/profile", authHandler.GetProfile)
+		protected.PATCH("/profile", authHandler.UpdateProfile)
+		protected.PUT("/profile/password", authHandler.ChangePassword)
+		protected.DELETE("/profile", authHandler.DeleteProfile)
+	}
+
+	// Rotas administrativas (autenticação + role admin)
+	admin := router.Group("/api/v1/admin")
+	admin.Use(auth, middleware.RequireRole(models.RoleAdmin), rateLimits.For("admin"))
+	{
+		admin.GET("/dead-letters", adminHandler.ListDeadLetters)
+		admin.GET("/dead-letters/:id", adminHandler.GetDeadLetter)
+		admin.POST("/dead-letters/:id/replay", adminHandler.ReplayDeadLetter)
+		admin.DELETE("/dead-letters/:id", adminHandler.DiscardDeadLetter)
+		admin.GET("/event-bus/stats", adminHandler.EventBusStats)
+		admin.GET("/workers", workerPoolHandler.GetWorkerPools)
+		admin.PUT("/workers/:type", workerPoolHandler.UpdateWorkerPool)
+		admin.GET("/schedules", scheduleHandler.ListSchedules)
+		admin.PUT("/schedules/:name", scheduleHandler.UpdateSchedule)
+		admin.POST("/schedules/:name/trigger", scheduleHandler.TriggerSchedule)
+		admin.GET("/schedules/:name/runs", scheduleHandler.ListScheduleRuns)
 	}
 
 	log.Println("✅ Routes configured")
 }
+
+// setupWorkerRoutes registra as rotas de um processo da role worker, que não serve a
+// API: só a saúde, as métricas, o tamanho dos seus worker pools e os jobs agendados.
+func setupWorkerRoutes(router *gin.Engine, health *handlers.HealthHandler, workerPoolHandler *handlers.WorkerPoolHandler, scheduleHandler *handlers.ScheduleHandler, auth gin.HandlerFunc) {
+	router.NoRoute(func(c *gin.Context) {
+		utils.SendError(c, http.StatusNotFound, "not_found", "route not found")
+	})
+
+	router.GET("/health", health.Health)
+	router.GET("/metrics", health.Metrics)
+
+	admin := router.Group("/api/v1/admin")
+	admin.Use(auth, middleware.RequireRole(models.RoleAdmin))
+	{
+		admin.GET("/workers", workerPoolHandler.GetWorkerPools)
+		admin.PUT("/workers/:type", workerPoolHandler.UpdateWorkerPool)
+		admin.GET("/schedules", scheduleHandler.ListSchedules)
+		admin.PUT("/schedules/:name", scheduleHandler.UpdateSchedule)
+		admin.POST("/schedules/:name/trigger", scheduleHandler.TriggerSchedule)
+		admin.GET("/schedules/:name/runs", scheduleHandler.ListScheduleRuns)
+	}
+}
+
+// newRateLimitStore escolhe o store dos contadores, que não muda em uma recarga da
+// configuração.
+func newRateLimitStore(cfg *config.Config, sharedStore middleware.RateLimitStore) (middleware.RateLimitStore, error) {
+	switch cfg.RateLimit.Store {
+	case "mongo":
+		if sharedStore == nil {
+			return nil, fmt.Errorf("rate limit store %q requires the mongo storage driver", cfg.RateLimit.Store)
+		}
+		return sharedStore, nil
+	case "", "memory":
+		return middleware.NewMemoryRateLimitStore(), nil
+	default:
+		return nil, fmt.Errorf("unknown rate limit store %q", cfg.RateLimit.Store)
+	}
+}
+
+// rateLimitHandlers monta os middlewares das policies de cfg; grupos com REQUESTS=0
+// ficam sem limite.
+func rateLimitHandlers(cfg config.RateLimitConfig, store middleware.RateLimitStore) (map[string]gin.HandlerFunc, error) {
+	handlers := map[string]gin.HandlerFunc{}
+	if !cfg.Enabled {
+		return handlers, nil
+	}
+
+	for group, policy := range cfg.Policies() {
+		if policy.Requests <= 0 || policy.Window <= 0 {
+			continue
+		}
+
+		keyBy, err := middleware.KeyFuncByName(policy.KeyBy)
+		if err != nil {
+			return nil, err
+		}
+
+		handlers[group] = middleware.RateLimit(middleware.RateLimitPolicy{
+			Name:   group,
+			Limit:  policy.Requests,
+			Window: policy.Window,
+			KeyBy:  keyBy,
+		}, store)
+	}
+
+	return handlers, nil
+}
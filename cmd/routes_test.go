@@ -0,0 +1,41 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lucas/go-rest-api-mongo/internal/middleware"
+	"github.com/lucas/go-rest-api-mongo/internal/openapi"
+)
+
+func TestRoutesAreDocumented(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	noop := func(c *gin.Context) { c.Next() }
+	setupRoutes(router, nil, nil, nil, nil, nil, nil, noop, noop, noop, middleware.NewRateLimiters(nil), "")
+
+	spec := openapi.Spec()
+	registered := make(map[string]bool)
+	for _, route := range router.Routes() {
+		key := route.Method + " " + route.Path
+		registered[key] = true
+		if _, ok := spec.Operation(route.Method, route.Path); !ok {
+			t.Errorf("route %s has no OpenAPI entry", key)
+		}
+	}
+
+	for _, key := range spec.Routes() {
+		if !registered[key] {
+			t.Errorf("OpenAPI documents %s, but no such route is registered", key)
+		}
+	}
+
+	// A role worker serve só um subconjunto das rotas da API
+	workerRouter := gin.New()
+	setupWorkerRoutes(workerRouter, nil, nil, nil, noop)
+	for _, route := range workerRouter.Routes() {
+		if key := route.Method + " " + route.Path; !registered[key] {
+			t.Errorf("worker route %s is not part of the API routes", key)
+		}
+	}
+}
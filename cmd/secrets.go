@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/lucas/go-rest-api-mongo/internal/config"
+	"github.com/lucas/go-rest-api-mongo/internal/secrets"
+)
+
+const secretsUsage = `Usage: app secrets <command> [flags]
+
+Manage the encrypted keystore at SECRETS_KEYSTORE_PATH, opened with
+SECRETS_KEYSTORE_PASSPHRASE. Secrets are named after their environment variable,
+e.g. JWT_SECRET or MONGO_PASSWORD, and are used when SECRET_PROVIDERS includes
+keystore. Running processes pick up changes within SECRETS_REFRESH_SECONDS.
+
+Commands:
+  set <name>   Store a secret read from stdin (the value never shows up in the
+               shell history or in the process list)
+  list         Print the names of the stored secrets
+  rm <name>    Remove a secret
+
+`
+
+// runSecrets implementa o subcomando "secrets".
+func runSecrets(args []string) error {
+	return runGroup("secrets", secretsUsage, args, map[string]func(args []string) error{
+		"set":  runSecretsSet,
+		"list": runSecretsList,
+		"rm":   runSecretsRemove,
+	})
+}
+
+func runSecretsSet(args []string) error {
+	flags := newFlagSet("secrets set", "Usage: app secrets set <name> < value\n\nFlags:\n")
+	opts := configFlags(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	name, err := singleArg(flags.Args(), "name")
+	if err != nil {
+		return err
+	}
+
+	data, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return fmt.Errorf("reading secret from stdin: %w", err)
+	}
+	value := strings.TrimRight(string(data), "\r\n")
+	if value == "" {
+		return fmt.Errorf("%s: empty secret on stdin", name)
+	}
+
+	return withKeystore(opts, func(keystore *secrets.Keystore) error {
+		if err := keystore.Set(name, value); err != nil {
+			return err
+		}
+		fmt.Printf("✅ %s stored\n", name)
+		return nil
+	})
+}
+
+func runSecretsList(args []string) error {
+	flags := newFlagSet("secrets list", "Usage: app secrets list\n\nFlags:\n")
+	opts := configFlags(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	return withKeystore(opts, func(keystore *secrets.Keystore) error {
+		names, err := keystore.Names()
+		if err != nil {
+			return err
+		}
+		for _, name := range names {
+			fmt.Println(name)
+		}
+		return nil
+	})
+}
+
+func runSecretsRemove(args []string) error {
+	flags := newFlagSet("secrets rm", "Usage: app secrets rm <name>\n\nFlags:\n")
+	opts := configFlags(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	name, err := singleArg(flags.Args(), "name")
+	if err != nil {
+		return err
+	}
+
+	return withKeystore(opts, func(keystore *secrets.Keystore) error {
+		if _, ok, err := keystore.Secret(name); err != nil {
+			return err
+		} else if !ok {
+			return fmt.Errorf("%s: not in the keystore", name)
+		}
+		if err := keystore.Delete(name); err != nil {
+			return err
+		}
+		fmt.Printf("🗑️  %s removed\n", name)
+		return nil
+	})
+}
+
+// withKeystore abre o keystore da configuração. Só a seção de segredos é carregada,
+// para que o keystore possa guardar segredos obrigatórios, como o JWT_SECRET.
+func withKeystore(opts *config.Options, fn func(keystore *secrets.Keystore) error) error {
+	cfg, err := config.LoadSecrets(*opts)
+	if err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+	keystore, err := cfg.Keystore()
+	if err != nil {
+		return err
+	}
+	return fn(keystore)
+}
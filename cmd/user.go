@@ -0,0 +1,216 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/lucas/go-rest-api-mongo/internal/config"
+	"github.com/lucas/go-rest-api-mongo/internal/dto"
+	"github.com/lucas/go-rest-api-mongo/internal/models"
+	"github.com/lucas/go-rest-api-mongo/internal/services"
+	"github.com/lucas/go-rest-api-mongo/pkg/utils"
+)
+
+const userUsage = `Usage: app user <command> [flags] <user>
+
+Manages users through the same service as the API, so every change publishes
+its event. <user> is a user ID or an email address.
+
+Commands:
+  create           Create a user (-name, -email, -role, -password)
+  disable <user>   Block the user from logging in; issued tokens stop working
+  enable <user>    Allow a disabled user to log in again
+  set-role <user> <role>
+                   Change the role (user or admin); issued tokens get the new role
+  reset-password [-password P] <user>
+                   Set a new password without the current one
+
+`
+
+// runUser implementa o subcomando "user".
+func runUser(args []string) error {
+	return runGroup("user", userUsage, args, map[string]func(args []string) error{
+		"create":         runUserCreate,
+		"disable":        func(args []string) error { return runUserSetDisabled("disable", args, true) },
+		"enable":         func(args []string) error { return runUserSetDisabled("enable", args, false) },
+		"set-role":       runUserSetRole,
+		"reset-password": runUserResetPassword,
+	})
+}
+
+func runUserCreate(args []string) error {
+	flags := newFlagSet("user create", "Usage: app user create -name NAME -email EMAIL [-role ROLE] [-password P]\n\nPrints the created user. Without -password, a random password is generated\nand printed to stderr.\n\nFlags:\n")
+	opts := configFlags(flags)
+	name := flags.String("name", "", "user name")
+	email := flags.String("email", "", "user email")
+	role := flags.String("role", models.RoleUser, "role: "+strings.Join(models.Roles, " or "))
+	password := flags.String("password", "", "password (default: generated)")
+	locale := flags.String("locale", "", "preferred locale (en or pt-BR)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	generated := *password == ""
+	if generated {
+		*password = randomPassword()
+	}
+	req := &dto.RegisterRequest{Name: *name, Email: *email, Password: *password, Locale: *locale}
+	if err := validateRequest(req); err != nil {
+		return err
+	}
+
+	return withUserService(opts, func(ctx context.Context, users *services.UserService) error {
+		user, err := users.CreateWithRole(ctx, req, *role)
+		if err != nil {
+			return err
+		}
+		if generated {
+			fmt.Fprintf(os.Stderr, "Generated password: %s\n", *password)
+		}
+		return printJSON(user)
+	})
+}
+
+func runUserSetDisabled(name string, args []string, disabled bool) error {
+	flags := newFlagSet("user "+name, fmt.Sprintf("Usage: app user %s <user>\n\nFlags:\n", name))
+	opts := configFlags(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	ref, err := singleArg(flags.Args(), "user")
+	if err != nil {
+		return err
+	}
+
+	return withUserService(opts, func(ctx context.Context, users *services.UserService) error {
+		user, err := users.Find(ctx, ref)
+		if err != nil {
+			return err
+		}
+		if user, err = users.SetDisabled(ctx, user.ID, disabled); err != nil {
+			return err
+		}
+		return printJSON(user)
+	})
+}
+
+func runUserSetRole(args []string) error {
+	flags := newFlagSet("user set-role", "Usage: app user set-role <user> <role>\n\nFlags:\n")
+	opts := configFlags(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 2 {
+		flags.Usage()
+		return errUsage
+	}
+	ref, role := flags.Arg(0), flags.Arg(1)
+
+	return withUserService(opts, func(ctx context.Context, users *services.UserService) error {
+		user, err := users.Find(ctx, ref)
+		if err != nil {
+			return err
+		}
+		if user, err = users.SetRole(ctx, user.ID, role); err != nil {
+			if errors.Is(err, services.ErrInvalidRole) {
+				return fmt.Errorf("%w %q: must be %s", err, role, strings.Join(models.Roles, " or "))
+			}
+			return err
+		}
+		return printJSON(user)
+	})
+}
+
+func runUserResetPassword(args []string) error {
+	flags := newFlagSet("user reset-password", "Usage: app user reset-password [-password P] <user>\n\nWithout -password, a random password is generated and printed.\n\nFlags:\n")
+	opts := configFlags(flags)
+	password := flags.String("password", "", "new password (default: generated)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	ref, err := singleArg(flags.Args(), "user")
+	if err != nil {
+		return err
+	}
+
+	generated := *password == ""
+	if generated {
+		*password = randomPassword()
+	}
+	// Mesma regra de tamanho da troca de senha pela API
+	if err := validateRequest(&dto.ChangePasswordRequest{CurrentPassword: "-", NewPassword: *password}); err != nil {
+		return err
+	}
+
+	return withUserService(opts, func(ctx context.Context, users *services.UserService) error {
+		user, err := users.Find(ctx, ref)
+		if err != nil {
+			return err
+		}
+		if err := users.ResetPassword(ctx, user.ID, *password); err != nil {
+			return err
+		}
+		if generated {
+			fmt.Println(*password)
+		}
+		fmt.Fprintf(os.Stderr, "Password of %s reset\n", user.Email)
+		return nil
+	})
+}
+
+// withUserService conecta ao backend e chama fn com o serviço de usuários, que publica
+// os eventos como a API.
+func withUserService(opts *config.Options, fn func(ctx context.Context, users *services.UserService) error) error {
+	ctx, stop := signalContext()
+	defer stop()
+
+	cfg, b, closeBackend, err := openBackend(ctx, opts)
+	if err != nil {
+		return err
+	}
+	defer closeBackend()
+
+	_, emitter, err := newEmitters(cfg, b)
+	if err != nil {
+		return err
+	}
+	return fn(ctx, services.NewUserService(b.users, services.NewAuthService(cfg), emitter))
+}
+
+// validateRequest aplica as regras de binding do DTO, como a API faz.
+func validateRequest(req interface{}) error {
+	utils.UseJSONFieldNames()
+	err := binding.Validator.ValidateStruct(req)
+
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		return err
+	}
+	messages := make([]string, 0, len(validationErrs))
+	for _, fieldErr := range utils.FieldErrors("en", validationErrs) {
+		messages = append(messages, fieldErr.Message)
+	}
+	return errors.New(strings.Join(messages, "; "))
+}
+
+func singleArg(args []string, name string) (string, error) {
+	if len(args) != 1 {
+		return "", fmt.Errorf("expected exactly one <%s> argument, got %d", name, len(args))
+	}
+	return args[0], nil
+}
+
+func randomPassword() string {
+	b := make([]byte, 18)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
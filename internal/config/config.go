@@ -1,110 +1,219 @@
 package config
 
 import (
-	"fmt"
+	"strings"
 	"time"
-
-	"github.com/spf13/viper"
 )
 
+// Config é carregada por Load a partir das tags dos campos. Nos arquivos YAML e TOML, a
+// chave é o caminho dos campos em snake_case (ex.: workers.batch_size, rate_limit.auth.requests).
+//
+//	env       variável de ambiente (ou chave do .env); em structs aninhadas, o prefixo dos campos
+//	default   valor usado quando a variável não está definida
+//	defaults  em structs aninhadas, defaults que substituem os dos campos ("REQUESTS=10 KEY=ip")
+//	unit      unidade de durações dadas como número ("ms", "s", "h"); "10s" também é aceito
+//	required  o valor não pode ser vazio
+//	min, max  limites de números e durações (na mesma unidade do campo)
+//	oneof     valores aceitos, separados por espaço, sem diferenciar maiúsculas
+//	secret    mascarado por Redacted ("uri" mascara só a senha da URI); o valor também pode
+//	          vir de <env>_FILE ou dos providers de SECRET_PROVIDERS
+//	reload    pode mudar sem reiniciar o processo (ver Loader.Watch)
 type Config struct {
-	Server   ServerConfig
-	Database DatabaseConfig
-	Kafka    KafkaConfig
-	JWT      JWTConfig
-	Workers  WorkersConfig
+	Server      ServerConfig
+	Database    DatabaseConfig
+	Kafka       KafkaConfig
+	JWT         JWTConfig
+	Events      EventsConfig
+	CDC         CDCConfig
+	Workers     WorkersConfig
+	Scheduler   SchedulerConfig
+	Idempotency IdempotencyConfig
+	RateLimit   RateLimitConfig
+	I18n        I18nConfig
+	Secrets     SecretsConfig
 }
 
 type ServerConfig struct {
-	Port string
-	Host string
-	Mode string // e.g., "development", "production"
+	Port            string `env:"SERVER_PORT" default:"8080" required:"true"`
+	Host            string `env:"SERVER_HOST" default:"localhost"`
+	Mode            string `env:"SERVER_MODE" default:"production" oneof:"development production test"`
+	Role            string `env:"APP_ROLE" default:"all" oneof:"api worker all"`
+	OpenAPIValidate bool   `env:"OPENAPI_VALIDATE" default:"false" reload:"true"` // valida requisições e respostas contra o OpenAPI (apenas em development)
+	LogLevel        string `env:"LOG_LEVEL" default:"info" oneof:"debug info warn error" reload:"true"`
+	DocsScript      string `env:"DOCS_SCRIPT_URL" default:"https://cdn.jsdelivr.net/npm/redoc@2.5.0/bundles/redoc.standalone.js" required:"true"` // bundle do Redoc usado por /docs, numa versão exata
+	DocsIntegrity   string `env:"DOCS_SCRIPT_INTEGRITY"`                                                                                          // hash SRI do bundle ("sha384-..."), conferido pelo navegador
 }
 
 type DatabaseConfig struct {
-	URI          string
-	DatabaseName string
-	Timeout      time.Duration
+	Driver       string        `env:"STORAGE_DRIVER" default:"mongo" oneof:"mongo memory"`
+	URI          string        `env:"MONGO_URI" default:"mongodb://localhost:27017" secret:"uri" reload:"true"`
+	Username     string        `env:"MONGO_USER" reload:"true"`                   // substitui o usuário da URI
+	Password     string        `env:"MONGO_PASSWORD" secret:"true" reload:"true"` // substitui a senha da URI
+	DatabaseName string        `env:"MONGO_DATABASE" default:"appdb"`
+	Timeout      time.Duration `env:"MONGO_TIMEOUT" default:"10" unit:"s" min:"1"`
+	AutoMigrate  bool          `env:"MONGO_AUTO_MIGRATE" default:"true"` // cria índices ao iniciar; com false, rode o comando migrate
 }
 
 type KafkaConfig struct {
-	Brokers               []string
-	TopicUserRegistration string
-	TopicUserEvents       string
-	GroupID               string
+	Brokers               []string      `env:"KAFKA_BROKERS" default:"localhost:9092"`
+	TopicUserRegistration string        `env:"KAFKA_TOPIC_USER_REGISTRATION" default:"user-registration-topic" required:"true"`
+	TopicUserEvents       string        `env:"KAFKA_TOPIC_USER_EVENTS" default:"user-events-topic" required:"true"`
+	GroupID               string        `env:"KAFKA_GROUP_ID" default:"app-group"`
+	Acks                  string        `env:"KAFKA_ACKS" default:"all" oneof:"all one none"`
+	Balancer              string        `env:"KAFKA_BALANCER" default:"hash" oneof:"hash murmur2 round_robin least_bytes"`
+	Compression           string        `env:"KAFKA_COMPRESSION" default:"snappy" oneof:"none gzip snappy lz4 zstd"`
+	BatchSize             int           `env:"KAFKA_BATCH_SIZE" default:"100" min:"1"`                // mensagens por lote do writer
+	BatchTimeout          time.Duration `env:"KAFKA_BATCH_TIMEOUT_MS" default:"10" unit:"ms" min:"1"` // espera máxima para completar um lote
+	Async                 bool          `env:"KAFKA_ASYNC" default:"false"`                           // não espera a confirmação do broker
+	SASL                  KafkaSASLConfig
+	TLS                   KafkaTLSConfig
+}
+
+type KafkaSASLConfig struct {
+	Mechanism string `env:"KAFKA_SASL_MECHANISM" oneof:"PLAIN SCRAM-SHA-256 SCRAM-SHA-512"` // vazio desliga o SASL
+	Username  string `env:"KAFKA_SASL_USERNAME"`
+	Password  string `env:"KAFKA_SASL_PASSWORD" secret:"true"`
+}
+
+type KafkaTLSConfig struct {
+	Enabled            bool   `env:"KAFKA_TLS_ENABLED" default:"false"`
+	CAFile             string `env:"KAFKA_TLS_CA_FILE"`   // vazio usa as CAs do sistema
+	CertFile           string `env:"KAFKA_TLS_CERT_FILE"` // certificado do client (mTLS)
+	KeyFile            string `env:"KAFKA_TLS_KEY_FILE"`
+	InsecureSkipVerify bool   `env:"KAFKA_TLS_INSECURE_SKIP_VERIFY" default:"false"`
+}
+
+type EventsConfig struct {
+	Driver          string `env:"EVENT_BUS_DRIVER" default:"kafka" oneof:"kafka memory file"`
+	FilePath        string `env:"EVENT_BUS_FILE_PATH" default:"events.jsonl"`                 // arquivo JSON lines do driver "file"
+	MemoryRetention int    `env:"EVENT_BUS_MEMORY_RETENTION" default:"10000" min:"1"`         // mensagens retidas por tópico no driver "memory"
+	Mode            string `env:"EVENTS_MODE" default:"structured" oneof:"structured binary"` // modo CloudEvents
+	Source          string `env:"EVENTS_SOURCE" default:"/go-rest-api-mongo" required:"true"` // atributo source dos CloudEvents
+	SchemaBaseURL   string `env:"EVENTS_SCHEMA_BASE_URL" default:"http://localhost:8080/schemas/events"`
+	Format          string `env:"EVENTS_FORMAT" default:"json" oneof:"json protobuf avro"` // formato do data
+	RegistryPath    string `env:"SCHEMA_REGISTRY_PATH"`                                    // vazio mantém o registry em memória
+	Compatibility   string `env:"SCHEMA_REGISTRY_COMPATIBILITY" default:"BACKWARD" oneof:"BACKWARD FORWARD FULL NONE"`
+}
+
+// CDCConfig liga a publicação de eventos a partir do change stream da coleção users.
+type CDCConfig struct {
+	Enabled bool `env:"CDC_ENABLED" default:"false"`
+	// LeaseTTL é o tempo até outra réplica assumir se o líder parar de renovar
+	LeaseTTL time.Duration `env:"CDC_LEASE_TTL_SECONDS" default:"15" unit:"s" min:"1"`
 }
 
 type JWTConfig struct {
-	SecretKey  string
-	Expiration time.Duration
+	SecretKey  string        `env:"JWT_SECRET" required:"true" secret:"true" reload:"true"`
+	Expiration time.Duration `env:"JWT_EXPIRATION_HOURS" default:"24" unit:"h" min:"1"`
 }
 
 type WorkersConfig struct {
-	PoolSize     int
-	BatchSize    int
-	BatchTimeout time.Duration
+	PoolSize          int           `env:"WORKER_POOL_SIZE" default:"5" min:"1"`              // tamanho inicial
+	PoolMin           int           `env:"WORKER_POOL_MIN" default:"1" min:"1" reload:"true"` // menor tamanho
+	PoolMax           int           `env:"WORKER_POOL_MAX" default:"0" min:"0" reload:"true"` // maior tamanho; 0 é o PoolSize
+	Autoscale         bool          `env:"WORKER_AUTOSCALE" default:"false" reload:"true"`    // pela fila e pelo tempo dos lotes
+	AutoscaleInterval time.Duration `env:"WORKER_AUTOSCALE_INTERVAL_SECONDS" default:"10" unit:"s" min:"1" reload:"true"`
+	QueueCapacity     int           `env:"JOB_QUEUE_CAPACITY" default:"100" min:"0"` // por tipo de job, só nas filas em memória; 0 sem limite
+	BatchSize         int           `env:"BATCH_SIZE" default:"10" min:"1" reload:"true"`
+	BatchTimeout      time.Duration `env:"BATCH_TIMEOUT_SECONDS" default:"5" unit:"s" min:"1" reload:"true"`
+	RetryMaxAttempts  int           `env:"RETRY_MAX_ATTEMPTS" default:"5" min:"1" reload:"true"`
+	RetryBaseDelay    time.Duration `env:"RETRY_BASE_DELAY_MS" default:"200" unit:"ms" min:"1" reload:"true"`
+	RetryMaxDelay     time.Duration `env:"RETRY_MAX_DELAY_MS" default:"10000" unit:"ms" min:"1" reload:"true"`
+	// VisibilityTimeout é por quanto tempo um lote da fila fica reservado para um worker
+	VisibilityTimeout time.Duration `env:"WORKER_VISIBILITY_TIMEOUT_SECONDS" default:"60" unit:"s" min:"1"`
+	// Lanes de prioridade das filas de jobs, alternadas pelos workers conforme o peso
+	LaneHigh   LaneConfig `env:"JOB_LANE_HIGH_" defaults:"WEIGHT=6"`
+	LaneNormal LaneConfig `env:"JOB_LANE_NORMAL_" defaults:"WEIGHT=3"`
+	LaneLow    LaneConfig `env:"JOB_LANE_LOW_"`
+	// FairKey identifica o cliente do /register-fast, cujos registros se alternam com os
+	// de outros clientes dentro de uma lane
+	FairKey string `env:"REGISTRATION_FAIR_KEY" default:"api_key" oneof:"ip api_key"`
+	// PriorityKeys são as chaves de API (X-API-Key), separadas por vírgula, que podem
+	// mandar registros para a lane high; sem uma delas, X-Priority: high vira normal
+	PriorityKeys string `env:"REGISTRATION_PRIORITY_KEYS" secret:"true"`
 }
 
-func Load() (*Config, error) {
-	setDefaults()
+// LaneConfig é uma lane de prioridade. Com CAPACITY=0, a lane só tem o limite de
+// JOB_QUEUE_CAPACITY.
+type LaneConfig struct {
+	Weight   int `env:"WEIGHT" default:"1" min:"1" reload:"true"`
+	Capacity int `env:"CAPACITY" default:"0" min:"0" reload:"true"`
+}
 
-	viper.SetConfigFile(".env")
-	viper.AutomaticEnv()
+// PoolBounds devolve os limites do tamanho do pool, com PoolMax 0 valendo PoolSize.
+func (w WorkersConfig) PoolBounds() (min, max int) {
+	if w.PoolMax == 0 {
+		return w.PoolMin, w.PoolSize
+	}
+	return w.PoolMin, w.PoolMax
+}
 
-	if err := viper.ReadInConfig(); err != nil {
-		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
-			return nil, fmt.Errorf("error reading config file: %w", err)
+// PriorityAPIKeys devolve as chaves de PriorityKeys, sem espaços e sem as vazias.
+func (w WorkersConfig) PriorityAPIKeys() []string {
+	var keys []string
+	for _, key := range strings.Split(w.PriorityKeys, ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			keys = append(keys, key)
 		}
 	}
+	return keys
+}
 
-	config := &Config{
-		Server: ServerConfig{
-			Port: viper.GetString("SERVER_PORT"),
-			Host: viper.GetString("SERVER_HOST"),
-			Mode: viper.GetString("SERVER_MODE"),
-		},
-		Database: DatabaseConfig{
-			URI:          viper.GetString("MONGO_URI"),
-			DatabaseName: viper.GetString("MONGO_DATABASE"),
-			Timeout:      time.Duration(viper.GetInt("MONGO_TIMEOUT")) * time.Second,
-		},
-		Kafka: KafkaConfig{
-			Brokers:               []string{viper.GetString("KAFKA_BROKERS")},
-			TopicUserRegistration: viper.GetString("KAFKA_TOPIC_USER_REGISTRATION"),
-			TopicUserEvents:       viper.GetString("KAFKA_TOPIC_USER_EVENTS"),
-			GroupID:               viper.GetString("KAFKA_GROUP_ID"),
-		},
-		JWT: JWTConfig{
-			SecretKey:  viper.GetString("JWT_SECRET"),
-			Expiration: time.Duration(viper.GetInt("JWT_EXPIRATION_HOURS")) * time.Hour,
-		},
-		Workers: WorkersConfig{
-			PoolSize:     viper.GetInt("WORKER_POOL_SIZE"),
-			BatchSize:    viper.GetInt("BATCH_SIZE"),
-			BatchTimeout: time.Duration(viper.GetInt("BATCH_TIMEOUT_SECONDS")) * time.Second,
-		},
-	}
+// SchedulerConfig configura os jobs periódicos de manutenção, que rodam nos processos
+// da role worker. Cada horário é executado por uma única réplica, a que pega o lease
+// do job. Uma expressão cron vazia desliga o job.
+type SchedulerConfig struct {
+	Enabled          bool          `env:"SCHEDULER_ENABLED" default:"true"`
+	Timezone         string        `env:"SCHEDULER_TIMEZONE" default:"UTC"`                          // fuso das expressões cron
+	Jitter           time.Duration `env:"SCHEDULER_JITTER_SECONDS" default:"30" unit:"s" min:"0"`    // atraso aleatório depois de cada horário
+	LeaseTTL         time.Duration `env:"SCHEDULER_LEASE_TTL_SECONDS" default:"30" unit:"s" min:"1"` // renovado enquanto o job roda
+	Timeout          time.Duration `env:"SCHEDULER_TIMEOUT_SECONDS" default:"600" unit:"s" min:"1"`  // limite de cada execução
+	HistoryRetention time.Duration `env:"SCHEDULER_HISTORY_RETENTION_HOURS" default:"168" unit:"h" min:"1"`
+	// RetryEvents reenvia os eventos que viraram dead letters
+	RetryEvents      string `env:"SCHEDULE_RETRY_EVENTS" default:"*/15 * * * *"`
+	RetryEventsLimit int    `env:"SCHEDULE_RETRY_EVENTS_LIMIT" default:"100" min:"1"` // dead letters por execução
+	// PurgeDeadLetters remove as dead letters mais antigas que DeadLetterRetention
+	PurgeDeadLetters    string        `env:"SCHEDULE_PURGE_DEAD_LETTERS" default:"0 3 * * *"`
+	DeadLetterRetention time.Duration `env:"DEAD_LETTER_RETENTION_HOURS" default:"720" unit:"h" min:"1"`
+}
 
-	return config, nil
+type IdempotencyConfig struct {
+	TTL time.Duration `env:"IDEMPOTENCY_TTL_HOURS" default:"24" unit:"h" min:"1"`
 }
 
-func setDefaults() {
-	viper.SetDefault("SERVER_PORT", "8080")
-	viper.SetDefault("SERVER_HOST", "localhost")
-	viper.SetDefault("SERVER_MODE", "production")
+type RateLimitConfig struct {
+	Enabled bool                  `env:"RATE_LIMIT_ENABLED" default:"true" reload:"true"`
+	Store   string                `env:"RATE_LIMIT_STORE" default:"memory" oneof:"memory mongo"`
+	Auth    RateLimitPolicyConfig `env:"RATE_LIMIT_AUTH_" defaults:"REQUESTS=10 KEY=ip"`
+	API     RateLimitPolicyConfig `env:"RATE_LIMIT_API_"`
+	Admin   RateLimitPolicyConfig `env:"RATE_LIMIT_ADMIN_" defaults:"REQUESTS=60"`
+}
 
-	viper.SetDefault("MONGO_DB_NAME", "appdb")
-	viper.SetDefault("MONGO_TIMEOUT", 10*time.Second)
+// RateLimitPolicyConfig é o limite de um grupo de rotas. Com REQUESTS=0, o grupo fica
+// sem limite.
+type RateLimitPolicyConfig struct {
+	Requests int           `env:"REQUESTS" default:"100" min:"0" reload:"true"`
+	Window   time.Duration `env:"WINDOW_SECONDS" default:"60" unit:"s" min:"1" reload:"true"`
+	KeyBy    string        `env:"KEY" default:"user" oneof:"ip user api_key" reload:"true"`
+}
 
-	viper.SetDefault("KAFKA_BROKERS", []string{"localhost:9092"})
-	viper.SetDefault("KAFKA_TOPIC_USER_REGISTRATION", "user-registration-topic")
-	viper.SetDefault("KAFKA_TOPIC_USER_EVENTS", "user-events-topic")
-	viper.SetDefault("KAFKA_GROUP_ID", "app-group")
+// Policies devolve as policies por grupo de rotas ("auth", "api" e "admin").
+func (c RateLimitConfig) Policies() map[string]RateLimitPolicyConfig {
+	return map[string]RateLimitPolicyConfig{"auth": c.Auth, "api": c.API, "admin": c.Admin}
+}
 
-	viper.SetDefault("JWT_SECRET_KEY", "supersecretkey")
-	viper.SetDefault("JWT_EXPIRATION_HOURS", 24)
+type I18nConfig struct {
+	DefaultLocale string `env:"DEFAULT_LOCALE" default:"en" oneof:"en pt-BR"`
+}
 
-	viper.SetDefault("WORKERS_POOL_SIZE", 5)
-	viper.SetDefault("WORKERS_BATCH_SIZE", 10)
-	viper.SetDefault("WORKERS_BATCH_TIMEOUT", 5*time.Second)
+// SecretsConfig diz de onde vêm os segredos além das camadas da configuração e das
+// variáveis *_FILE. Os segredos são relidos a cada Refresh, então um segredo
+// rotacionado na origem vale sem reiniciar o processo.
+type SecretsConfig struct {
+	// Providers em ordem de precedência: "file" (SECRETS_DIR), "env" e "keystore"
+	Providers          []string      `env:"SECRET_PROVIDERS"`
+	Dir                string        `env:"SECRETS_DIR"` // um arquivo por segredo, ex.: /run/secrets/jwt_secret
+	KeystorePath       string        `env:"SECRETS_KEYSTORE_PATH"`
+	KeystorePassphrase string        `env:"SECRETS_KEYSTORE_PASSPHRASE" secret:"true"`
+	Refresh            time.Duration `env:"SECRETS_REFRESH_SECONDS" default:"60" unit:"s" min:"0"` // 0 desliga
 }
@@ -0,0 +1,167 @@
+package config
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+const strongSecret = "0123456789abcdef0123456789abcdef"
+
+func loadValues(values map[string]string) (*Config, error) {
+	return build(func(env, key string) (string, bool) {
+		value, ok := values[env]
+		return value, ok
+	})
+}
+
+func TestLoadDefaults(t *testing.T) {
+	cfg, err := loadValues(map[string]string{"JWT_SECRET": strongSecret})
+	if err != nil {
+		t.Fatalf("load() error = %v", err)
+	}
+
+	if cfg.Database.URI == "" || cfg.Database.DatabaseName != "appdb" || cfg.Database.Timeout != 10*time.Second {
+		t.Errorf("Database = %+v, want the defaults", cfg.Database)
+	}
+	if cfg.Workers.PoolSize != 5 || cfg.Workers.BatchSize != 10 || cfg.Workers.BatchTimeout != 5*time.Second || cfg.Workers.VisibilityTimeout != time.Minute {
+		t.Errorf("Workers = %+v, want the defaults", cfg.Workers)
+	}
+	if cfg.JWT.Expiration != 24*time.Hour || !cfg.Database.AutoMigrate || cfg.Server.Mode != "production" {
+		t.Errorf("config = %+v, want the defaults", cfg)
+	}
+
+	policies := cfg.RateLimit.Policies()
+	if auth := policies["auth"]; auth.Requests != 10 || auth.KeyBy != "ip" || auth.Window != time.Minute {
+		t.Errorf("auth policy = %+v, want 10 per minute by ip", auth)
+	}
+	if api := policies["api"]; api.Requests != 100 || api.KeyBy != "user" {
+		t.Errorf("api policy = %+v, want 100 by user", api)
+	}
+}
+
+func TestLoadValues(t *testing.T) {
+	cfg, err := loadValues(map[string]string{
+		"JWT_SECRET":               strongSecret,
+		"MONGO_TIMEOUT":            "1500ms",
+		"WORKER_POOL_SIZE":         "8",
+		"BATCH_TIMEOUT_SECONDS":    "",
+		"KAFKA_BROKERS":            "a:9092, b:9092",
+		"RATE_LIMIT_ADMIN_KEY":     "API_KEY",
+		"RATE_LIMIT_AUTH_REQUESTS": "0",
+	})
+	if err != nil {
+		t.Fatalf("load() error = %v", err)
+	}
+	if cfg.Database.Timeout != 1500*time.Millisecond || cfg.Workers.PoolSize != 8 || cfg.Workers.BatchTimeout != 5*time.Second {
+		t.Errorf("config = %+v, want the values set and the default for the empty one", cfg.Workers)
+	}
+	if len(cfg.Kafka.Brokers) != 2 || cfg.RateLimit.Admin.KeyBy != "api_key" || cfg.RateLimit.Auth.Requests != 0 {
+		t.Errorf("config = %+v", cfg)
+	}
+}
+
+func TestLoadReportsEveryProblem(t *testing.T) {
+	_, err := loadValues(map[string]string{
+		"WORKER_POOL_SIZE":                  "0",
+		"MONGO_TIMEOUT":                     "soon",
+		"KAFKA_ACKS":                        "maybe",
+		"WORKER_VISIBILITY_TIMEOUT_SECONDS": "-1",
+		"SERVER_PORT":                       "http",
+	})
+
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("load() error = %v, want a *ValidationError", err)
+	}
+	for _, want := range []string{
+		"WORKER_POOL_SIZE: must be at least 1",
+		`MONGO_TIMEOUT: "soon" is not a duration`,
+		`KAFKA_ACKS: "maybe" must be one of all, one, none`,
+		"WORKER_VISIBILITY_TIMEOUT_SECONDS: must be at least 1s",
+		"JWT_SECRET: is required",
+		`SERVER_PORT: "http" is not a valid port`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error = %v\nwant it to contain %q", err, want)
+		}
+	}
+}
+
+func TestLoadWorkerPoolBounds(t *testing.T) {
+	cfg, err := loadValues(map[string]string{"JWT_SECRET": strongSecret, "WORKER_POOL_SIZE": "8"})
+	if err != nil {
+		t.Fatalf("load() error = %v", err)
+	}
+	if min, max := cfg.Workers.PoolBounds(); min != 1 || max != 8 {
+		t.Errorf("PoolBounds() = %d, %d; want 1 and WORKER_POOL_SIZE without WORKER_POOL_MAX", min, max)
+	}
+
+	_, err = loadValues(map[string]string{"JWT_SECRET": strongSecret, "WORKER_POOL_SIZE": "30", "WORKER_POOL_MIN": "2", "WORKER_POOL_MAX": "20"})
+	if err == nil || !strings.Contains(err.Error(), "WORKER_POOL_SIZE: must be between WORKER_POOL_MIN (2) and WORKER_POOL_MAX (20)") {
+		t.Errorf("load() with the size above the max error = %v", err)
+	}
+}
+
+func TestLoadJobLanes(t *testing.T) {
+	cfg, err := loadValues(map[string]string{"JWT_SECRET": strongSecret, "JOB_LANE_LOW_CAPACITY": "50"})
+	if err != nil {
+		t.Fatalf("load() error = %v", err)
+	}
+	if w := cfg.Workers; w.LaneHigh.Weight != 6 || w.LaneNormal.Weight != 3 || w.LaneLow.Weight != 1 || w.LaneLow.Capacity != 50 || w.LaneHigh.Capacity != 0 {
+		t.Errorf("lanes = %+v %+v %+v, want weights 6/3/1 and only the low lane capped at 50", w.LaneHigh, w.LaneNormal, w.LaneLow)
+	}
+
+	if _, err := loadValues(map[string]string{"JWT_SECRET": strongSecret, "JOB_LANE_HIGH_WEIGHT": "0"}); err == nil || !strings.Contains(err.Error(), "JOB_LANE_HIGH_WEIGHT") {
+		t.Errorf("load() with a zero weight error = %v", err)
+	}
+
+	cfg, _ = loadValues(map[string]string{"JWT_SECRET": strongSecret, "REGISTRATION_PRIORITY_KEYS": " key-a, ,key-b "})
+	if keys := cfg.Workers.PriorityAPIKeys(); len(keys) != 2 || keys[0] != "key-a" || keys[1] != "key-b" {
+		t.Errorf("PriorityAPIKeys() = %q, want key-a and key-b", keys)
+	}
+	if redacted := cfg.Redacted().Workers.PriorityKeys; redacted == cfg.Workers.PriorityKeys {
+		t.Errorf("redacted priority keys = %q, want them masked", redacted)
+	}
+}
+
+func TestLoadScheduler(t *testing.T) {
+	cfg, err := loadValues(map[string]string{"JWT_SECRET": strongSecret, "SCHEDULE_PURGE_DEAD_LETTERS": ""})
+	if err != nil {
+		t.Fatalf("load() error = %v", err)
+	}
+	if s := cfg.Scheduler; !s.Enabled || s.RetryEvents != "*/15 * * * *" || s.PurgeDeadLetters != "" || s.DeadLetterRetention != 720*time.Hour {
+		t.Errorf("scheduler = %+v, want the defaults with the purge turned off", s)
+	}
+
+	_, err = loadValues(map[string]string{"JWT_SECRET": strongSecret, "SCHEDULE_RETRY_EVENTS": "*/15 * *", "SCHEDULER_TIMEZONE": "Mars/Olympus"})
+	if err == nil || !strings.Contains(err.Error(), "SCHEDULE_RETRY_EVENTS") || !strings.Contains(err.Error(), "SCHEDULER_TIMEZONE") {
+		t.Errorf("load() with an invalid cron and time zone error = %v", err)
+	}
+}
+
+func TestLoadRefusesWeakJWTSecretInProduction(t *testing.T) {
+	for _, secret := range []string{"short", "your-super-secret-jwt-key-change-in-production"} {
+		if _, err := loadValues(map[string]string{"JWT_SECRET": secret}); err == nil || !strings.Contains(err.Error(), "JWT_SECRET: too weak") {
+			t.Errorf("secret %q: load() error = %v, want it refused", secret, err)
+		}
+	}
+	if _, err := loadValues(map[string]string{"JWT_SECRET": "short", "SERVER_MODE": "development"}); err != nil {
+		t.Errorf("development: load() error = %v, want a weak secret accepted", err)
+	}
+}
+
+func TestLoadWithoutEnvFile(t *testing.T) {
+	t.Chdir(t.TempDir())
+	t.Setenv("JWT_SECRET", strongSecret)
+	t.Setenv("MONGO_DATABASE", "from_env")
+
+	cfg, err := Load(Options{})
+	if err != nil {
+		t.Fatalf("Load() without .env error = %v", err)
+	}
+	if cfg.Database.DatabaseName != "from_env" {
+		t.Errorf("DatabaseName = %q, want the environment variable", cfg.Database.DatabaseName)
+	}
+}
@@ -0,0 +1,351 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/viper"
+)
+
+// envFile é lido do diretório atual, se existir.
+const envFile = ".env"
+
+// fileExtensions são os formatos aceitos para os arquivos de configuração.
+var fileExtensions = []string{".yaml", ".yml", ".toml"}
+
+// Options escolhe as camadas da configuração. Da menor para a maior precedência:
+// defaults das tags, arquivo base, arquivo do profile, .env, variáveis de ambiente e
+// Overrides (as flags -set da linha de comando).
+type Options struct {
+	// File é o arquivo base (YAML ou TOML); vazio usa CONFIG_FILE ou, se existir,
+	// config.yaml, config.yml ou config.toml no diretório atual
+	File string
+	// Profile mescla <base>.<profile>.<ext> sobre o arquivo base; vazio usa CONFIG_PROFILE
+	Profile string
+	// Overrides são valores pelo nome da variável de ambiente (ex.: WORKER_POOL_SIZE)
+	Overrides map[string]string
+}
+
+// Load lê e valida a configuração. Todos os problemas encontrados são devolvidos
+// juntos em um *ValidationError.
+func Load(opts Options) (*Config, error) {
+	loader, err := NewLoader(opts)
+	if err != nil {
+		return nil, err
+	}
+	return loader.Config(), nil
+}
+
+// Loader mantém a configuração atual e, com Watch, a recarrega quando os arquivos
+// mudam. Só campos com a tag reload mudam em tempo de execução: uma mudança em
+// qualquer outro campo é rejeitada e a configuração anterior continua valendo.
+type Loader struct {
+	opts  Options
+	env   *viper.Viper
+	files []string // base e profile, nessa ordem
+
+	reloadMu      sync.Mutex // serializa as recargas
+	mu            sync.Mutex
+	current       *Config
+	subscribers   []func(old, new *Config)
+	lastRejection string
+}
+
+// NewLoader resolve os arquivos de Options e carrega a configuração.
+func NewLoader(opts Options) (*Loader, error) {
+	l, err := newLoader(opts)
+	if err != nil {
+		return nil, err
+	}
+	if l.current, err = l.load(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// LoadSecrets lê só a seção Secrets, com as mesmas camadas de Load. Serve aos comandos
+// que gerenciam o keystore, que precisam rodar antes de o resto da configuração (o
+// JWT_SECRET, por exemplo) existir.
+func LoadSecrets(opts Options) (SecretsConfig, error) {
+	var config SecretsConfig
+	l, err := newLoader(opts)
+	if err != nil {
+		return config, err
+	}
+	lookup, err := l.lookup()
+	if err != nil {
+		return config, err
+	}
+
+	field, _ := reflect.TypeOf(Config{}).FieldByName("Secrets")
+	if problems := decode(lookup, reflect.ValueOf(&config).Elem(), field.Tag.Get("env"), snakeCase(field.Name), nil); len(problems) > 0 {
+		return config, &ValidationError{Problems: problems}
+	}
+	return config, nil
+}
+
+func newLoader(opts Options) (*Loader, error) {
+	env := viper.New()
+	env.SetConfigFile(envFile)
+	env.SetConfigType("env")
+	env.AutomaticEnv()
+	if err := env.ReadInConfig(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("error reading %s: %w", envFile, err)
+	}
+
+	if opts.File == "" {
+		opts.File = env.GetString("CONFIG_FILE")
+	}
+	if opts.Profile == "" {
+		opts.Profile = env.GetString("CONFIG_PROFILE")
+	}
+	files, err := configFiles(opts.File, opts.Profile)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Loader{opts: opts, env: env, files: files}, nil
+}
+
+// Config devolve a configuração atual. O valor devolvido não deve ser alterado.
+func (l *Loader) Config() *Config {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.current
+}
+
+// Files devolve os arquivos lidos, do base ao profile.
+func (l *Loader) Files() []string {
+	return l.files
+}
+
+// Subscribe registra fn para ser chamada a cada recarga aceita, com a configuração
+// anterior e a nova.
+func (l *Loader) Subscribe(fn func(old, new *Config)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.subscribers = append(l.subscribers, fn)
+}
+
+// Watch recarrega a configuração sempre que um dos arquivos muda e, a cada
+// SECRETS_REFRESH_SECONDS, para ler os segredos rotacionados. Recargas rejeitadas
+// são logadas.
+func (l *Loader) Watch() {
+	for _, file := range l.files {
+		watcher := viper.New()
+		watcher.SetConfigFile(file)
+		watcher.OnConfigChange(func(fsnotify.Event) { l.reloadLogged() })
+		watcher.WatchConfig()
+	}
+
+	if interval := l.Config().Secrets.Refresh; interval > 0 {
+		go func() {
+			for range time.Tick(interval) {
+				l.reloadLogged()
+			}
+		}()
+	}
+}
+
+// reloadLogged recarrega e loga a rejeição só quando ela muda, para que a releitura
+// periódica não repita o mesmo aviso a cada intervalo.
+func (l *Loader) reloadLogged() {
+	err := l.Reload()
+	message := ""
+	if err != nil {
+		message = err.Error()
+	}
+
+	l.mu.Lock()
+	repeated := message == l.lastRejection
+	l.lastRejection = message
+	l.mu.Unlock()
+
+	if err != nil && !repeated {
+		log.Printf("⚠️  Config reload rejected: %v", err)
+	}
+}
+
+// Reload lê as camadas de novo. A nova configuração só é aceita se for válida e se
+// todos os campos alterados tiverem a tag reload; então os subscribers são chamados.
+func (l *Loader) Reload() error {
+	l.reloadMu.Lock()
+	defer l.reloadMu.Unlock()
+
+	next, err := l.load()
+	if err != nil {
+		return err
+	}
+
+	old := l.Config()
+	var changed, restart []string
+	for _, change := range diff(reflect.ValueOf(old).Elem(), reflect.ValueOf(next).Elem(), "") {
+		changed = append(changed, change.env)
+		if !change.reloadable {
+			restart = append(restart, change.env)
+		}
+	}
+	if len(restart) > 0 {
+		return fmt.Errorf("%s changed but need a restart", strings.Join(restart, ", "))
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+
+	l.mu.Lock()
+	l.current = next
+	subscribers := slices.Clone(l.subscribers)
+	l.mu.Unlock()
+
+	log.Printf("🔄 Config reloaded: %s", strings.Join(changed, ", "))
+	for _, fn := range subscribers {
+		fn(old, next)
+	}
+	return nil
+}
+
+func (l *Loader) load() (*Config, error) {
+	lookup, err := l.lookup()
+	if err != nil {
+		return nil, err
+	}
+	return build(lookup)
+}
+
+// lookup lê os arquivos e devolve a busca pelas camadas, da maior para a menor
+// precedência.
+func (l *Loader) lookup() (lookupFunc, error) {
+	files := make([]*viper.Viper, 0, len(l.files))
+	for _, file := range l.files {
+		v := viper.New()
+		v.SetConfigFile(file)
+		if err := v.ReadInConfig(); err != nil {
+			return nil, fmt.Errorf("error reading %s: %w", file, err)
+		}
+		files = append(files, v)
+	}
+
+	return func(env, key string) (string, bool) {
+		if value, ok := l.opts.Overrides[env]; ok {
+			return strings.TrimSpace(value), true
+		}
+		if l.env.IsSet(env) {
+			return strings.TrimSpace(l.env.GetString(env)), true
+		}
+		for i := len(files) - 1; i >= 0; i-- {
+			if files[i].IsSet(key) {
+				return fileValue(files[i].Get(key)), true
+			}
+		}
+		return "", false
+	}, nil
+}
+
+// build preenche a Config a partir de lookup e valida o resultado.
+func build(lookup lookupFunc) (*Config, error) {
+	config := &Config{}
+	problems := decode(lookup, reflect.ValueOf(config).Elem(), "", "", nil)
+	problems = append(problems, config.resolveSecrets()...)
+	problems = append(problems, config.validate()...)
+	if len(problems) > 0 {
+		return nil, &ValidationError{Problems: problems}
+	}
+	return config, nil
+}
+
+// fileValue converte um valor de YAML ou TOML para o formato das variáveis de
+// ambiente; listas viram itens separados por vírgula.
+func fileValue(value interface{}) string {
+	if items, ok := value.([]interface{}); ok {
+		parts := make([]string, len(items))
+		for i, item := range items {
+			parts[i] = fmt.Sprint(item)
+		}
+		return strings.Join(parts, ",")
+	}
+	return strings.TrimSpace(fmt.Sprint(value))
+}
+
+// configFiles resolve o arquivo base e o do profile. Sem file, o arquivo base é
+// opcional; um profile pedido precisa existir.
+func configFiles(file, profile string) ([]string, error) {
+	var files []string
+	if file != "" {
+		if !hasConfigExtension(file) {
+			return nil, fmt.Errorf("config file %s: use a .yaml, .yml or .toml file", file)
+		}
+		if _, err := os.Stat(file); err != nil {
+			return nil, fmt.Errorf("config file: %w", err)
+		}
+		files = append(files, file)
+	} else if found := findFile("config"); found != "" {
+		files = append(files, found)
+	}
+
+	if profile == "" {
+		return files, nil
+	}
+
+	var profileFile string
+	if len(files) > 0 {
+		ext := filepath.Ext(files[0])
+		profileFile = strings.TrimSuffix(files[0], ext) + "." + profile + ext
+		if _, err := os.Stat(profileFile); err != nil {
+			return nil, fmt.Errorf("profile %q: %w", profile, err)
+		}
+	} else if profileFile = findFile("config." + profile); profileFile == "" {
+		return nil, fmt.Errorf("profile %q: no config.%s.yaml, .yml or .toml found", profile, profile)
+	}
+	return append(files, profileFile), nil
+}
+
+func findFile(name string) string {
+	for _, ext := range fileExtensions {
+		if _, err := os.Stat(name + ext); err == nil {
+			return name + ext
+		}
+	}
+	return ""
+}
+
+func hasConfigExtension(file string) bool {
+	for _, ext := range fileExtensions {
+		if strings.EqualFold(filepath.Ext(file), ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// change é um campo com valores diferentes entre duas configurações.
+type change struct {
+	env        string
+	reloadable bool
+}
+
+func diff(old, new reflect.Value, prefix string) []change {
+	var changes []change
+	for i := 0; i < old.NumField(); i++ {
+		field := old.Type().Field(i)
+		name := field.Tag.Get("env")
+
+		if field.Type.Kind() == reflect.Struct {
+			changes = append(changes, diff(old.Field(i), new.Field(i), prefix+name)...)
+			continue
+		}
+		if !reflect.DeepEqual(old.Field(i).Interface(), new.Field(i).Interface()) {
+			changes = append(changes, change{env: prefix + name, reloadable: field.Tag.Get("reload") == "true"})
+		}
+	}
+	return changes
+}
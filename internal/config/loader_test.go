@@ -0,0 +1,146 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeFile(t *testing.T, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(name, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoaderLayers(t *testing.T) {
+	t.Chdir(t.TempDir())
+	writeFile(t, "config.yaml", `
+server:
+  port: 9000
+workers:
+  pool_size: 3
+  batch_size: 20
+  batch_timeout: 2s
+kafka:
+  brokers: [a:9092, b:9092]
+rate_limit:
+  auth:
+    requests: 5
+`)
+	writeFile(t, "config.staging.yaml", "workers:\n  batch_size: 30\n")
+	t.Setenv("WORKER_POOL_SIZE", "7")
+
+	cfg, err := Load(Options{Profile: "staging", Overrides: map[string]string{"JWT_SECRET": strongSecret}})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if cfg.Server.Port != "9000" || cfg.Workers.BatchTimeout != 2*time.Second {
+		t.Errorf("config = %+v, want the values of the base file", cfg.Server)
+	}
+	if cfg.Workers.BatchSize != 30 {
+		t.Errorf("BatchSize = %d, want 30 from the profile", cfg.Workers.BatchSize)
+	}
+	if cfg.Workers.PoolSize != 7 {
+		t.Errorf("PoolSize = %d, want 7 from the environment", cfg.Workers.PoolSize)
+	}
+	if len(cfg.Kafka.Brokers) != 2 || cfg.RateLimit.Auth.Requests != 5 || cfg.RateLimit.Auth.KeyBy != "ip" {
+		t.Errorf("config = %+v, want the brokers and the auth policy of the file", cfg)
+	}
+
+	if _, err := Load(Options{Profile: "missing"}); err == nil {
+		t.Error("Load() with a missing profile file succeeded")
+	}
+}
+
+func TestLoaderTOML(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	file := filepath.Join(dir, "app.toml")
+	writeFile(t, file, "[jwt]\nsecret_key = \""+strongSecret+"\"\n[database]\ntimeout = 3\n")
+
+	cfg, err := Load(Options{File: file})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.JWT.SecretKey != strongSecret || cfg.Database.Timeout != 3*time.Second {
+		t.Errorf("config = %+v, want the values of the TOML file", cfg)
+	}
+}
+
+func TestLoaderReload(t *testing.T) {
+	t.Chdir(t.TempDir())
+	t.Setenv("JWT_SECRET", strongSecret)
+	writeFile(t, "config.yaml", "workers:\n  batch_size: 10\n")
+
+	loader, err := NewLoader(Options{})
+	if err != nil {
+		t.Fatalf("NewLoader() error = %v", err)
+	}
+	var reloads []*Config
+	loader.Subscribe(func(old, new *Config) {
+		if old.Workers.BatchSize != 10 {
+			t.Errorf("old BatchSize = %d, want 10", old.Workers.BatchSize)
+		}
+		reloads = append(reloads, new)
+	})
+
+	writeFile(t, "config.yaml", "workers:\n  batch_size: 50\nrate_limit:\n  enabled: false\nserver:\n  log_level: warn\n")
+	if err := loader.Reload(); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	if len(reloads) != 1 || loader.Config().Workers.BatchSize != 50 || loader.Config().RateLimit.Enabled || loader.Config().Server.LogLevel != "warn" {
+		t.Fatalf("after a safe change: %d reloads, config %+v", len(reloads), loader.Config().Workers)
+	}
+
+	writeFile(t, "config.yaml", "workers:\n  batch_size: 60\n  pool_size: 20\nserver:\n  port: 9000\n")
+	err = loader.Reload()
+	if err == nil || !strings.Contains(err.Error(), "SERVER_PORT, WORKER_POOL_SIZE changed but need a restart") {
+		t.Errorf("Reload() error = %v, want the settings that need a restart", err)
+	}
+
+	writeFile(t, "config.yaml", "workers:\n  batch_size: 50\nserver:\n  log_level: verbose\n")
+	if err := loader.Reload(); err == nil {
+		t.Error("Reload() with an unknown LOG_LEVEL succeeded")
+	}
+
+	writeFile(t, "config.yaml", "workers:\n  batch_size: 0\n")
+	if err := loader.Reload(); err == nil {
+		t.Error("Reload() with an invalid value succeeded")
+	}
+	if len(reloads) != 1 || loader.Config().Workers.BatchSize != 50 {
+		t.Errorf("rejected reloads changed the config: %d reloads, BatchSize %d", len(reloads), loader.Config().Workers.BatchSize)
+	}
+}
+
+func TestLoaderWatch(t *testing.T) {
+	t.Chdir(t.TempDir())
+	t.Setenv("JWT_SECRET", strongSecret)
+	writeFile(t, "config.yaml", "workers:\n  batch_size: 10\n")
+
+	loader, err := NewLoader(Options{})
+	if err != nil {
+		t.Fatalf("NewLoader() error = %v", err)
+	}
+	reloaded := make(chan *Config, 1)
+	loader.Subscribe(func(_, new *Config) {
+		select {
+		case reloaded <- new:
+		default:
+		}
+	})
+	loader.Watch()
+
+	writeFile(t, "config.yaml", "workers:\n  batch_size: 25\n")
+	select {
+	case cfg := <-reloaded:
+		if cfg.Workers.BatchSize != 25 {
+			t.Errorf("BatchSize = %d, want 25", cfg.Workers.BatchSize)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the change to the config file was not reloaded")
+	}
+}
@@ -0,0 +1,55 @@
+package config
+
+import (
+	"net/url"
+	"reflect"
+	"strings"
+)
+
+const redacted = "********"
+
+// Redacted devolve uma cópia da configuração com os campos da tag secret mascarados,
+// para ser exibida ou logada. Campos secretos vazios continuam vazios.
+func (c *Config) Redacted() *Config {
+	clone := *c
+	clone.Kafka.Brokers = append([]string(nil), c.Kafka.Brokers...)
+	redactFields(reflect.ValueOf(&clone).Elem())
+	return &clone
+}
+
+func redactFields(value reflect.Value) {
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Field(i)
+		switch value.Type().Field(i).Tag.Get("secret") {
+		case "true":
+			field.SetString(redact(field.String()))
+		case "uri":
+			field.SetString(redactURI(field.String()))
+		default:
+			if field.Kind() == reflect.Struct {
+				redactFields(field)
+			}
+		}
+	}
+}
+
+func redact(value string) string {
+	if value == "" {
+		return ""
+	}
+	return redacted
+}
+
+// redactURI mascara só a senha da URI, mantendo host e usuário visíveis.
+func redactURI(uri string) string {
+	parsed, err := url.Parse(uri)
+	if err != nil {
+		return redact(uri)
+	}
+	if _, hasPassword := parsed.User.Password(); !hasPassword {
+		return uri
+	}
+	// url.UserPassword escaparia os asteriscos
+	parsed.User = url.User(parsed.User.Username())
+	return strings.Replace(parsed.String(), "@", ":"+redacted+"@", 1)
+}
@@ -0,0 +1,247 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/lucas/go-rest-api-mongo/internal/secrets"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// units são os valores aceitos na tag unit.
+var units = map[string]time.Duration{
+	"ms": time.Millisecond,
+	"s":  time.Second,
+	"m":  time.Minute,
+	"h":  time.Hour,
+}
+
+// lookupFunc devolve o valor de um campo pela variável de ambiente ou pela chave
+// nos arquivos; ok é false quando nenhuma camada define o campo.
+type lookupFunc func(env, key string) (value string, ok bool)
+
+// decode preenche os campos de target a partir de lookup, seguindo as tags descritas
+// em Config, e devolve um problema por campo inválido. overrides substitui os
+// defaults dos campos de uma struct aninhada.
+func decode(lookup lookupFunc, target reflect.Value, prefix, path string, overrides map[string]string) []string {
+	var problems []string
+	for i := 0; i < target.NumField(); i++ {
+		field := target.Type().Field(i)
+		value := target.Field(i)
+		name := field.Tag.Get("env")
+		key := joinKey(path, snakeCase(field.Name))
+
+		if field.Type.Kind() == reflect.Struct {
+			problems = append(problems, decode(lookup, value, prefix+name, key, parseDefaults(field.Tag.Get("defaults")))...)
+			continue
+		}
+		if name == "" {
+			continue
+		}
+
+		env := prefix + name
+		raw, ok := overrides[name]
+		if !ok {
+			raw = field.Tag.Get("default")
+		}
+		// Vazio mantém o default de números, durações e booleanos; strings e listas
+		// podem ser esvaziadas de propósito (ex.: SERVER_HOST= escuta em todas as interfaces)
+		found, set := lookup(env, key)
+		if set && (found != "" || isText(field.Type)) {
+			raw = found
+		}
+
+		if field.Tag.Get("secret") != "" {
+			if file, ok := lookup(env+"_FILE", key+"_file"); ok && file != "" {
+				if set && found != "" {
+					problems = append(problems, fmt.Sprintf("%s: set either %s or %s_FILE", env, env, env))
+					continue
+				}
+				secret, err := secrets.ReadFile(file)
+				if err != nil {
+					problems = append(problems, fmt.Sprintf("%s_FILE: %v", env, err))
+					continue
+				}
+				raw = secret
+			}
+		}
+
+		if err := decodeField(value, field, raw); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", env, err))
+		}
+	}
+	return problems
+}
+
+func isText(t reflect.Type) bool {
+	return t.Kind() == reflect.String || t.Kind() == reflect.Slice
+}
+
+// decodeField converte raw para o tipo do campo e aplica required, oneof, min e max.
+func decodeField(value reflect.Value, field reflect.StructField, raw string) error {
+	if raw == "" {
+		// Segredos podem vir de um provider; ver resolveSecrets
+		if field.Tag.Get("required") == "true" && field.Tag.Get("secret") == "" {
+			return fmt.Errorf("is required")
+		}
+		return nil
+	}
+
+	switch {
+	case field.Type == durationType:
+		d, err := parseDuration(raw, field.Tag.Get("unit"))
+		if err != nil {
+			return err
+		}
+		if err := checkRange(field, float64(d), func(limit string) (float64, string, error) {
+			d, err := parseDuration(limit, field.Tag.Get("unit"))
+			return float64(d), d.String(), err
+		}); err != nil {
+			return err
+		}
+		value.SetInt(int64(d))
+
+	case field.Type.Kind() == reflect.Int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return fmt.Errorf("%q is not an integer", raw)
+		}
+		if err := checkRange(field, float64(n), func(limit string) (float64, string, error) {
+			n, err := strconv.ParseFloat(limit, 64)
+			return n, limit, err
+		}); err != nil {
+			return err
+		}
+		value.SetInt(int64(n))
+
+	case field.Type.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("%q is not a boolean", raw)
+		}
+		value.SetBool(b)
+
+	case field.Type.Kind() == reflect.String:
+		canonical, err := oneOf(field, raw)
+		if err != nil {
+			return err
+		}
+		value.SetString(canonical)
+
+	case field.Type.Kind() == reflect.Slice && field.Type.Elem().Kind() == reflect.String:
+		value.Set(reflect.ValueOf(splitList(raw)))
+
+	default:
+		panic(fmt.Sprintf("config: unsupported field type %s of %s", field.Type, field.Name))
+	}
+	return nil
+}
+
+// parseDuration aceita um número na unidade do campo ("10") ou uma duração do Go ("10s").
+func parseDuration(raw, unit string) (time.Duration, error) {
+	if n, err := strconv.ParseInt(raw, 10, 64); err == nil {
+		multiplier, ok := units[unit]
+		if !ok {
+			return 0, fmt.Errorf("%q needs a unit, e.g. %q", raw, raw+"s")
+		}
+		return time.Duration(n) * multiplier, nil
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a duration", raw)
+	}
+	return d, nil
+}
+
+// checkRange aplica as tags min e max; parse converte um limite para o tipo do campo e
+// o formata para a mensagem de erro.
+func checkRange(field reflect.StructField, n float64, parse func(limit string) (float64, string, error)) error {
+	if tag := field.Tag.Get("min"); tag != "" {
+		min, formatted, err := parse(tag)
+		if err != nil {
+			panic(fmt.Sprintf("config: invalid min tag of %s: %v", field.Name, err))
+		}
+		if n < min {
+			return fmt.Errorf("must be at least %s", formatted)
+		}
+	}
+	if tag := field.Tag.Get("max"); tag != "" {
+		max, formatted, err := parse(tag)
+		if err != nil {
+			panic(fmt.Sprintf("config: invalid max tag of %s: %v", field.Name, err))
+		}
+		if n > max {
+			return fmt.Errorf("must be at most %s", formatted)
+		}
+	}
+	return nil
+}
+
+// oneOf confere a tag oneof e devolve o valor com a grafia da tag (ex.: "Mongo" vira
+// "mongo"), para que o resto do código compare valores exatos.
+func oneOf(field reflect.StructField, raw string) (string, error) {
+	options := strings.Fields(field.Tag.Get("oneof"))
+	if len(options) == 0 {
+		return raw, nil
+	}
+	for _, option := range options {
+		if strings.EqualFold(option, raw) {
+			return option, nil
+		}
+	}
+	return "", fmt.Errorf("%q must be one of %s", raw, strings.Join(options, ", "))
+}
+
+// parseDefaults lê a tag defaults ("REQUESTS=10 KEY=ip").
+func parseDefaults(tag string) map[string]string {
+	if tag == "" {
+		return nil
+	}
+	defaults := make(map[string]string)
+	for _, pair := range strings.Fields(tag) {
+		name, value, _ := strings.Cut(pair, "=")
+		defaults[name] = value
+	}
+	return defaults
+}
+
+func joinKey(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// snakeCase converte o nome de um campo para a chave dos arquivos: "OpenAPIValidate"
+// vira "open_api_validate" e "CAFile", "ca_file".
+func snakeCase(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if !unicode.IsUpper(prev) || nextLower {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
+
+// splitList separa uma lista por vírgulas, ignorando espaços e itens vazios.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,100 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/lucas/go-rest-api-mongo/internal/secrets"
+)
+
+// keystores guarda os keystores já abertos, para que a chave derivada da passphrase
+// (lenta de propósito) seja reaproveitada a cada recarga.
+var keystores sync.Map
+
+// Provider monta a cadeia de providers de SECRET_PROVIDERS; vazia, nenhum provider é
+// consultado.
+func (c SecretsConfig) Provider() (secrets.SecretProvider, error) {
+	var chain secrets.Chain
+	for _, name := range c.Providers {
+		switch name {
+		case "file":
+			if c.Dir == "" {
+				return nil, errors.New("the file provider needs SECRETS_DIR")
+			}
+			chain = append(chain, secrets.NewFileProvider(c.Dir))
+		case "env":
+			chain = append(chain, secrets.NewEnvProvider())
+		case "keystore":
+			keystore, err := c.Keystore()
+			if err != nil {
+				return nil, err
+			}
+			chain = append(chain, keystore)
+		default:
+			return nil, fmt.Errorf("unknown secret provider %q: use file, env or keystore", name)
+		}
+	}
+	return chain, nil
+}
+
+// Keystore abre o keystore de SECRETS_KEYSTORE_PATH.
+func (c SecretsConfig) Keystore() (*secrets.Keystore, error) {
+	if c.KeystorePath == "" || c.KeystorePassphrase == "" {
+		return nil, errors.New("the keystore needs SECRETS_KEYSTORE_PATH and SECRETS_KEYSTORE_PASSPHRASE")
+	}
+	id := c.KeystorePath + "\x00" + c.KeystorePassphrase
+	keystore, _ := keystores.LoadOrStore(id, secrets.NewKeystore(c.KeystorePath, c.KeystorePassphrase))
+	return keystore.(*secrets.Keystore), nil
+}
+
+// resolveSecrets troca os campos secretos pelos valores dos providers, que têm
+// precedência sobre as camadas da configuração, e confere os segredos obrigatórios.
+// A seção Secrets não passa pelos providers: ela é que diz como chegar neles.
+func (c *Config) resolveSecrets() []string {
+	provider, err := c.Secrets.Provider()
+	if err != nil {
+		return []string{"SECRET_PROVIDERS: " + err.Error()}
+	}
+
+	var problems []string
+	sections := reflect.ValueOf(c).Elem()
+	for i := 0; i < sections.NumField(); i++ {
+		if sections.Field(i).Type() == reflect.TypeOf(SecretsConfig{}) {
+			continue
+		}
+		walkFields(sections.Field(i), "", func(env string, field reflect.StructField, value reflect.Value) {
+			if field.Tag.Get("secret") == "" {
+				return
+			}
+			secret, ok, err := provider.Secret(env)
+			switch {
+			case err != nil:
+				problems = append(problems, fmt.Sprintf("%s: %v", env, err))
+			case ok:
+				value.SetString(secret)
+			}
+			if value.String() == "" && field.Tag.Get("required") == "true" {
+				problems = append(problems, fmt.Sprintf("%s: is required (set it, %s_FILE or a secret provider)", env, env))
+			}
+		})
+	}
+	return problems
+}
+
+// walkFields chama fn para cada campo com a tag env de target, com o nome completo
+// da variável de ambiente.
+func walkFields(target reflect.Value, prefix string, fn func(env string, field reflect.StructField, value reflect.Value)) {
+	for i := 0; i < target.NumField(); i++ {
+		field := target.Type().Field(i)
+		name := field.Tag.Get("env")
+		if field.Type.Kind() == reflect.Struct {
+			walkFields(target.Field(i), prefix+name, fn)
+			continue
+		}
+		if name != "" {
+			fn(prefix+name, field, target.Field(i))
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadSecretFromFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "jwt_secret")
+	writeFile(t, file, strongSecret+"\n")
+
+	cfg, err := loadValues(map[string]string{"JWT_SECRET_FILE": file})
+	if err != nil {
+		t.Fatalf("load() error = %v", err)
+	}
+	if cfg.JWT.SecretKey != strongSecret {
+		t.Errorf("SecretKey = %q, want the file content without the newline", cfg.JWT.SecretKey)
+	}
+
+	_, err = loadValues(map[string]string{"JWT_SECRET": strongSecret, "JWT_SECRET_FILE": file})
+	if err == nil || !strings.Contains(err.Error(), "JWT_SECRET: set either JWT_SECRET or JWT_SECRET_FILE") {
+		t.Errorf("load() with both set error = %v", err)
+	}
+
+	_, err = loadValues(map[string]string{"JWT_SECRET_FILE": filepath.Join(t.TempDir(), "missing")})
+	if err == nil || !strings.Contains(err.Error(), "JWT_SECRET_FILE:") {
+		t.Errorf("load() with a missing file error = %v", err)
+	}
+}
+
+func TestLoadSecretFromProvider(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "jwt_secret"), strongSecret)
+	writeFile(t, filepath.Join(dir, "mongo_password"), "from-provider")
+
+	cfg, err := loadValues(map[string]string{
+		"SECRET_PROVIDERS": "file",
+		"SECRETS_DIR":      dir,
+		"MONGO_PASSWORD":   "from-env",
+	})
+	if err != nil {
+		t.Fatalf("load() error = %v", err)
+	}
+	if cfg.JWT.SecretKey != strongSecret || cfg.Database.Password != "from-provider" {
+		t.Errorf("secrets = %q, %q; want the provider values", cfg.JWT.SecretKey, cfg.Database.Password)
+	}
+
+	_, err = loadValues(map[string]string{"SECRET_PROVIDERS": "file", "SECRETS_DIR": t.TempDir()})
+	if err == nil || !strings.Contains(err.Error(), "JWT_SECRET: is required (set it, JWT_SECRET_FILE or a secret provider)") {
+		t.Errorf("load() without the secret error = %v", err)
+	}
+
+	_, err = loadValues(map[string]string{"JWT_SECRET": strongSecret, "SECRET_PROVIDERS": "vault"})
+	if err == nil || !strings.Contains(err.Error(), `SECRET_PROVIDERS: unknown secret provider "vault"`) {
+		t.Errorf("load() with an unknown provider error = %v", err)
+	}
+}
+
+func TestLoadSecretsSection(t *testing.T) {
+	t.Chdir(t.TempDir())
+	t.Setenv("SECRET_PROVIDERS", "keystore")
+	t.Setenv("SECRETS_KEYSTORE_PATH", "secrets.keystore")
+
+	// Sem JWT_SECRET: o keystore precisa ser gerenciável antes de guardar o segredo
+	cfg, err := LoadSecrets(Options{Overrides: map[string]string{"SECRETS_KEYSTORE_PASSPHRASE": "pass"}})
+	if err != nil {
+		t.Fatalf("LoadSecrets() error = %v", err)
+	}
+	keystore, err := cfg.Keystore()
+	if err != nil {
+		t.Fatalf("Keystore() error = %v", err)
+	}
+	if err := keystore.Set("JWT_SECRET", strongSecret); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	t.Setenv("SECRETS_KEYSTORE_PASSPHRASE", "pass")
+	full, err := Load(Options{})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if full.JWT.SecretKey != strongSecret {
+		t.Errorf("SecretKey = %q, want the keystore value", full.JWT.SecretKey)
+	}
+}
+
+func TestLoaderReloadsRotatedSecrets(t *testing.T) {
+	t.Chdir(t.TempDir())
+	file := filepath.Join(t.TempDir(), "jwt_secret")
+	writeFile(t, file, strongSecret)
+	t.Setenv("JWT_SECRET_FILE", file)
+
+	loader, err := NewLoader(Options{})
+	if err != nil {
+		t.Fatalf("NewLoader() error = %v", err)
+	}
+	var rotated string
+	loader.Subscribe(func(old, new *Config) { rotated = new.JWT.SecretKey })
+
+	next := strings.ToUpper(strongSecret)
+	if err := os.WriteFile(file, []byte(next), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := loader.Reload(); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	if rotated != next || loader.Config().JWT.SecretKey != next {
+		t.Errorf("SecretKey after the rotation = %q, want %q", loader.Config().JWT.SecretKey, next)
+	}
+}
@@ -0,0 +1,110 @@
+package config
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lucas/go-rest-api-mongo/pkg/cron"
+)
+
+// minJWTSecretLength é o tamanho mínimo do JWT_SECRET em produção (256 bits para HS256).
+const minJWTSecretLength = 32
+
+// placeholderSecrets são segredos de exemplo que nunca devem chegar à produção.
+var placeholderSecrets = []string{
+	"your-super-secret-jwt-key-change-in-production",
+	"supersecretkey",
+	"secret",
+	"changeme",
+}
+
+// ValidationError lista todos os problemas da configuração, um por variável.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+// validate confere as regras que envolvem mais de um campo e não cabem nas tags.
+func (c *Config) validate() []string {
+	var problems []string
+	add := func(problem string) { problems = append(problems, problem) }
+
+	if port, err := strconv.Atoi(c.Server.Port); c.Server.Port != "" && (err != nil || port < 1 || port > 65535) {
+		add("SERVER_PORT: " + strconv.Quote(c.Server.Port) + " is not a valid port")
+	}
+	if c.Server.Mode == "production" && c.JWT.SecretKey != "" && weakSecret(c.JWT.SecretKey) {
+		add("JWT_SECRET: too weak for production: use a random value with at least " + strconv.Itoa(minJWTSecretLength) + " characters")
+	}
+
+	if c.Database.Driver == "mongo" {
+		if c.Database.URI == "" {
+			add("MONGO_URI: is required with STORAGE_DRIVER=mongo")
+		}
+		if c.Database.DatabaseName == "" {
+			add("MONGO_DATABASE: is required with STORAGE_DRIVER=mongo")
+		}
+	} else {
+		if c.Server.Role == "api" || c.Server.Role == "worker" {
+			add("APP_ROLE: " + c.Server.Role + " needs job queues shared between processes: use STORAGE_DRIVER=mongo")
+		}
+		if c.RateLimit.Store == "mongo" {
+			add("RATE_LIMIT_STORE: mongo requires STORAGE_DRIVER=mongo")
+		}
+	}
+
+	switch c.Events.Driver {
+	case "kafka":
+		if len(c.Kafka.Brokers) == 0 {
+			add("KAFKA_BROKERS: is required with EVENT_BUS_DRIVER=kafka")
+		}
+	case "file":
+		if c.Events.FilePath == "" {
+			add("EVENT_BUS_FILE_PATH: is required with EVENT_BUS_DRIVER=file")
+		}
+	}
+
+	if c.Kafka.SASL.Mechanism != "" && (c.Kafka.SASL.Username == "" || c.Kafka.SASL.Password == "") {
+		add("KAFKA_SASL_USERNAME, KAFKA_SASL_PASSWORD: are required with KAFKA_SASL_MECHANISM")
+	}
+	if (c.Kafka.TLS.CertFile == "") != (c.Kafka.TLS.KeyFile == "") {
+		add("KAFKA_TLS_CERT_FILE, KAFKA_TLS_KEY_FILE: must be set together")
+	}
+
+	// PoolSize 0 já foi reportado pela tag min
+	if min, max := c.Workers.PoolBounds(); c.Workers.PoolSize > 0 && (c.Workers.PoolSize < min || c.Workers.PoolSize > max) {
+		add("WORKER_POOL_SIZE: must be between WORKER_POOL_MIN (" + strconv.Itoa(min) + ") and WORKER_POOL_MAX (" + strconv.Itoa(max) + ")")
+	}
+	if c.Workers.RetryMaxDelay < c.Workers.RetryBaseDelay {
+		add("RETRY_MAX_DELAY_MS: must not be less than RETRY_BASE_DELAY_MS")
+	}
+	if _, err := time.LoadLocation(c.Scheduler.Timezone); err != nil {
+		add("SCHEDULER_TIMEZONE: " + strconv.Quote(c.Scheduler.Timezone) + " is not a known time zone")
+	}
+	schedules := []struct{ env, spec string }{
+		{"SCHEDULE_RETRY_EVENTS", c.Scheduler.RetryEvents},
+		{"SCHEDULE_PURGE_DEAD_LETTERS", c.Scheduler.PurgeDeadLetters},
+	}
+	for _, schedule := range schedules {
+		if _, err := cron.Parse(schedule.spec); schedule.spec != "" && err != nil {
+			add(schedule.env + ": " + err.Error())
+		}
+	}
+
+	return problems
+}
+
+func weakSecret(secret string) bool {
+	if len(secret) < minJWTSecretLength {
+		return true
+	}
+	for _, placeholder := range placeholderSecrets {
+		if strings.EqualFold(secret, placeholder) {
+			return true
+		}
+	}
+	return false
+}
@@ -2,6 +2,11 @@ package database
 
 import (
 	"context"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/lucas/go-rest-api-mongo/internal/config"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -9,12 +14,30 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/readpref"
 )
 
+// MongoDB é a conexão com o banco. O client pode ser trocado por Reconnect quando as
+// credenciais são rotacionadas; as coleções de Collection sempre usam o client atual.
 type MongoDB struct {
-	Client   *mongo.Client
-	Database *mongo.Database
+	name    string
+	timeout time.Duration
+
+	mu      sync.Mutex // serializa Reconnect e Close
+	current atomic.Pointer[mongo.Client]
 }
 
 func NewMongoDB(cfg *config.Config) (*MongoDB, error) {
+	client, err := connect(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &MongoDB{name: cfg.Database.DatabaseName, timeout: cfg.Database.Timeout}
+	m.current.Store(client)
+	return m, nil
+}
+
+// connect abre um client com a URI de cfg e confere a conexão. MONGO_USER e
+// MONGO_PASSWORD, quando definidos, substituem as credenciais da URI.
+func connect(cfg *config.Config) (*mongo.Client, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), cfg.Database.Timeout)
 	defer cancel()
 
@@ -22,24 +45,73 @@ func NewMongoDB(cfg *config.Config) (*MongoDB, error) {
 		ApplyURI(cfg.Database.URI).
 		SetServerSelectionTimeout(cfg.Database.Timeout)
 
+	if cfg.Database.Username != "" || cfg.Database.Password != "" {
+		credential := options.Credential{}
+		if clientOpts.Auth != nil {
+			credential = *clientOpts.Auth
+		}
+		if cfg.Database.Username != "" {
+			credential.Username = cfg.Database.Username
+		}
+		if cfg.Database.Password != "" {
+			credential.Password, credential.PasswordSet = cfg.Database.Password, true
+		}
+		clientOpts.SetAuth(credential)
+	}
+
 	client, err := mongo.Connect(ctx, clientOpts)
 	if err != nil {
 		return nil, err
 	}
 
-	err = client.Ping(ctx, readpref.Primary())
-	if err != nil {
+	if err := client.Ping(ctx, readpref.Primary()); err != nil {
+		client.Disconnect(context.Background())
 		return nil, err
 	}
+	return client, nil
+}
 
-	db := client.Database(cfg.Database.DatabaseName)
+// Client devolve o client atual.
+func (m *MongoDB) Client() *mongo.Client {
+	return m.current.Load()
+}
+
+// Database devolve o banco no client atual.
+func (m *MongoDB) Database() *mongo.Database {
+	return m.Client().Database(m.name)
+}
+
+// Collection devolve uma função que resolve a coleção no client atual, para os
+// repositórios continuarem funcionando depois de um Reconnect.
+func (m *MongoDB) Collection(name string) func() *mongo.Collection {
+	return func() *mongo.Collection {
+		return m.Database().Collection(name)
+	}
+}
+
+// Reconnect abre um client com a URI e as credenciais de cfg e, se ele conectar, o
+// coloca no lugar do atual. O client antigo é fechado depois do timeout, para que as
+// operações em andamento terminem; se o novo falhar, o atual continua em uso.
+func (m *MongoDB) Reconnect(cfg *config.Config) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	client, err := connect(cfg)
+	if err != nil {
+		return fmt.Errorf("reconnecting to MongoDB: %w", err)
+	}
+	old := m.current.Swap(client)
 
-	return &MongoDB{
-		Client:   client,
-		Database: db,
-	}, nil
+	time.AfterFunc(m.timeout, func() {
+		if err := old.Disconnect(context.Background()); err != nil {
+			log.Printf("Error closing the previous MongoDB client: %v", err)
+		}
+	})
+	return nil
 }
 
 func (m *MongoDB) Close(ctx context.Context) error {
-	return m.Client.Disconnect(ctx)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.Client().Disconnect(ctx)
 }
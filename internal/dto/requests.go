@@ -4,9 +4,32 @@ type RegisterRequest struct {
 	Name     string `json:"name" binding:"required"`
 	Email    string `json:"email" binding:"required,email"`
 	Password string `json:"password" binding:"required,min=6"`
+	Locale   string `json:"locale,omitempty" binding:"omitempty,oneof=en pt-BR"`
 }
 
 type LoginRequest struct {
 	Email    string `json:"email" binding:"required,email"`
 	Password string `json:"password" binding:"required"`
 }
+
+// UpdateProfileRequest altera apenas os campos enviados.
+type UpdateProfileRequest struct {
+	Name  *string `json:"name,omitempty" binding:"omitempty,min=1"`
+	Email *string `json:"email,omitempty" binding:"omitempty,email"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required,min=6"`
+}
+
+// UpdateWorkerPoolRequest altera apenas os campos enviados.
+type UpdateWorkerPoolRequest struct {
+	Workers   *int  `json:"workers,omitempty" binding:"omitempty,min=1"`
+	Autoscale *bool `json:"autoscale,omitempty"`
+}
+
+// UpdateScheduleRequest pausa ou retoma os disparos do cron de um job agendado.
+type UpdateScheduleRequest struct {
+	Paused *bool `json:"paused" binding:"required"`
+}
@@ -1,5 +1,7 @@
 package dto
 
+import "github.com/lucas/go-rest-api-mongo/internal/models"
+
 type UserResponse struct {
 	ID        string `json:"id"`
 	Name      string `json:"name"`
@@ -11,3 +13,36 @@ type LoginResponse struct {
 	Token string       `json:"token"`
 	User  UserResponse `json:"user"`
 }
+
+type MessageResponse struct {
+	Message string `json:"message"`
+}
+
+type RegisterResponse struct {
+	Message string       `json:"message"`
+	User    UserResponse `json:"user"`
+}
+
+type DeadLetterListResponse struct {
+	DeadLetters []*models.DeadLetter `json:"dead_letters"`
+	Limit       int64                `json:"limit"`
+	Offset      int64                `json:"offset"`
+}
+
+type ScheduleRunListResponse struct {
+	Runs  []*models.ScheduleRun `json:"runs"`
+	Limit int64                 `json:"limit"`
+}
+
+type HealthResponse struct {
+	Status string            `json:"status"`
+	Role   string            `json:"role,omitempty"`
+	Checks map[string]string `json:"checks,omitempty"` // "ok" ou o erro de cada verificação
+}
+
+// MetricsResponse traz as métricas de cada componente da role do processo.
+type MetricsResponse struct {
+	Role    string                 `json:"role"`
+	Metrics map[string]interface{} `json:"metrics"`
+	Errors  map[string]string      `json:"errors,omitempty"` // componentes que não responderam
+}
@@ -0,0 +1,95 @@
+package events
+
+import (
+	"fmt"
+
+	"github.com/hamba/avro/v2"
+)
+
+const ContentTypeAvro = "application/avro"
+
+// avroAPI usa as tags json dos structs de evento como nomes dos campos Avro.
+var avroAPI = avro.Config{TagKey: "json"}.Freeze()
+
+type avroSerializer struct {
+	registry SchemaRegistry
+	ids      map[string]int
+	schemas  map[string]avro.Schema // schema atual (de leitura) por tipo de evento
+}
+
+func newAvroSerializer(registry SchemaRegistry) (*avroSerializer, error) {
+	ids, err := registerSchemas(registry, FormatAvro)
+	if err != nil {
+		return nil, err
+	}
+
+	schemas := make(map[string]avro.Schema, len(ids))
+	for eventType := range ids {
+		_, source, err := SchemaSource(eventType, FormatAvro)
+		if err != nil {
+			return nil, err
+		}
+		schema, err := parseAvro(source)
+		if err != nil {
+			return nil, fmt.Errorf("parsing avro schema for %s: %w", eventType, err)
+		}
+		schemas[eventType] = schema
+	}
+
+	return &avroSerializer{registry: registry, ids: ids, schemas: schemas}, nil
+}
+
+func (s *avroSerializer) Format() Format      { return FormatAvro }
+func (s *avroSerializer) ContentType() string { return ContentTypeAvro }
+
+func (s *avroSerializer) Marshal(event Event) ([]byte, error) {
+	schema, ok := s.schemas[event.EventType()]
+	if !ok {
+		return nil, fmt.Errorf("no avro schema for %s", event.EventType())
+	}
+	payload, err := avroAPI.Marshal(schema, event)
+	if err != nil {
+		return nil, err
+	}
+	return wireEncode(s.ids[event.EventType()], payload), nil
+}
+
+// Unmarshal lê o payload com o schema de escrita (pelo ID do prefixo) resolvido contra
+// o schema atual, então eventos gravados por versões compatíveis continuam legíveis.
+func (s *avroSerializer) Unmarshal(data []byte, event Event) error {
+	id, payload, err := wireDecode(data)
+	if err != nil {
+		return err
+	}
+	registered, err := s.registry.SchemaByID(id)
+	if err != nil {
+		return err
+	}
+	if registered.SchemaType != SchemaTypeAvro {
+		return fmt.Errorf("schema %d is %s, not %s", id, registered.SchemaType, SchemaTypeAvro)
+	}
+
+	reader, ok := s.schemas[event.EventType()]
+	if !ok {
+		return fmt.Errorf("no avro schema for %s", event.EventType())
+	}
+	writer, err := parseAvro(registered.Schema)
+	if err != nil {
+		return err
+	}
+
+	resolved := reader
+	if writer.Fingerprint() != reader.Fingerprint() {
+		resolved, err = avro.NewSchemaCompatibility().Resolve(reader, writer)
+		if err != nil {
+			return err
+		}
+	}
+	return avroAPI.Unmarshal(resolved, payload, event)
+}
+
+// parseAvro usa um cache próprio: versões diferentes do mesmo record têm o mesmo
+// nome e não podem dividir o cache global da biblioteca.
+func parseAvro(source string) (avro.Schema, error) {
+	return avro.ParseWithCache(source, "", &avro.SchemaCache{})
+}
@@ -0,0 +1,239 @@
+package events
+
+import (
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lucas/go-rest-api-mongo/internal/messaging"
+)
+
+const (
+	SpecVersion = "1.0"
+
+	ContentTypeJSON       = "application/json"
+	ContentTypeCloudEvent = "application/cloudevents+json"
+)
+
+// Mode é o modo do protocol binding de Kafka do CloudEvents.
+type Mode string
+
+const (
+	// ModeStructured envia o envelope inteiro como payload.
+	ModeStructured Mode = "structured"
+	// ModeBinary envia só o data no payload e os atributos em headers ce_*.
+	ModeBinary Mode = "binary"
+)
+
+var ErrNotCloudEvent = errors.New("message is not a CloudEvent")
+
+// CloudEvent é o envelope CloudEvents 1.0 no formato JSON.
+type CloudEvent struct {
+	SpecVersion     string          `json:"specversion"`
+	ID              string          `json:"id"`
+	Source          string          `json:"source"`
+	Type            string          `json:"type"`
+	Subject         string          `json:"subject,omitempty"`
+	Time            time.Time       `json:"time"`
+	DataContentType string          `json:"datacontenttype,omitempty"`
+	DataSchema      string          `json:"dataschema,omitempty"`
+	Data            json.RawMessage `json:"data,omitempty"`
+	DataBase64      []byte          `json:"data_base64,omitempty"` // data binário (Protobuf, Avro) no modo estruturado
+}
+
+// DataAs decodifica um data JSON no evento tipado; para os outros formatos use
+// Encoder.DecodeData.
+func (ce CloudEvent) DataAs(v interface{}) error {
+	return json.Unmarshal(ce.Data, v)
+}
+
+// Encoder monta o envelope e a mensagem do EventBus de acordo com o modo, com o
+// data no formato do serializer.
+type Encoder struct {
+	mode          Mode
+	source        string
+	schemaBaseURL string
+	serializer    Serializer
+}
+
+// NewEncoder cria o encoder; com serializer nil o data é JSON.
+func NewEncoder(mode Mode, source, schemaBaseURL string, serializer Serializer) (*Encoder, error) {
+	switch mode {
+	case ModeStructured, ModeBinary:
+	case "":
+		mode = ModeStructured
+	default:
+		return nil, fmt.Errorf("unknown CloudEvents mode %q", mode)
+	}
+	if serializer == nil {
+		serializer = jsonSerializer{}
+	}
+	return &Encoder{
+		mode:          mode,
+		source:        source,
+		schemaBaseURL: strings.TrimSuffix(schemaBaseURL, "/"),
+		serializer:    serializer,
+	}, nil
+}
+
+// identifiedEvent é um evento com o atributo id definido por quem publica.
+type identifiedEvent struct {
+	Event
+	id string
+}
+
+// WithID fixa o id do CloudEvent, que por padrão é aleatório. Publicar de novo o
+// mesmo evento com o mesmo id permite que consumidores descartem a duplicata.
+func WithID(event Event, id string) Event {
+	return identifiedEvent{Event: event, id: id}
+}
+
+// NameID gera um id determinístico (UUID v5, namespace URL) a partir de name.
+func NameID(name string) string {
+	namespace := [16]byte{0x6b, 0xa7, 0xb8, 0x11, 0x9d, 0xad, 0x11, 0xd1, 0x80, 0xb4, 0x00, 0xc0, 0x4f, 0xd4, 0x30, 0xc8}
+	h := sha1.New()
+	h.Write(namespace[:])
+	h.Write([]byte(name))
+
+	var b [16]byte
+	copy(b[:], h.Sum(nil))
+	b[6] = (b[6] & 0x0f) | 0x50
+	b[8] = (b[8] & 0x3f) | 0x80
+	return formatUUID(b)
+}
+
+func (e *Encoder) Envelope(event Event) (CloudEvent, error) {
+	id := ""
+	if identified, ok := event.(identifiedEvent); ok {
+		event, id = identified.Event, identified.id
+	} else {
+		id = newEventID()
+	}
+
+	data, err := e.serializer.Marshal(event)
+	if err != nil {
+		return CloudEvent{}, err
+	}
+
+	ce := CloudEvent{
+		SpecVersion:     SpecVersion,
+		ID:              id,
+		Source:          e.source,
+		Type:            event.EventType(),
+		Subject:         event.Subject(),
+		Time:            time.Now().UTC(),
+		DataContentType: e.serializer.ContentType(),
+		DataSchema:      e.schemaBaseURL + "/" + SchemaName(event.EventType()),
+	}
+	if e.serializer.Format() == FormatJSON {
+		ce.Data = data
+	} else {
+		ce.DataSchema += "?format=" + string(e.serializer.Format())
+		ce.DataBase64 = data
+	}
+	return ce, nil
+}
+
+// DecodeData decodifica o data do envelope em event (um ponteiro para o struct do
+// evento), de acordo com o datacontenttype.
+func (e *Encoder) DecodeData(ce CloudEvent, event Event) error {
+	switch {
+	case ce.DataContentType == "" || ce.DataContentType == ContentTypeJSON:
+		return json.Unmarshal(ce.Data, event)
+	case ce.DataContentType == e.serializer.ContentType():
+		data := ce.DataBase64
+		if data == nil {
+			data = ce.Data
+		}
+		return e.serializer.Unmarshal(data, event)
+	default:
+		return fmt.Errorf("unsupported datacontenttype %q", ce.DataContentType)
+	}
+}
+
+// Encode gera a mensagem do evento para o tópico, com o subject como key.
+func (e *Encoder) Encode(topic string, event Event) (messaging.Message, error) {
+	ce, err := e.Envelope(event)
+	if err != nil {
+		return messaging.Message{}, err
+	}
+
+	msg := messaging.Message{Topic: topic, Key: ce.Subject, Time: ce.Time}
+
+	if e.mode == ModeBinary {
+		msg.Headers = map[string]string{
+			"content-type":   ce.DataContentType,
+			"ce_specversion": ce.SpecVersion,
+			"ce_id":          ce.ID,
+			"ce_source":      ce.Source,
+			"ce_type":        ce.Type,
+			"ce_subject":     ce.Subject,
+			"ce_time":        ce.Time.Format(time.RFC3339Nano),
+			"ce_dataschema":  ce.DataSchema,
+		}
+		msg.Payload = ce.Data
+		if ce.DataBase64 != nil {
+			msg.Payload = ce.DataBase64
+		}
+		return msg, nil
+	}
+
+	msg.Headers = map[string]string{"content-type": ContentTypeCloudEvent}
+	msg.Payload, err = json.Marshal(ce)
+	return msg, err
+}
+
+// Decode lê um CloudEvent de uma mensagem em qualquer um dos dois modos.
+func Decode(msg messaging.Message) (CloudEvent, error) {
+	if specVersion, ok := msg.Headers["ce_specversion"]; ok {
+		ce := CloudEvent{
+			SpecVersion:     specVersion,
+			ID:              msg.Headers["ce_id"],
+			Source:          msg.Headers["ce_source"],
+			Type:            msg.Headers["ce_type"],
+			Subject:         msg.Headers["ce_subject"],
+			DataContentType: msg.Headers["content-type"],
+			DataSchema:      msg.Headers["ce_dataschema"],
+		}
+		if ce.DataContentType == "" || ce.DataContentType == ContentTypeJSON {
+			ce.Data = msg.Payload
+		} else {
+			ce.DataBase64 = msg.Payload
+		}
+		if value := msg.Headers["ce_time"]; value != "" {
+			t, err := time.Parse(time.RFC3339Nano, value)
+			if err != nil {
+				return CloudEvent{}, fmt.Errorf("invalid ce_time: %w", err)
+			}
+			ce.Time = t
+		}
+		return ce, nil
+	}
+
+	var ce CloudEvent
+	if err := json.Unmarshal(msg.Payload, &ce); err != nil || ce.SpecVersion == "" {
+		return CloudEvent{}, ErrNotCloudEvent
+	}
+	return ce, nil
+}
+
+// newEventID gera um UUID v4 para o atributo id.
+func newEventID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(err)
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return formatUUID(b)
+}
+
+func formatUUID(b [16]byte) string {
+	s := hex.EncodeToString(b[:])
+	return s[0:8] + "-" + s[8:12] + "-" + s[12:16] + "-" + s[16:20] + "-" + s[20:]
+}
@@ -0,0 +1,163 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/bufbuild/protocompile"
+	"github.com/hamba/avro/v2"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// checkCompatible verifica se dados gravados com writer podem ser lidos com reader.
+func checkCompatible(schemaType, reader, writer string) error {
+	switch schemaType {
+	case SchemaTypeAvro:
+		return checkAvroCompatible(reader, writer)
+	case SchemaTypeProtobuf:
+		return checkProtobufCompatible(reader, writer)
+	case SchemaTypeJSON:
+		return checkJSONCompatible(reader, writer)
+	default:
+		return fmt.Errorf("unknown schema type %q", schemaType)
+	}
+}
+
+func checkAvroCompatible(reader, writer string) error {
+	readerSchema, err := parseAvro(reader)
+	if err != nil {
+		return err
+	}
+	writerSchema, err := parseAvro(writer)
+	if err != nil {
+		return err
+	}
+	return avro.NewSchemaCompatibility().Compatible(readerSchema, writerSchema)
+}
+
+// checkProtobufCompatible compara as mensagens pelo número dos campos: adicionar e
+// remover campos é compatível no proto3, mudar o tipo ou a cardinalidade não é.
+func checkProtobufCompatible(reader, writer string) error {
+	readerMessages, err := protoMessagesOf(reader)
+	if err != nil {
+		return err
+	}
+	writerMessages, err := protoMessagesOf(writer)
+	if err != nil {
+		return err
+	}
+
+	for name, writerMessage := range writerMessages {
+		readerMessage, ok := readerMessages[name]
+		if !ok {
+			return fmt.Errorf("message %s was removed", name)
+		}
+
+		writerFields := writerMessage.Fields()
+		for i := 0; i < writerFields.Len(); i++ {
+			writerField := writerFields.Get(i)
+			readerField := readerMessage.Fields().ByNumber(writerField.Number())
+			if readerField == nil {
+				continue
+			}
+			if protoFieldType(readerField) != protoFieldType(writerField) {
+				return fmt.Errorf("field %d of %s changed from %s to %s",
+					writerField.Number(), name, protoFieldType(writerField), protoFieldType(readerField))
+			}
+		}
+	}
+	return nil
+}
+
+func protoFieldType(field protoreflect.FieldDescriptor) string {
+	typ := field.Kind().String()
+	switch {
+	case field.Message() != nil:
+		typ = string(field.Message().FullName())
+	case field.Enum() != nil:
+		typ = string(field.Enum().FullName())
+	}
+	return field.Cardinality().String() + " " + typ
+}
+
+// protoMessagesOf compila o texto de um .proto e retorna as mensagens pelo nome completo.
+func protoMessagesOf(source string) (map[string]protoreflect.MessageDescriptor, error) {
+	const name = "schema.proto"
+	compiler := protocompile.Compiler{
+		Resolver: protocompile.WithStandardImports(&protocompile.SourceResolver{
+			Accessor: func(path string) (io.ReadCloser, error) {
+				if path != name {
+					return nil, fmt.Errorf("import %s is not supported", path)
+				}
+				return io.NopCloser(strings.NewReader(source)), nil
+			},
+		}),
+	}
+
+	files, err := compiler.Compile(context.Background(), name)
+	if err != nil {
+		return nil, err
+	}
+
+	messages := make(map[string]protoreflect.MessageDescriptor)
+	all := files[0].Messages()
+	for i := 0; i < all.Len(); i++ {
+		messages[string(all.Get(i).FullName())] = all.Get(i)
+	}
+	return messages, nil
+}
+
+// jsonSchema é o subconjunto de JSON Schema usado nos schemas de eventos.
+type jsonSchema struct {
+	Type                 string                `json:"type"`
+	Required             []string              `json:"required"`
+	Properties           map[string]jsonSchema `json:"properties"`
+	AdditionalProperties *bool                 `json:"additionalProperties"`
+}
+
+// checkJSONCompatible exige que todo campo obrigatório do reader também seja
+// obrigatório no writer, que os tipos dos campos em comum não mudem e, se o reader
+// não aceita propriedades extras, que ele conheça todos os campos do writer.
+func checkJSONCompatible(reader, writer string) error {
+	var r, w jsonSchema
+	if err := json.Unmarshal([]byte(reader), &r); err != nil {
+		return err
+	}
+	if err := json.Unmarshal([]byte(writer), &w); err != nil {
+		return err
+	}
+
+	writerRequired := make(map[string]bool, len(w.Required))
+	for _, name := range w.Required {
+		writerRequired[name] = true
+	}
+	for _, name := range r.Required {
+		if !writerRequired[name] {
+			return fmt.Errorf("property %q is required but may be missing", name)
+		}
+	}
+
+	names := make([]string, 0, len(w.Properties))
+	for name := range w.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		readerProperty, ok := r.Properties[name]
+		if !ok {
+			if r.AdditionalProperties != nil && !*r.AdditionalProperties {
+				return fmt.Errorf("property %q is not allowed", name)
+			}
+			continue
+		}
+		if readerProperty.Type != w.Properties[name].Type {
+			return fmt.Errorf("property %q changed from %s to %s", name, w.Properties[name].Type, readerProperty.Type)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package events
+
+import (
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/lucas/go-rest-api-mongo/internal/models"
+)
+
+// typePrefix é o prefixo dos tipos CloudEvents publicados pela API.
+const typePrefix = "com.github.lucasfdcampos."
+
+// Tipos dos eventos. A versão no sufixo muda quando o schema muda de forma
+// incompatível; o schema de cada versão fica em schemas/<nome>.json.
+const (
+	TypeUserRegistered      = typePrefix + "user.registered.v1"
+	TypeUserLoggedIn        = typePrefix + "user.logged_in.v1"
+	TypeUserLoginFailed     = typePrefix + "user.login_failed.v1"
+	TypeUserUpdated         = typePrefix + "user.updated.v1"
+	TypeUserPasswordChanged = typePrefix + "user.password_changed.v1"
+	TypeUserDeleted         = typePrefix + "user.deleted.v1"
+)
+
+// Event é o payload (campo data) de um CloudEvent.
+type Event interface {
+	// EventType é o tipo CloudEvents, com a versão do schema.
+	EventType() string
+	// Subject é o ID do usuário: vira o subject do envelope e a key da mensagem.
+	Subject() string
+}
+
+// Catalog lista um exemplo de cada evento, na ordem em que são documentados.
+var Catalog = []Event{
+	UserRegistered{},
+	UserLoggedIn{},
+	UserLoginFailed{},
+	UserUpdated{},
+	UserPasswordChanged{},
+	UserDeleted{},
+}
+
+// New devolve um ponteiro para um evento vazio do tipo, para decodificar o evento.
+func New(eventType string) (Event, bool) {
+	for _, event := range Catalog {
+		if event.EventType() == eventType {
+			return reflect.New(reflect.TypeOf(event)).Interface().(Event), true
+		}
+	}
+	return nil, false
+}
+
+// SchemaName é o nome do schema de um tipo (ex.: user.registered.v1).
+func SchemaName(eventType string) string {
+	return strings.TrimPrefix(eventType, typePrefix)
+}
+
+type UserRegistered struct {
+	UserID       string    `json:"user_id"`
+	Email        string    `json:"email"`
+	Name         string    `json:"name"`
+	Role         string    `json:"role"`
+	Locale       string    `json:"locale,omitempty"`
+	RegisteredAt time.Time `json:"registered_at"`
+}
+
+func NewUserRegistered(user *models.User) UserRegistered {
+	return UserRegistered{
+		UserID:       user.ID.Hex(),
+		Email:        user.Email,
+		Name:         user.Name,
+		Role:         user.Role,
+		Locale:       user.Locale,
+		RegisteredAt: user.CreatedAt.UTC(),
+	}
+}
+
+func (e UserRegistered) EventType() string { return TypeUserRegistered }
+func (e UserRegistered) Subject() string   { return e.UserID }
+
+type UserLoggedIn struct {
+	UserID     string    `json:"user_id"`
+	Email      string    `json:"email"`
+	LoggedInAt time.Time `json:"logged_in_at"`
+}
+
+func (e UserLoggedIn) EventType() string { return TypeUserLoggedIn }
+func (e UserLoggedIn) Subject() string   { return e.UserID }
+
+// Motivos de UserLoginFailed.
+const (
+	LoginFailedUnknownEmail    = "unknown_email"
+	LoginFailedInvalidPassword = "invalid_password"
+	LoginFailedUserDisabled    = "user_disabled"
+)
+
+type UserLoginFailed struct {
+	UserID   string    `json:"user_id,omitempty"`
+	Email    string    `json:"email"`
+	Reason   string    `json:"reason"`
+	FailedAt time.Time `json:"failed_at"`
+}
+
+func (e UserLoginFailed) EventType() string { return TypeUserLoginFailed }
+
+// Subject é o email quando ele não pertence a nenhum usuário.
+func (e UserLoginFailed) Subject() string {
+	if e.UserID == "" {
+		return e.Email
+	}
+	return e.UserID
+}
+
+// UserUpdated traz o estado atual do perfil e os campos alterados.
+type UserUpdated struct {
+	UserID    string    `json:"user_id"`
+	Email     string    `json:"email"`
+	Name      string    `json:"name"`
+	Changed   []string  `json:"changed"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (e UserUpdated) EventType() string { return TypeUserUpdated }
+func (e UserUpdated) Subject() string   { return e.UserID }
+
+type UserPasswordChanged struct {
+	UserID    string    `json:"user_id"`
+	ChangedAt time.Time `json:"changed_at"`
+}
+
+func (e UserPasswordChanged) EventType() string { return TypeUserPasswordChanged }
+func (e UserPasswordChanged) Subject() string   { return e.UserID }
+
+type UserDeleted struct {
+	UserID    string    `json:"user_id"`
+	Email     string    `json:"email"`
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+func (e UserDeleted) EventType() string { return TypeUserDeleted }
+func (e UserDeleted) Subject() string   { return e.UserID }
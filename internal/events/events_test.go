@@ -0,0 +1,105 @@
+package events
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEncodeDecodeModes(t *testing.T) {
+	event := UserRegistered{UserID: "65a1b2c3d4e5f60718293a4b", Email: "ana@example.com", Name: "Ana", Role: "user", RegisteredAt: time.Now().UTC()}
+
+	for _, mode := range []Mode{ModeStructured, ModeBinary} {
+		t.Run(string(mode), func(t *testing.T) {
+			encoder, err := NewEncoder(mode, "/test", "http://localhost/schemas/events/", nil)
+			if err != nil {
+				t.Fatalf("NewEncoder() error = %v", err)
+			}
+
+			msg, err := encoder.Encode("users", event)
+			if err != nil {
+				t.Fatalf("Encode() error = %v", err)
+			}
+			if msg.Topic != "users" || msg.Key != event.UserID {
+				t.Errorf("message topic = %q, key = %q", msg.Topic, msg.Key)
+			}
+
+			ce, err := Decode(msg)
+			if err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+			if ce.SpecVersion != SpecVersion || ce.ID == "" || ce.Source != "/test" || ce.Type != TypeUserRegistered || ce.Subject != event.UserID || ce.Time.IsZero() {
+				t.Errorf("envelope = %+v", ce)
+			}
+			if ce.DataSchema != "http://localhost/schemas/events/user.registered.v1" {
+				t.Errorf("dataschema = %q", ce.DataSchema)
+			}
+
+			var decoded UserRegistered
+			if err := ce.DataAs(&decoded); err != nil || decoded.Email != event.Email {
+				t.Errorf("DataAs() = %+v, %v", decoded, err)
+			}
+
+			_, binary := msg.Headers["ce_id"]
+			if binary != (mode == ModeBinary) {
+				t.Errorf("headers = %v for mode %s", msg.Headers, mode)
+			}
+		})
+	}
+}
+
+func TestNewEncoderRejectsUnknownMode(t *testing.T) {
+	if _, err := NewEncoder("xml", "/test", "", nil); err == nil {
+		t.Error("NewEncoder() must reject unknown modes")
+	}
+}
+
+// TestSchemasMatchEvents impede que um struct mude sem o schema publicado acompanhar.
+func TestSchemasMatchEvents(t *testing.T) {
+	for _, event := range Catalog {
+		name := SchemaName(event.EventType())
+		t.Run(name, func(t *testing.T) {
+			_, data, err := SchemaSource(event.EventType(), FormatJSON)
+			if err != nil {
+				t.Fatalf("SchemaSource() error = %v", err)
+			}
+
+			var schema struct {
+				Required   []string                   `json:"required"`
+				Properties map[string]json.RawMessage `json:"properties"`
+			}
+			if err := json.Unmarshal([]byte(data), &schema); err != nil {
+				t.Fatalf("invalid schema: %v", err)
+			}
+
+			var properties, required []string
+			typ := reflect.TypeOf(event)
+			for i := 0; i < typ.NumField(); i++ {
+				tag := strings.Split(typ.Field(i).Tag.Get("json"), ",")
+				properties = append(properties, tag[0])
+				if len(tag) == 1 {
+					required = append(required, tag[0])
+				}
+			}
+
+			var schemaProperties []string
+			for property := range schema.Properties {
+				schemaProperties = append(schemaProperties, property)
+			}
+			sort.Strings(properties)
+			sort.Strings(schemaProperties)
+			sort.Strings(required)
+			sort.Strings(schema.Required)
+
+			if !reflect.DeepEqual(properties, schemaProperties) {
+				t.Errorf("schema properties = %v, struct fields = %v", schemaProperties, properties)
+			}
+			if !reflect.DeepEqual(required, schema.Required) {
+				t.Errorf("schema required = %v, struct required = %v", schema.Required, required)
+			}
+		})
+	}
+}
@@ -0,0 +1,153 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.9
+// 	protoc        (unknown)
+// source: user_login_failed.proto
+
+package eventspb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Data do evento user.login_failed.v1 (mesmos campos de events.UserLoginFailed).
+type UserLoginFailed struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Email         string                 `protobuf:"bytes,2,opt,name=email,proto3" json:"email,omitempty"`
+	Reason        string                 `protobuf:"bytes,3,opt,name=reason,proto3" json:"reason,omitempty"`
+	FailedAt      *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=failed_at,json=failedAt,proto3" json:"failed_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UserLoginFailed) Reset() {
+	*x = UserLoginFailed{}
+	mi := &file_user_login_failed_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UserLoginFailed) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UserLoginFailed) ProtoMessage() {}
+
+func (x *UserLoginFailed) ProtoReflect() protoreflect.Message {
+	mi := &file_user_login_failed_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UserLoginFailed.ProtoReflect.Descriptor instead.
+func (*UserLoginFailed) Descriptor() ([]byte, []int) {
+	return file_user_login_failed_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *UserLoginFailed) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *UserLoginFailed) GetEmail() string {
+	if x != nil {
+		return x.Email
+	}
+	return ""
+}
+
+func (x *UserLoginFailed) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *UserLoginFailed) GetFailedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.FailedAt
+	}
+	return nil
+}
+
+var File_user_login_failed_proto protoreflect.FileDescriptor
+
+const file_user_login_failed_proto_rawDesc = "" +
+	"\n" +
+	"\x17user_login_failed.proto\x12\x16lucasfdcampos.users.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\x91\x01\n" +
+	"\x0fUserLoginFailed\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x14\n" +
+	"\x05email\x18\x02 \x01(\tR\x05email\x12\x16\n" +
+	"\x06reason\x18\x03 \x01(\tR\x06reason\x127\n" +
+	"\tfailed_at\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\bfailedAtB=Z;github.com/lucas/go-rest-api-mongo/internal/events/eventspbb\x06proto3"
+
+var (
+	file_user_login_failed_proto_rawDescOnce sync.Once
+	file_user_login_failed_proto_rawDescData []byte
+)
+
+func file_user_login_failed_proto_rawDescGZIP() []byte {
+	file_user_login_failed_proto_rawDescOnce.Do(func() {
+		file_user_login_failed_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_user_login_failed_proto_rawDesc), len(file_user_login_failed_proto_rawDesc)))
+	})
+	return file_user_login_failed_proto_rawDescData
+}
+
+var file_user_login_failed_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_user_login_failed_proto_goTypes = []any{
+	(*UserLoginFailed)(nil),       // 0: lucasfdcampos.users.v1.UserLoginFailed
+	(*timestamppb.Timestamp)(nil), // 1: google.protobuf.Timestamp
+}
+var file_user_login_failed_proto_depIdxs = []int32{
+	1, // 0: lucasfdcampos.users.v1.UserLoginFailed.failed_at:type_name -> google.protobuf.Timestamp
+	1, // [1:1] is the sub-list for method output_type
+	1, // [1:1] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_user_login_failed_proto_init() }
+func file_user_login_failed_proto_init() {
+	if File_user_login_failed_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_user_login_failed_proto_rawDesc), len(file_user_login_failed_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   1,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_user_login_failed_proto_goTypes,
+		DependencyIndexes: file_user_login_failed_proto_depIdxs,
+		MessageInfos:      file_user_login_failed_proto_msgTypes,
+	}.Build()
+	File_user_login_failed_proto = out.File
+	file_user_login_failed_proto_goTypes = nil
+	file_user_login_failed_proto_depIdxs = nil
+}
@@ -0,0 +1,159 @@
+package events
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/lucas/go-rest-api-mongo/internal/events/eventspb"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const ContentTypeProtobuf = "application/x-protobuf"
+
+// protoMessages mapeia cada tipo de evento para a mensagem gerada a partir de proto/.
+var protoMessages = map[string]func() proto.Message{
+	TypeUserRegistered:      func() proto.Message { return &eventspb.UserRegistered{} },
+	TypeUserLoggedIn:        func() proto.Message { return &eventspb.UserLoggedIn{} },
+	TypeUserLoginFailed:     func() proto.Message { return &eventspb.UserLoginFailed{} },
+	TypeUserUpdated:         func() proto.Message { return &eventspb.UserUpdated{} },
+	TypeUserPasswordChanged: func() proto.Message { return &eventspb.UserPasswordChanged{} },
+	TypeUserDeleted:         func() proto.Message { return &eventspb.UserDeleted{} },
+}
+
+// protoFile é o arquivo .proto (em proto/) que define a mensagem do evento.
+func protoFile(eventType string) (string, bool) {
+	newMessage, ok := protoMessages[eventType]
+	if !ok {
+		return "", false
+	}
+	return newMessage().ProtoReflect().Descriptor().ParentFile().Path(), true
+}
+
+type protobufSerializer struct {
+	registry SchemaRegistry
+	ids      map[string]int
+}
+
+func newProtobufSerializer(registry SchemaRegistry) (*protobufSerializer, error) {
+	ids, err := registerSchemas(registry, FormatProtobuf)
+	if err != nil {
+		return nil, err
+	}
+	return &protobufSerializer{registry: registry, ids: ids}, nil
+}
+
+func (s *protobufSerializer) Format() Format      { return FormatProtobuf }
+func (s *protobufSerializer) ContentType() string { return ContentTypeProtobuf }
+
+// Marshal segue o wire format do Confluent para Protobuf: depois do ID vêm os índices
+// da mensagem no arquivo; como cada arquivo tem uma única mensagem, é sempre o byte 0.
+func (s *protobufSerializer) Marshal(event Event) ([]byte, error) {
+	msg, err := toProto(event)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := proto.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	return wireEncode(s.ids[event.EventType()], append([]byte{0}, payload...)), nil
+}
+
+func (s *protobufSerializer) Unmarshal(data []byte, event Event) error {
+	id, payload, err := wireDecode(data)
+	if err != nil {
+		return err
+	}
+	schema, err := s.registry.SchemaByID(id)
+	if err != nil {
+		return err
+	}
+	if schema.SchemaType != SchemaTypeProtobuf {
+		return fmt.Errorf("schema %d is %s, not %s", id, schema.SchemaType, SchemaTypeProtobuf)
+	}
+	if len(payload) == 0 || payload[0] != 0 {
+		return fmt.Errorf("unsupported protobuf message indexes")
+	}
+
+	newMessage, ok := protoMessages[event.EventType()]
+	if !ok {
+		return fmt.Errorf("no protobuf message for %s", event.EventType())
+	}
+	msg := newMessage()
+	if err := proto.Unmarshal(payload[1:], msg); err != nil {
+		return err
+	}
+	return fromProto(msg, event)
+}
+
+func toProto(event Event) (proto.Message, error) {
+	// Os services emitem ponteiros (&events.UserLoggedIn{...}); a mensagem sai do valor
+	if v := reflect.ValueOf(event); v.Kind() == reflect.Pointer && !v.IsNil() {
+		if value, ok := v.Elem().Interface().(Event); ok {
+			event = value
+		}
+	}
+
+	switch e := event.(type) {
+	case UserRegistered:
+		return &eventspb.UserRegistered{
+			UserId: e.UserID, Email: e.Email, Name: e.Name, Role: e.Role, Locale: e.Locale,
+			RegisteredAt: timestamppb.New(e.RegisteredAt),
+		}, nil
+	case UserLoggedIn:
+		return &eventspb.UserLoggedIn{UserId: e.UserID, Email: e.Email, LoggedInAt: timestamppb.New(e.LoggedInAt)}, nil
+	case UserLoginFailed:
+		return &eventspb.UserLoginFailed{UserId: e.UserID, Email: e.Email, Reason: e.Reason, FailedAt: timestamppb.New(e.FailedAt)}, nil
+	case UserUpdated:
+		return &eventspb.UserUpdated{
+			UserId: e.UserID, Email: e.Email, Name: e.Name, Changed: e.Changed,
+			UpdatedAt: timestamppb.New(e.UpdatedAt),
+		}, nil
+	case UserPasswordChanged:
+		return &eventspb.UserPasswordChanged{UserId: e.UserID, ChangedAt: timestamppb.New(e.ChangedAt)}, nil
+	case UserDeleted:
+		return &eventspb.UserDeleted{UserId: e.UserID, Email: e.Email, DeletedAt: timestamppb.New(e.DeletedAt)}, nil
+	default:
+		return nil, fmt.Errorf("no protobuf message for %T", event)
+	}
+}
+
+func fromProto(msg proto.Message, event Event) error {
+	switch m := msg.(type) {
+	case *eventspb.UserRegistered:
+		if e, ok := event.(*UserRegistered); ok {
+			*e = UserRegistered{
+				UserID: m.UserId, Email: m.Email, Name: m.Name, Role: m.Role, Locale: m.Locale,
+				RegisteredAt: m.RegisteredAt.AsTime(),
+			}
+			return nil
+		}
+	case *eventspb.UserLoggedIn:
+		if e, ok := event.(*UserLoggedIn); ok {
+			*e = UserLoggedIn{UserID: m.UserId, Email: m.Email, LoggedInAt: m.LoggedInAt.AsTime()}
+			return nil
+		}
+	case *eventspb.UserLoginFailed:
+		if e, ok := event.(*UserLoginFailed); ok {
+			*e = UserLoginFailed{UserID: m.UserId, Email: m.Email, Reason: m.Reason, FailedAt: m.FailedAt.AsTime()}
+			return nil
+		}
+	case *eventspb.UserUpdated:
+		if e, ok := event.(*UserUpdated); ok {
+			*e = UserUpdated{UserID: m.UserId, Email: m.Email, Name: m.Name, Changed: m.Changed, UpdatedAt: m.UpdatedAt.AsTime()}
+			return nil
+		}
+	case *eventspb.UserPasswordChanged:
+		if e, ok := event.(*UserPasswordChanged); ok {
+			*e = UserPasswordChanged{UserID: m.UserId, ChangedAt: m.ChangedAt.AsTime()}
+			return nil
+		}
+	case *eventspb.UserDeleted:
+		if e, ok := event.(*UserDeleted); ok {
+			*e = UserDeleted{UserID: m.UserId, Email: m.Email, DeletedAt: m.DeletedAt.AsTime()}
+			return nil
+		}
+	}
+	return fmt.Errorf("cannot decode %T into %T", msg, event)
+}
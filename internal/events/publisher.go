@@ -0,0 +1,65 @@
+package events
+
+import (
+	"context"
+
+	"github.com/lucas/go-rest-api-mongo/internal/messaging"
+)
+
+// Publisher publica eventos tipados em um ou mais tópicos do EventBus, já no
+// envelope CloudEvents.
+type Publisher struct {
+	bus     messaging.EventBus
+	encoder *Encoder
+	topics  []string
+}
+
+func NewPublisher(bus messaging.EventBus, encoder *Encoder, topics ...string) *Publisher {
+	return &Publisher{
+		bus:     bus,
+		encoder: encoder,
+		topics:  topics,
+	}
+}
+
+// Topic é o primeiro tópico do publisher, usado quando uma mensagem não traz o seu.
+func (p *Publisher) Topic() string {
+	return p.topics[0]
+}
+
+// Messages monta uma mensagem por tópico sem publicá-las, para publicação em lote.
+// Todas carregam o mesmo envelope (mesmo id), então consumidores podem deduplicar.
+func (p *Publisher) Messages(event Event) ([]messaging.Message, error) {
+	msg, err := p.encoder.Encode(p.topics[0], event)
+	if err != nil {
+		return nil, err
+	}
+
+	messages := make([]messaging.Message, len(p.topics))
+	for i, topic := range p.topics {
+		messages[i] = msg
+		messages[i].Topic = topic
+	}
+	return messages, nil
+}
+
+func (p *Publisher) Publish(ctx context.Context, event Event) error {
+	messages, err := p.Messages(event)
+	if err != nil {
+		return err
+	}
+	return p.PublishBatch(ctx, messages)
+}
+
+// PublishBatch publica mensagens já montadas; veja messaging.EventBus.PublishBatch.
+func (p *Publisher) PublishBatch(ctx context.Context, messages []messaging.Message) error {
+	return p.bus.PublishBatch(ctx, messages)
+}
+
+// PublishMessage republica uma mensagem já montada (ex.: uma dead letter).
+func (p *Publisher) PublishMessage(ctx context.Context, msg messaging.Message) error {
+	if msg.Topic == "" {
+		msg.Topic = p.Topic()
+	}
+	return p.bus.Publish(ctx, msg.Topic, msg.Key, msg.Headers, msg.Payload)
+}
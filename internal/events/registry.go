@@ -0,0 +1,238 @@
+package events
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Compatibility é a regra aplicada ao registrar uma nova versão de um subject.
+type Compatibility string
+
+const (
+	// CompatibilityBackward: o schema novo lê dados gravados com a versão anterior.
+	CompatibilityBackward Compatibility = "BACKWARD"
+	// CompatibilityForward: a versão anterior lê dados gravados com o schema novo.
+	CompatibilityForward Compatibility = "FORWARD"
+	// CompatibilityFull: as duas direções.
+	CompatibilityFull Compatibility = "FULL"
+	CompatibilityNone Compatibility = "NONE"
+)
+
+var (
+	ErrSchemaNotFound     = errors.New("schema not found")
+	ErrIncompatibleSchema = errors.New("schema is incompatible with the latest version")
+)
+
+// RegisteredSchema é uma versão de um schema em um subject.
+type RegisteredSchema struct {
+	ID         int    `json:"id"`
+	Subject    string `json:"subject"`
+	Version    int    `json:"version"`
+	SchemaType string `json:"schema_type"`
+	Schema     string `json:"schema"`
+}
+
+// SchemaRegistry guarda os schemas dos eventos e atribui os IDs usados no wire format.
+type SchemaRegistry interface {
+	// Register retorna o ID do schema, criando uma nova versão do subject se ele mudou.
+	// Uma versão nova precisa ser compatível com a última segundo a regra do registry.
+	Register(subject, schemaType, schema string) (int, error)
+	SchemaByID(id int) (RegisteredSchema, error)
+	Latest(subject string) (RegisteredSchema, error)
+}
+
+var _ SchemaRegistry = (*FileSchemaRegistry)(nil)
+
+// FileSchemaRegistry é um registry mínimo no formato do Confluent, persistido em um
+// arquivo JSON. Com path vazio ele fica só em memória.
+type FileSchemaRegistry struct {
+	mu            sync.Mutex
+	path          string
+	compatibility Compatibility
+	schemas       []RegisteredSchema
+}
+
+func NewFileSchemaRegistry(path string, compatibility Compatibility) (*FileSchemaRegistry, error) {
+	switch compatibility {
+	case CompatibilityBackward, CompatibilityForward, CompatibilityFull, CompatibilityNone:
+	case "":
+		compatibility = CompatibilityBackward
+	default:
+		return nil, fmt.Errorf("unknown schema compatibility %q", compatibility)
+	}
+
+	r := &FileSchemaRegistry{path: path, compatibility: compatibility}
+	if path == "" {
+		return r, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return r, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading schema registry: %w", err)
+	}
+	if err := json.Unmarshal(data, &r.schemas); err != nil {
+		return nil, fmt.Errorf("reading schema registry %s: %w", path, err)
+	}
+	return r, nil
+}
+
+func (r *FileSchemaRegistry) Register(subject, schemaType, schema string) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	normalized := normalizeSchema(schemaType, schema)
+
+	var latest *RegisteredSchema
+	for i := range r.schemas {
+		s := &r.schemas[i]
+		if s.Subject != subject {
+			continue
+		}
+		if s.SchemaType == schemaType && normalizeSchema(s.SchemaType, s.Schema) == normalized {
+			return s.ID, nil
+		}
+		if latest == nil || s.Version > latest.Version {
+			latest = s
+		}
+	}
+
+	version := 1
+	if latest != nil {
+		if latest.SchemaType != schemaType {
+			return 0, fmt.Errorf("%w: subject %s is %s, not %s", ErrIncompatibleSchema, subject, latest.SchemaType, schemaType)
+		}
+		if err := r.checkCompatibility(schemaType, schema, latest.Schema); err != nil {
+			return 0, fmt.Errorf("%w: subject %s version %d: %v", ErrIncompatibleSchema, subject, latest.Version, err)
+		}
+		version = latest.Version + 1
+	}
+
+	// Como no Confluent, o mesmo schema em outro subject reaproveita o ID
+	id := r.idOf(schemaType, normalized)
+	if id == 0 {
+		id = r.maxID() + 1
+	}
+
+	r.schemas = append(r.schemas, RegisteredSchema{
+		ID:         id,
+		Subject:    subject,
+		Version:    version,
+		SchemaType: schemaType,
+		Schema:     schema,
+	})
+	if err := r.save(); err != nil {
+		r.schemas = r.schemas[:len(r.schemas)-1]
+		return 0, err
+	}
+	return id, nil
+}
+
+func (r *FileSchemaRegistry) SchemaByID(id int) (RegisteredSchema, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, s := range r.schemas {
+		if s.ID == id {
+			return s, nil
+		}
+	}
+	return RegisteredSchema{}, fmt.Errorf("%w: id %d", ErrSchemaNotFound, id)
+}
+
+func (r *FileSchemaRegistry) Latest(subject string) (RegisteredSchema, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var latest RegisteredSchema
+	for _, s := range r.schemas {
+		if s.Subject == subject && s.Version > latest.Version {
+			latest = s
+		}
+	}
+	if latest.Version == 0 {
+		return RegisteredSchema{}, fmt.Errorf("%w: subject %s", ErrSchemaNotFound, subject)
+	}
+	return latest, nil
+}
+
+func (r *FileSchemaRegistry) checkCompatibility(schemaType, next, previous string) error {
+	switch r.compatibility {
+	case CompatibilityBackward:
+		return checkCompatible(schemaType, next, previous)
+	case CompatibilityForward:
+		return checkCompatible(schemaType, previous, next)
+	case CompatibilityFull:
+		if err := checkCompatible(schemaType, next, previous); err != nil {
+			return err
+		}
+		return checkCompatible(schemaType, previous, next)
+	default:
+		return nil
+	}
+}
+
+func (r *FileSchemaRegistry) idOf(schemaType, normalized string) int {
+	for _, s := range r.schemas {
+		if s.SchemaType == schemaType && normalizeSchema(s.SchemaType, s.Schema) == normalized {
+			return s.ID
+		}
+	}
+	return 0
+}
+
+func (r *FileSchemaRegistry) maxID() int {
+	max := 0
+	for _, s := range r.schemas {
+		if s.ID > max {
+			max = s.ID
+		}
+	}
+	return max
+}
+
+// save grava em um arquivo temporário e renomeia, para nunca deixar o registry pela metade.
+func (r *FileSchemaRegistry) save() error {
+	if r.path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(r.schemas, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(r.path), filepath.Base(r.path)+".*")
+	if err != nil {
+		return fmt.Errorf("saving schema registry: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("saving schema registry: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("saving schema registry: %w", err)
+	}
+	return os.Rename(tmp.Name(), r.path)
+}
+
+// normalizeSchema ignora diferenças de formatação ao comparar schemas.
+func normalizeSchema(schemaType, schema string) string {
+	if schemaType == SchemaTypeProtobuf {
+		return strings.TrimSpace(schema)
+	}
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, []byte(schema)); err != nil {
+		return strings.TrimSpace(schema)
+	}
+	return buf.String()
+}
@@ -0,0 +1,27 @@
+package events
+
+import "github.com/lucas/go-rest-api-mongo/internal/messaging"
+
+// HeaderReplay marca mensagens republicadas por um backfill; o valor é o ID da
+// execução. Eventos ao vivo nunca têm esse header, nos dois modos CloudEvents.
+const HeaderReplay = "x-replay"
+
+// MarkReplay devolve cópias das mensagens com o header de replay.
+func MarkReplay(messages []messaging.Message, runID string) []messaging.Message {
+	marked := make([]messaging.Message, len(messages))
+	for i, msg := range messages {
+		headers := make(map[string]string, len(msg.Headers)+1)
+		for name, value := range msg.Headers {
+			headers[name] = value
+		}
+		headers[HeaderReplay] = runID
+		msg.Headers = headers
+		marked[i] = msg
+	}
+	return marked
+}
+
+// IsReplay diz se a mensagem veio de um backfill e não de uma mudança ao vivo.
+func IsReplay(msg messaging.Message) bool {
+	return msg.Headers[HeaderReplay] != ""
+}
@@ -0,0 +1,94 @@
+package events
+
+import (
+	"embed"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lucas/go-rest-api-mongo/pkg/utils"
+)
+
+//go:embed schemas/*.json avro/*.avsc proto/*.proto
+var schemaFiles embed.FS
+
+// SchemaInfo descreve um schema publicado em /schemas/events.
+type SchemaInfo struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+	URL  string `json:"url"`
+}
+
+// SchemaSource retorna o tipo e o texto do schema de um evento no formato: o JSON
+// Schema de schemas/, o .avsc de avro/ ou o .proto de proto/.
+func SchemaSource(eventType string, format Format) (string, string, error) {
+	var schemaType, path string
+	switch format {
+	case "", FormatJSON:
+		schemaType, path = SchemaTypeJSON, "schemas/"+SchemaName(eventType)+".json"
+	case FormatAvro:
+		schemaType, path = SchemaTypeAvro, "avro/"+SchemaName(eventType)+".avsc"
+	case FormatProtobuf:
+		file, ok := protoFile(eventType)
+		if !ok {
+			return "", "", fmt.Errorf("no protobuf schema for %s", eventType)
+		}
+		schemaType, path = SchemaTypeProtobuf, "proto/"+file
+	default:
+		return "", "", fmt.Errorf("unknown event format %q", format)
+	}
+
+	data, err := schemaFiles.ReadFile(path)
+	if err != nil {
+		return "", "", fmt.Errorf("no %s schema for %s", format, eventType)
+	}
+	return schemaType, string(data), nil
+}
+
+// SchemaIndexHandler lista os schemas de todos os eventos do Catalog.
+func SchemaIndexHandler(schemaBaseURL string) gin.HandlerFunc {
+	schemaBaseURL = strings.TrimSuffix(schemaBaseURL, "/")
+	index := make([]SchemaInfo, 0, len(Catalog))
+	for _, event := range Catalog {
+		name := SchemaName(event.EventType())
+		index = append(index, SchemaInfo{Name: name, Type: event.EventType(), URL: schemaBaseURL + "/" + name})
+	}
+
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, index)
+	}
+}
+
+// SchemaHandler serve um schema pelo parâmetro :name. Com ?format=avro ou
+// ?format=protobuf serve o .avsc ou o .proto do evento no lugar do JSON Schema.
+func SchemaHandler() gin.HandlerFunc {
+	types := make(map[string]string, len(Catalog))
+	for _, event := range Catalog {
+		types[SchemaName(event.EventType())] = event.EventType()
+	}
+
+	return func(c *gin.Context) {
+		eventType, ok := types[c.Param("name")]
+		if !ok {
+			utils.SendError(c, http.StatusNotFound, "not_found", "schema not found")
+			return
+		}
+
+		format := Format(c.DefaultQuery("format", string(FormatJSON)))
+		_, schema, err := SchemaSource(eventType, format)
+		if err != nil {
+			utils.SendError(c, http.StatusNotFound, "not_found", "schema not found")
+			return
+		}
+
+		contentType := "application/schema+json"
+		switch format {
+		case FormatAvro:
+			contentType = "application/json"
+		case FormatProtobuf:
+			contentType = "text/plain; charset=utf-8"
+		}
+		c.Data(http.StatusOK, contentType, []byte(schema))
+	}
+}
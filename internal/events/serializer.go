@@ -0,0 +1,105 @@
+package events
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Format é o formato do data dos eventos (EVENTS_FORMAT).
+type Format string
+
+const (
+	FormatJSON     Format = "json"
+	FormatProtobuf Format = "protobuf"
+	FormatAvro     Format = "avro"
+)
+
+// Tipos de schema, com os mesmos nomes do Confluent Schema Registry.
+const (
+	SchemaTypeJSON     = "JSON"
+	SchemaTypeProtobuf = "PROTOBUF"
+	SchemaTypeAvro     = "AVRO"
+)
+
+var ErrInvalidWireFormat = errors.New("payload is not in the Confluent wire format")
+
+// Serializer converte o data de um evento em bytes e de volta. Protobuf e Avro usam
+// o wire format do Confluent (byte 0, ID do schema em 4 bytes big-endian, payload);
+// JSON continua JSON puro, para que o data siga legível no envelope estruturado.
+type Serializer interface {
+	Format() Format
+	ContentType() string
+	Marshal(event Event) ([]byte, error)
+	// Unmarshal decodifica data em event, que deve ser um ponteiro para o struct do evento.
+	Unmarshal(data []byte, event Event) error
+}
+
+// NewSerializer cria o serializer do formato e registra os schemas de todos os eventos
+// do Catalog no registry; um schema alterado de forma incompatível falha aqui.
+func NewSerializer(format Format, registry SchemaRegistry) (Serializer, error) {
+	switch format {
+	case "", FormatJSON:
+		if _, err := registerSchemas(registry, FormatJSON); err != nil {
+			return nil, err
+		}
+		return jsonSerializer{}, nil
+	case FormatProtobuf:
+		return newProtobufSerializer(registry)
+	case FormatAvro:
+		return newAvroSerializer(registry)
+	default:
+		return nil, fmt.Errorf("unknown event format %q", format)
+	}
+}
+
+// Subject é o subject do schema de um evento no registry: um por tipo e formato,
+// já que um mesmo tópico carrega vários tipos de evento.
+func Subject(eventType string, format Format) string {
+	return SchemaName(eventType) + "-" + string(format)
+}
+
+// registerSchemas registra o schema de cada evento do Catalog e retorna os IDs por tipo.
+func registerSchemas(registry SchemaRegistry, format Format) (map[string]int, error) {
+	ids := make(map[string]int, len(Catalog))
+	for _, event := range Catalog {
+		schemaType, schema, err := SchemaSource(event.EventType(), format)
+		if err != nil {
+			return nil, err
+		}
+		id, err := registry.Register(Subject(event.EventType(), format), schemaType, schema)
+		if err != nil {
+			return nil, fmt.Errorf("registering %s schema for %s: %w", format, event.EventType(), err)
+		}
+		ids[event.EventType()] = id
+	}
+	return ids, nil
+}
+
+type jsonSerializer struct{}
+
+func (jsonSerializer) Format() Format      { return FormatJSON }
+func (jsonSerializer) ContentType() string { return ContentTypeJSON }
+
+func (jsonSerializer) Marshal(event Event) ([]byte, error) {
+	return json.Marshal(event)
+}
+
+func (jsonSerializer) Unmarshal(data []byte, event Event) error {
+	return json.Unmarshal(data, event)
+}
+
+// wireEncode prefixa o payload com o byte mágico e o ID do schema.
+func wireEncode(schemaID int, payload []byte) []byte {
+	out := make([]byte, 5, 5+len(payload))
+	binary.BigEndian.PutUint32(out[1:], uint32(schemaID))
+	return append(out, payload...)
+}
+
+func wireDecode(data []byte) (int, []byte, error) {
+	if len(data) < 5 || data[0] != 0 {
+		return 0, nil, ErrInvalidWireFormat
+	}
+	return int(binary.BigEndian.Uint32(data[1:5])), data[5:], nil
+}
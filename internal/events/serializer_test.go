@@ -0,0 +1,250 @@
+package events
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func sampleEvents() []Event {
+	at := time.Date(2026, 1, 2, 3, 4, 5, 6000000, time.UTC)
+	return []Event{
+		UserRegistered{UserID: "65a1b2c3d4e5f60718293a4b", Email: "ana@example.com", Name: "Ana", Role: "user", Locale: "pt-BR", RegisteredAt: at},
+		UserLoggedIn{UserID: "65a1b2c3d4e5f60718293a4b", Email: "ana@example.com", LoggedInAt: at},
+		UserLoginFailed{Email: "nobody@example.com", Reason: LoginFailedUnknownEmail, FailedAt: at},
+		UserUpdated{UserID: "65a1b2c3d4e5f60718293a4b", Email: "ana@example.com", Name: "Ana Maria", Changed: []string{"name"}, UpdatedAt: at},
+		UserPasswordChanged{UserID: "65a1b2c3d4e5f60718293a4b", ChangedAt: at},
+		UserDeleted{UserID: "65a1b2c3d4e5f60718293a4b", Email: "ana@example.com", DeletedAt: at},
+	}
+}
+
+func TestSerializersRoundTrip(t *testing.T) {
+	for _, format := range []Format{FormatJSON, FormatProtobuf, FormatAvro} {
+		t.Run(string(format), func(t *testing.T) {
+			registry, _ := NewFileSchemaRegistry("", CompatibilityBackward)
+			serializer, err := NewSerializer(format, registry)
+			if err != nil {
+				t.Fatalf("NewSerializer() error = %v", err)
+			}
+
+			for _, event := range sampleEvents() {
+				data, err := serializer.Marshal(event)
+				if err != nil {
+					t.Fatalf("Marshal(%T) error = %v", event, err)
+				}
+
+				if format != FormatJSON {
+					id, _, err := wireDecode(data)
+					latest, _ := registry.Latest(Subject(event.EventType(), format))
+					if err != nil || id != latest.ID {
+						t.Errorf("%T: wire format id = %d, %v, want %d", event, id, err, latest.ID)
+					}
+				}
+
+				decoded := reflect.New(reflect.TypeOf(event))
+				if err := serializer.Unmarshal(data, decoded.Interface().(Event)); err != nil {
+					t.Fatalf("Unmarshal(%T) error = %v", event, err)
+				}
+				if got := decoded.Elem().Interface(); !reflect.DeepEqual(got, event) {
+					t.Errorf("round trip = %+v, want %+v", got, event)
+				}
+			}
+		})
+	}
+}
+
+// Os services emitem ponteiros para os eventos; todo formato os codifica como o valor.
+func TestSerializersEncodePointerEvents(t *testing.T) {
+	for _, format := range []Format{FormatJSON, FormatProtobuf, FormatAvro} {
+		t.Run(string(format), func(t *testing.T) {
+			registry, _ := NewFileSchemaRegistry("", CompatibilityBackward)
+			serializer, err := NewSerializer(format, registry)
+			if err != nil {
+				t.Fatalf("NewSerializer() error = %v", err)
+			}
+
+			for _, event := range sampleEvents() {
+				pointer := reflect.New(reflect.TypeOf(event))
+				pointer.Elem().Set(reflect.ValueOf(event))
+
+				got, err := serializer.Marshal(pointer.Interface().(Event))
+				if err != nil {
+					t.Fatalf("Marshal(%T) error = %v", pointer.Interface(), err)
+				}
+				want, _ := serializer.Marshal(event)
+				if string(got) != string(want) {
+					t.Errorf("Marshal(%T) = %x, want the encoding of the value %x", pointer.Interface(), got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestEncoderWithBinaryFormat(t *testing.T) {
+	registry, _ := NewFileSchemaRegistry("", CompatibilityBackward)
+	serializer, err := NewSerializer(FormatAvro, registry)
+	if err != nil {
+		t.Fatalf("NewSerializer() error = %v", err)
+	}
+	event := sampleEvents()[0].(UserRegistered)
+
+	for _, mode := range []Mode{ModeStructured, ModeBinary} {
+		encoder, _ := NewEncoder(mode, "/test", "http://localhost/schemas/events", serializer)
+		msg, err := encoder.Encode("users", event)
+		if err != nil {
+			t.Fatalf("Encode() error = %v", err)
+		}
+
+		ce, err := Decode(msg)
+		if err != nil {
+			t.Fatalf("Decode() error = %v", err)
+		}
+		if ce.DataContentType != ContentTypeAvro || !strings.HasSuffix(ce.DataSchema, "?format=avro") || ce.DataBase64 == nil {
+			t.Errorf("%s envelope = %+v", mode, ce)
+		}
+
+		var decoded UserRegistered
+		if err := encoder.DecodeData(ce, &decoded); err != nil || decoded != event {
+			t.Errorf("%s DecodeData() = %+v, %v", mode, decoded, err)
+		}
+	}
+}
+
+func TestAvroReadsOlderWriterSchema(t *testing.T) {
+	registry, _ := NewFileSchemaRegistry("", CompatibilityBackward)
+
+	// Versão antiga, sem o campo locale
+	old := `{"type":"record","name":"UserRegistered","namespace":"com.github.lucasfdcampos.users.v1","fields":[
+		{"name":"user_id","type":"string"},{"name":"email","type":"string"},{"name":"name","type":"string"},
+		{"name":"role","type":"string"},{"name":"registered_at","type":{"type":"long","logicalType":"timestamp-millis"}}]}`
+	oldID, err := registry.Register(Subject(TypeUserRegistered, FormatAvro), SchemaTypeAvro, old)
+	if err != nil {
+		t.Fatalf("Register(old) error = %v", err)
+	}
+
+	serializer, err := NewSerializer(FormatAvro, registry)
+	if err != nil {
+		t.Fatalf("NewSerializer() error = %v (adding a field with a default is backward compatible)", err)
+	}
+
+	event := sampleEvents()[0].(UserRegistered)
+	event.Locale = ""
+	oldSchema, _ := parseAvro(old)
+	payload, err := avroAPI.Marshal(oldSchema, event)
+	if err != nil {
+		t.Fatalf("Marshal with old schema error = %v", err)
+	}
+
+	var decoded UserRegistered
+	if err := serializer.Unmarshal(wireEncode(oldID, payload), &decoded); err != nil || decoded != event {
+		t.Errorf("Unmarshal(old payload) = %+v, %v", decoded, err)
+	}
+}
+
+func TestRegistryCompatibility(t *testing.T) {
+	tests := []struct {
+		name          string
+		schemaType    string
+		previous      string
+		next          string
+		compatibility Compatibility
+		wantErr       bool
+	}{
+		{
+			name:       "avro field without default",
+			schemaType: SchemaTypeAvro,
+			previous:   `{"type":"record","name":"R","fields":[{"name":"a","type":"string"}]}`,
+			next:       `{"type":"record","name":"R","fields":[{"name":"a","type":"string"},{"name":"b","type":"string"}]}`,
+			wantErr:    true,
+		},
+		{
+			name:          "avro field without default is forward compatible",
+			schemaType:    SchemaTypeAvro,
+			previous:      `{"type":"record","name":"R","fields":[{"name":"a","type":"string"}]}`,
+			next:          `{"type":"record","name":"R","fields":[{"name":"a","type":"string"},{"name":"b","type":"string"}]}`,
+			compatibility: CompatibilityForward,
+		},
+		{
+			name:       "protobuf new field",
+			schemaType: SchemaTypeProtobuf,
+			previous:   `syntax = "proto3"; message M { string a = 1; }`,
+			next:       `syntax = "proto3"; message M { string a = 1; int64 b = 2; }`,
+		},
+		{
+			name:       "protobuf field type change",
+			schemaType: SchemaTypeProtobuf,
+			previous:   `syntax = "proto3"; message M { string a = 1; }`,
+			next:       `syntax = "proto3"; message M { int64 a = 1; }`,
+			wantErr:    true,
+		},
+		{
+			name:       "json new optional property",
+			schemaType: SchemaTypeJSON,
+			previous:   `{"type":"object","required":["a"],"properties":{"a":{"type":"string"}}}`,
+			next:       `{"type":"object","required":["a"],"properties":{"a":{"type":"string"},"b":{"type":"string"}}}`,
+		},
+		{
+			name:          "json new required property",
+			schemaType:    SchemaTypeJSON,
+			previous:      `{"type":"object","required":["a"],"properties":{"a":{"type":"string"}}}`,
+			next:          `{"type":"object","required":["a","b"],"properties":{"a":{"type":"string"},"b":{"type":"string"}}}`,
+			compatibility: CompatibilityFull,
+			wantErr:       true,
+		},
+		{
+			name:          "none accepts anything",
+			schemaType:    SchemaTypeJSON,
+			previous:      `{"type":"object","properties":{"a":{"type":"string"}}}`,
+			next:          `{"type":"object","properties":{"a":{"type":"integer"}}}`,
+			compatibility: CompatibilityNone,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry, err := NewFileSchemaRegistry("", tt.compatibility)
+			if err != nil {
+				t.Fatalf("NewFileSchemaRegistry() error = %v", err)
+			}
+			if _, err := registry.Register("subject", tt.schemaType, tt.previous); err != nil {
+				t.Fatalf("Register(previous) error = %v", err)
+			}
+
+			_, err = registry.Register("subject", tt.schemaType, tt.next)
+			if tt.wantErr != errors.Is(err, ErrIncompatibleSchema) {
+				t.Errorf("Register(next) error = %v, want incompatible = %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFileSchemaRegistryPersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "registry.json")
+	registry, err := NewFileSchemaRegistry(path, CompatibilityBackward)
+	if err != nil {
+		t.Fatalf("NewFileSchemaRegistry() error = %v", err)
+	}
+
+	schema := `{"type":"object","properties":{"a":{"type":"string"}}}`
+	id, _ := registry.Register("a-json", SchemaTypeJSON, schema)
+	if other, _ := registry.Register("b-json", SchemaTypeJSON, schema); other != id {
+		t.Errorf("same schema in another subject got id %d, want %d", other, id)
+	}
+
+	reopened, err := NewFileSchemaRegistry(path, CompatibilityBackward)
+	if err != nil {
+		t.Fatalf("reopen error = %v", err)
+	}
+	if again, _ := reopened.Register("a-json", SchemaTypeJSON, " "+schema+"\n"); again != id {
+		t.Errorf("re-registering the same schema got id %d, want %d", again, id)
+	}
+	if latest, err := reopened.Latest("b-json"); err != nil || latest.Version != 1 || latest.ID != id {
+		t.Errorf("Latest() = %+v, %v", latest, err)
+	}
+	if _, err := reopened.SchemaByID(99); !errors.Is(err, ErrSchemaNotFound) {
+		t.Errorf("SchemaByID(99) error = %v, want ErrSchemaNotFound", err)
+	}
+}
@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lucas/go-rest-api-mongo/internal/dto"
+	"github.com/lucas/go-rest-api-mongo/internal/messaging"
+	"github.com/lucas/go-rest-api-mongo/internal/services"
+	"github.com/lucas/go-rest-api-mongo/pkg/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	defaultListLimit = 50
+	maxListLimit     = 200
+)
+
+type AdminHandler struct {
+	deadLetterService *services.DeadLetterService
+	bus               messaging.EventBus
+}
+
+func NewAdminHandler(deadLetterService *services.DeadLetterService, bus messaging.EventBus) *AdminHandler {
+	return &AdminHandler{
+		deadLetterService: deadLetterService,
+		bus:               bus,
+	}
+}
+
+func (h *AdminHandler) ListDeadLetters(c *gin.Context) {
+	limit, err := strconv.ParseInt(c.DefaultQuery("limit", strconv.Itoa(defaultListLimit)), 10, 64)
+	if err != nil || limit <= 0 || limit > maxListLimit {
+		utils.SendError(c, http.StatusBadRequest, "bad_request", "invalid limit")
+		return
+	}
+
+	offset, err := strconv.ParseInt(c.DefaultQuery("offset", "0"), 10, 64)
+	if err != nil || offset < 0 {
+		utils.SendError(c, http.StatusBadRequest, "bad_request", "invalid offset")
+		return
+	}
+
+	letters, err := h.deadLetterService.List(c.Request.Context(), c.Query("kind"), limit, offset)
+	if err != nil {
+		utils.SendError(c, http.StatusInternalServerError, "internal_error", "failed to list dead letters")
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.DeadLetterListResponse{
+		DeadLetters: letters,
+		Limit:       limit,
+		Offset:      offset,
+	})
+}
+
+func (h *AdminHandler) GetDeadLetter(c *gin.Context) {
+	id, ok := parseObjectIDParam(c)
+	if !ok {
+		return
+	}
+
+	letter, err := h.deadLetterService.Get(c.Request.Context(), id)
+	if err != nil {
+		sendServiceError(c, err, "failed to retrieve dead letter")
+		return
+	}
+
+	c.JSON(http.StatusOK, letter)
+}
+
+func (h *AdminHandler) ReplayDeadLetter(c *gin.Context) {
+	id, ok := parseObjectIDParam(c)
+	if !ok {
+		return
+	}
+
+	if err := h.deadLetterService.Replay(c.Request.Context(), id); err != nil {
+		sendServiceError(c, err, "failed to replay dead letter")
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.MessageResponse{
+		Message: "dead letter replayed successfully",
+	})
+}
+
+func (h *AdminHandler) DiscardDeadLetter(c *gin.Context) {
+	id, ok := parseObjectIDParam(c)
+	if !ok {
+		return
+	}
+
+	if err := h.deadLetterService.Discard(c.Request.Context(), id); err != nil {
+		sendServiceError(c, err, "failed to discard dead letter")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// EventBusStats retorna as métricas do producer; só o driver kafka as expõe.
+func (h *AdminHandler) EventBusStats(c *gin.Context) {
+	reporter, ok := h.bus.(messaging.StatsReporter)
+	if !ok {
+		utils.SendError(c, http.StatusNotFound, "not_found", "event bus does not report stats")
+		return
+	}
+
+	c.JSON(http.StatusOK, reporter.Stats())
+}
+
+func parseObjectIDParam(c *gin.Context) (primitive.ObjectID, bool) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		utils.SendError(c, http.StatusBadRequest, "bad_request", "invalid ID")
+		return primitive.NilObjectID, false
+	}
+	return id, true
+}
@@ -1,7 +1,6 @@
 package handlers
 
 import (
-	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -25,24 +24,24 @@ func (h *AuthHandler) Register(c *gin.Context) {
 	var req dto.RegisterRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		utils.SendError(c, http.StatusBadRequest, "bad_request", err.Error())
+		utils.SendBindingError(c, err)
 		return
 	}
 
+	// Sem preferência explícita, o usuário fica com o idioma da requisição
+	if req.Locale == "" {
+		req.Locale = c.GetString("locale")
+	}
+
 	user, err := h.userService.Register(c.Request.Context(), &req)
 	if err != nil {
-		// Trata erros específicos
-		if errors.Is(err, services.ErrEmailExists) {
-			utils.SendError(c, http.StatusConflict, "conflict", "email already exists")
-			return
-		}
-		utils.SendError(c, http.StatusInternalServerError, "internal_error", "failed to register user")
+		sendServiceError(c, err, "failed to register user")
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{
-		"message": "user registered successfully",
-		"user": dto.UserResponse{
+	c.JSON(http.StatusCreated, dto.RegisterResponse{
+		Message: "user registered successfully",
+		User: dto.UserResponse{
 			ID:        user.ID.Hex(),
 			Name:      user.Name,
 			Email:     user.Email,
@@ -55,18 +54,13 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	var req dto.LoginRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		utils.SendError(c, http.StatusBadRequest, "bad_request", err.Error())
+		utils.SendBindingError(c, err)
 		return
 	}
 
 	loginResponse, err := h.userService.Login(c.Request.Context(), &req)
 	if err != nil {
-		// Trata erros específicos
-		if errors.Is(err, services.ErrInvalidCredentials) {
-			utils.SendError(c, http.StatusUnauthorized, "unauthorized", "invalid credentials")
-			return
-		}
-		utils.SendError(c, http.StatusInternalServerError, "internal_error", "failed to login")
+		sendServiceError(c, err, "failed to login")
 		return
 	}
 
@@ -74,25 +68,40 @@ func (h *AuthHandler) Login(c *gin.Context) {
 }
 
 func (h *AuthHandler) GetProfile(c *gin.Context) {
-	userID, exists := c.Get("user_id")
-	if !exists {
-		utils.SendError(c, http.StatusUnauthorized, "unauthorized", "user not authenticated")
+	objectID, ok := authenticatedUserID(c)
+	if !ok {
 		return
 	}
 
-	objectID, err := primitive.ObjectIDFromHex(userID.(string))
+	user, err := h.userService.GetByID(c.Request.Context(), objectID)
 	if err != nil {
-		utils.SendError(c, http.StatusBadRequest, "bad_request", "invalid user ID")
+		sendServiceError(c, err, "failed to retrieve user profile")
 		return
 	}
 
-	user, err := h.userService.GetByID(c.Request.Context(), objectID)
+	c.JSON(http.StatusOK, dto.UserResponse{
+		ID:        user.ID.Hex(),
+		Name:      user.Name,
+		Email:     user.Email,
+		CreatedAt: user.CreatedAt.String(),
+	})
+}
+
+func (h *AuthHandler) UpdateProfile(c *gin.Context) {
+	userID, ok := authenticatedUserID(c)
+	if !ok {
+		return
+	}
+
+	var req dto.UpdateProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendBindingError(c, err)
+		return
+	}
+
+	user, err := h.userService.UpdateProfile(c.Request.Context(), userID, &req)
 	if err != nil {
-		if errors.Is(err, services.ErrUserNotFound) {
-			utils.SendError(c, http.StatusNotFound, "not_found", "user not found")
-			return
-		}
-		utils.SendError(c, http.StatusInternalServerError, "internal_error", "failed to retrieve user profile")
+		sendServiceError(c, err, "failed to update user profile")
 		return
 	}
 
@@ -103,3 +112,54 @@ func (h *AuthHandler) GetProfile(c *gin.Context) {
 		CreatedAt: user.CreatedAt.String(),
 	})
 }
+
+func (h *AuthHandler) ChangePassword(c *gin.Context) {
+	userID, ok := authenticatedUserID(c)
+	if !ok {
+		return
+	}
+
+	var req dto.ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendBindingError(c, err)
+		return
+	}
+
+	if err := h.userService.ChangePassword(c.Request.Context(), userID, &req); err != nil {
+		sendServiceError(c, err, "failed to change password")
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.MessageResponse{Message: "password changed successfully"})
+}
+
+func (h *AuthHandler) DeleteProfile(c *gin.Context) {
+	userID, ok := authenticatedUserID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.userService.Delete(c.Request.Context(), userID); err != nil {
+		sendServiceError(c, err, "failed to delete user")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// authenticatedUserID lê o ID colocado no contexto pelo AuthMiddleware, respondendo
+// com erro quando ele não existe ou é inválido.
+func authenticatedUserID(c *gin.Context) (primitive.ObjectID, bool) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.SendError(c, http.StatusUnauthorized, "unauthorized", "user not authenticated")
+		return primitive.NilObjectID, false
+	}
+
+	objectID, err := primitive.ObjectIDFromHex(userID.(string))
+	if err != nil {
+		utils.SendError(c, http.StatusBadRequest, "bad_request", "invalid user ID")
+		return primitive.NilObjectID, false
+	}
+	return objectID, true
+}
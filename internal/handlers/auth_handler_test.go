@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lucas/go-rest-api-mongo/internal/config"
+	"github.com/lucas/go-rest-api-mongo/internal/dto"
+	"github.com/lucas/go-rest-api-mongo/internal/middleware"
+	"github.com/lucas/go-rest-api-mongo/internal/repositories"
+	"github.com/lucas/go-rest-api-mongo/internal/services"
+	"github.com/lucas/go-rest-api-mongo/pkg/utils"
+)
+
+func newTestAuthRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	utils.UseJSONFieldNames()
+
+	cfg := &config.Config{JWT: config.JWTConfig{SecretKey: "test-secret", Expiration: time.Hour}}
+	authService := services.NewAuthService(cfg)
+	userService := services.NewUserService(repositories.NewMemoryUserRepository(), authService, nil)
+	handler := NewAuthHandler(userService)
+
+	router := gin.New()
+	router.POST("/register", handler.Register)
+	router.POST("/login", handler.Login)
+	router.GET("/profile", middleware.AuthMiddleware(authService, userService), handler.GetProfile)
+	return router
+}
+
+func doJSON(router *gin.Engine, method, path, body, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestAuthHandlerRegisterLoginProfile(t *testing.T) {
+	router := newTestAuthRouter()
+
+	w := doJSON(router, http.MethodPost, "/register", `{"name":"Ana","email":"ana@example.com","password":"secret123"}`, "")
+	if w.Code != http.StatusCreated {
+		t.Fatalf("register status = %d, body = %s", w.Code, w.Body)
+	}
+
+	w = doJSON(router, http.MethodPost, "/login", `{"email":"ana@example.com","password":"secret123"}`, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("login status = %d, body = %s", w.Code, w.Body)
+	}
+	var login dto.LoginResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &login); err != nil || login.Token == "" {
+		t.Fatalf("login response = %s, want a token", w.Body)
+	}
+
+	w = doJSON(router, http.MethodGet, "/profile", "", login.Token)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "ana@example.com") {
+		t.Fatalf("profile status = %d, body = %s", w.Code, w.Body)
+	}
+}
+
+func TestAuthHandlerErrorsAreProblems(t *testing.T) {
+	router := newTestAuthRouter()
+	doJSON(router, http.MethodPost, "/register", `{"name":"Ana","email":"ana@example.com","password":"secret123"}`, "")
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		wantStatus int
+		wantCode   string
+	}{
+		{"duplicate email", http.MethodPost, "/register", `{"name":"Ana","email":"ana@example.com","password":"secret123"}`, http.StatusConflict, "conflict"},
+		{"invalid body", http.MethodPost, "/register", `{"name":"Ana","email":"not-an-email","password":"1"}`, http.StatusBadRequest, "validation_error"},
+		{"wrong password", http.MethodPost, "/login", `{"email":"ana@example.com","password":"wrong"}`, http.StatusUnauthorized, "unauthorized"},
+		{"missing token", http.MethodGet, "/profile", "", http.StatusUnauthorized, "unauthorized"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := doJSON(router, tt.method, tt.path, tt.body, "")
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (body = %s)", w.Code, tt.wantStatus, w.Body)
+			}
+			if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, utils.ProblemContentType) {
+				t.Errorf("Content-Type = %q, want %q", ct, utils.ProblemContentType)
+			}
+
+			var problem utils.Problem
+			if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil || problem.Code != tt.wantCode {
+				t.Errorf("problem = %s, want code %q", w.Body, tt.wantCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lucas/go-rest-api-mongo/internal/services"
+	"github.com/lucas/go-rest-api-mongo/pkg/utils"
+)
+
+type problemMapping struct {
+	err     error
+	status  int
+	code    string
+	message string
+}
+
+// problemRegistry é o mapeamento central dos erros de serviço para respostas HTTP.
+// Erros que não estão aqui viram 500 com a mensagem genérica de cada handler.
+var problemRegistry = []problemMapping{
+	{services.ErrEmailExists, http.StatusConflict, "conflict", "email already exists"},
+	{services.ErrInvalidCredentials, http.StatusUnauthorized, "unauthorized", "invalid credentials"},
+	{services.ErrUserNotFound, http.StatusNotFound, "not_found", "user not found"},
+	{services.ErrInvalidPassword, http.StatusForbidden, "forbidden", "current password is incorrect"},
+	{services.ErrUserDisabled, http.StatusForbidden, "forbidden", "user is disabled"},
+	{services.ErrDeadLetterNotFound, http.StatusNotFound, "not_found", "dead letter not found"},
+	{services.ErrPoolSizeOutOfRange, http.StatusBadRequest, "bad_request", "worker pool size is outside the configured bounds"},
+	{services.ErrJobTypeNotFound, http.StatusNotFound, "not_found", "job type not found"},
+	{services.ErrUnknownLane, http.StatusBadRequest, "bad_request", "unknown priority lane"},
+	{services.ErrScheduleNotFound, http.StatusNotFound, "not_found", "scheduled job not found"},
+	{services.ErrScheduleRunning, http.StatusConflict, "conflict", "scheduled job is already running"},
+}
+
+// sendServiceError responde com o Problem registrado para err, ou com um 500 usando fallback.
+func sendServiceError(c *gin.Context, err error, fallback string) {
+	for _, mapping := range problemRegistry {
+		if errors.Is(err, mapping.err) {
+			utils.SendError(c, mapping.status, mapping.code, mapping.message)
+			return
+		}
+	}
+
+	log.Printf("%s %s: %v", c.Request.Method, c.FullPath(), err)
+	utils.SendError(c, http.StatusInternalServerError, "internal_error", fallback)
+}
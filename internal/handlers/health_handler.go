@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lucas/go-rest-api-mongo/internal/dto"
+)
+
+// healthTimeout limita cada verificação, para que o probe não fique pendurado.
+const healthTimeout = 2 * time.Second
+
+// HealthCheck retorna um erro quando o componente não está saudável.
+type HealthCheck func(ctx context.Context) error
+
+// MetricsSource devolve as métricas de um componente.
+type MetricsSource func(ctx context.Context) (interface{}, error)
+
+// HealthHandler responde /health e /metrics de um processo. Cada role registra as
+// verificações e métricas dos componentes que executa.
+type HealthHandler struct {
+	role    string
+	checks  []namedCheck
+	metrics []namedMetrics
+}
+
+type namedCheck struct {
+	name  string
+	check HealthCheck
+}
+
+type namedMetrics struct {
+	name   string
+	source MetricsSource
+}
+
+func NewHealthHandler(role string) *HealthHandler {
+	return &HealthHandler{role: role}
+}
+
+func (h *HealthHandler) AddCheck(name string, check HealthCheck) {
+	h.checks = append(h.checks, namedCheck{name, check})
+}
+
+func (h *HealthHandler) AddMetrics(name string, source MetricsSource) {
+	h.metrics = append(h.metrics, namedMetrics{name, source})
+}
+
+// Health responde 200 quando todas as verificações passam e 503 caso contrário.
+func (h *HealthHandler) Health(c *gin.Context) {
+	resp := dto.HealthResponse{Status: "ok", Role: h.role}
+	status := http.StatusOK
+
+	if len(h.checks) > 0 {
+		resp.Checks = make(map[string]string, len(h.checks))
+	}
+	for _, check := range h.checks {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), healthTimeout)
+		err := check.check(ctx)
+		cancel()
+
+		resp.Checks[check.name] = "ok"
+		if err != nil {
+			resp.Checks[check.name] = err.Error()
+			resp.Status = "unavailable"
+			status = http.StatusServiceUnavailable
+		}
+	}
+
+	c.JSON(status, resp)
+}
+
+// Metrics responde com as métricas de todos os componentes; um componente que falha
+// aparece em errors sem impedir os demais.
+func (h *HealthHandler) Metrics(c *gin.Context) {
+	resp := dto.MetricsResponse{Role: h.role, Metrics: make(map[string]interface{}, len(h.metrics))}
+	for _, metrics := range h.metrics {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), healthTimeout)
+		value, err := metrics.source(ctx)
+		cancel()
+
+		resp.Metrics[metrics.name] = value
+		if err != nil {
+			if resp.Errors == nil {
+				resp.Errors = make(map[string]string)
+			}
+			resp.Errors[metrics.name] = err.Error()
+		}
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
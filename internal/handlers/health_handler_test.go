@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lucas/go-rest-api-mongo/internal/dto"
+)
+
+func TestHealthHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var queueErr error
+	health := NewHealthHandler("worker")
+	health.AddCheck("queue", func(context.Context) error { return queueErr })
+	health.AddMetrics("pool", func(context.Context) (interface{}, error) { return map[string]int{"jobs": 3}, nil })
+	health.AddMetrics("bus", func(context.Context) (interface{}, error) { return nil, errors.New("bus down") })
+
+	router := gin.New()
+	router.GET("/health", health.Health)
+	router.GET("/metrics", health.Metrics)
+
+	var resp dto.HealthResponse
+	w := doJSON(router, http.MethodGet, "/health", "", "")
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if w.Code != http.StatusOK || resp.Role != "worker" || resp.Checks["queue"] != "ok" {
+		t.Fatalf("health = %d %s, want ok", w.Code, w.Body)
+	}
+
+	queueErr = errors.New("queue unreachable")
+	w = doJSON(router, http.MethodGet, "/health", "", "")
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if w.Code != http.StatusServiceUnavailable || resp.Status != "unavailable" || resp.Checks["queue"] != "queue unreachable" {
+		t.Fatalf("health = %d %s, want unavailable with the error", w.Code, w.Body)
+	}
+
+	var metrics dto.MetricsResponse
+	w = doJSON(router, http.MethodGet, "/metrics", "", "")
+	json.Unmarshal(w.Body.Bytes(), &metrics)
+	if w.Code != http.StatusOK || metrics.Metrics["pool"] == nil || metrics.Errors["bus"] != "bus down" {
+		t.Errorf("metrics = %d %s, want the pool metrics and the bus error", w.Code, w.Body)
+	}
+}
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lucas/go-rest-api-mongo/internal/dto"
+	"github.com/lucas/go-rest-api-mongo/internal/services"
+	"github.com/lucas/go-rest-api-mongo/pkg/utils"
+)
+
+// ScheduleHandler expõe os jobs agendados de manutenção. Como os worker pools, eles
+// rodam nos processos da role worker, que servem as mesmas rotas; o estado e o
+// histórico ficam no banco, então qualquer réplica worker responde por todas.
+type ScheduleHandler struct {
+	scheduler *services.Scheduler
+}
+
+func NewScheduleHandler(scheduler *services.Scheduler) *ScheduleHandler {
+	return &ScheduleHandler{scheduler: scheduler}
+}
+
+// ListSchedules devolve os jobs com o próximo horário e a última execução.
+func (h *ScheduleHandler) ListSchedules(c *gin.Context) {
+	if !h.available(c) {
+		return
+	}
+
+	schedules, err := h.scheduler.List(c.Request.Context())
+	if err != nil {
+		sendServiceError(c, err, "failed to list scheduled jobs")
+		return
+	}
+	c.JSON(http.StatusOK, schedules)
+}
+
+// UpdateSchedule pausa ou retoma um job em todas as réplicas.
+func (h *ScheduleHandler) UpdateSchedule(c *gin.Context) {
+	if !h.available(c) {
+		return
+	}
+
+	var req dto.UpdateScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendBindingError(c, err)
+		return
+	}
+
+	status, err := h.scheduler.SetPaused(c.Request.Context(), c.Param("name"), *req.Paused)
+	if err != nil {
+		sendServiceError(c, err, "failed to update scheduled job")
+		return
+	}
+	c.JSON(http.StatusOK, status)
+}
+
+// TriggerSchedule dispara o job agora e responde assim que a execução começa.
+func (h *ScheduleHandler) TriggerSchedule(c *gin.Context) {
+	if !h.available(c) {
+		return
+	}
+
+	run, err := h.scheduler.Trigger(c.Request.Context(), c.Param("name"))
+	if err != nil {
+		sendServiceError(c, err, "failed to trigger scheduled job")
+		return
+	}
+	c.JSON(http.StatusAccepted, run)
+}
+
+// ListScheduleRuns devolve o histórico de execuções de um job, mais recentes primeiro.
+func (h *ScheduleHandler) ListScheduleRuns(c *gin.Context) {
+	if !h.available(c) {
+		return
+	}
+
+	limit, err := strconv.ParseInt(c.DefaultQuery("limit", strconv.Itoa(defaultListLimit)), 10, 64)
+	if err != nil || limit <= 0 || limit > maxListLimit {
+		utils.SendError(c, http.StatusBadRequest, "bad_request", "invalid limit")
+		return
+	}
+
+	runs, err := h.scheduler.Runs(c.Request.Context(), c.Param("name"), limit)
+	if err != nil {
+		sendServiceError(c, err, "failed to list scheduled job runs")
+		return
+	}
+	c.JSON(http.StatusOK, dto.ScheduleRunListResponse{Runs: runs, Limit: limit})
+}
+
+func (h *ScheduleHandler) available(c *gin.Context) bool {
+	if h.scheduler == nil {
+		utils.SendError(c, http.StatusNotFound, "not_found", "scheduled jobs run in the worker processes")
+		return false
+	}
+	return true
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lucas/go-rest-api-mongo/internal/dto"
+	"github.com/lucas/go-rest-api-mongo/internal/models"
+	"github.com/lucas/go-rest-api-mongo/internal/repositories"
+	"github.com/lucas/go-rest-api-mongo/internal/services"
+)
+
+func newTestScheduleRouter(scheduler *services.Scheduler) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	handler := NewScheduleHandler(scheduler)
+	router := gin.New()
+	router.GET("/schedules", handler.ListSchedules)
+	router.PUT("/schedules/:name", handler.UpdateSchedule)
+	router.POST("/schedules/:name/trigger", handler.TriggerSchedule)
+	router.GET("/schedules/:name/runs", handler.ListScheduleRuns)
+	return router
+}
+
+func TestScheduleHandler(t *testing.T) {
+	scheduler := services.NewScheduler(repositories.NewMemoryScheduleRepository(), repositories.NewMemoryLeaseRepository(), services.SchedulerOptions{
+		LeaseTTL:         time.Second,
+		HistoryRetention: time.Hour,
+	})
+	scheduler.Add(services.ScheduledTask{
+		Name:     "cleanup",
+		Schedule: "0 3 * * *",
+		Run:      func(context.Context) (string, error) { return "done", nil },
+	})
+	router := newTestScheduleRouter(scheduler)
+
+	var statuses []services.ScheduleStatus
+	w := doJSON(router, http.MethodGet, "/schedules", "", "")
+	json.Unmarshal(w.Body.Bytes(), &statuses)
+	if w.Code != http.StatusOK || len(statuses) != 1 || statuses[0].Name != "cleanup" || statuses[0].NextRunAt == nil {
+		t.Fatalf("GET = %d %s, want the cleanup job with its next run", w.Code, w.Body)
+	}
+
+	var status services.ScheduleStatus
+	w = doJSON(router, http.MethodPut, "/schedules/cleanup", `{"paused":true}`, "")
+	json.Unmarshal(w.Body.Bytes(), &status)
+	if w.Code != http.StatusOK || !status.Paused {
+		t.Fatalf("PUT = %d %s, want the job paused", w.Code, w.Body)
+	}
+	if w = doJSON(router, http.MethodPut, "/schedules/cleanup", `{}`, ""); w.Code != http.StatusBadRequest {
+		t.Errorf("PUT without paused = %d %s, want 400", w.Code, w.Body)
+	}
+	if w = doJSON(router, http.MethodPut, "/schedules/export", `{"paused":true}`, ""); w.Code != http.StatusNotFound {
+		t.Errorf("PUT on an unknown job = %d %s, want 404", w.Code, w.Body)
+	}
+
+	var run models.ScheduleRun
+	w = doJSON(router, http.MethodPost, "/schedules/cleanup/trigger", "", "")
+	json.Unmarshal(w.Body.Bytes(), &run)
+	if w.Code != http.StatusAccepted || run.Job != "cleanup" || run.Trigger != models.ScheduleTriggerManual {
+		t.Fatalf("POST trigger = %d %s, want the manual run", w.Code, w.Body)
+	}
+	scheduler.Wait()
+
+	var runs dto.ScheduleRunListResponse
+	w = doJSON(router, http.MethodGet, "/schedules/cleanup/runs?limit=5", "", "")
+	json.Unmarshal(w.Body.Bytes(), &runs)
+	if w.Code != http.StatusOK || len(runs.Runs) != 1 || runs.Runs[0].Result != "done" {
+		t.Errorf("GET runs = %d %s, want the finished run", w.Code, w.Body)
+	}
+	if w = doJSON(router, http.MethodGet, "/schedules/cleanup/runs?limit=0", "", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("GET runs with limit 0 = %d %s, want 400", w.Code, w.Body)
+	}
+}
+
+func TestScheduleHandlerWithoutScheduler(t *testing.T) {
+	router := newTestScheduleRouter(nil)
+	if w := doJSON(router, http.MethodGet, "/schedules", "", ""); w.Code != http.StatusNotFound {
+		t.Errorf("GET on the api role = %d %s, want 404", w.Code, w.Body)
+	}
+}
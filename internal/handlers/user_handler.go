@@ -1,21 +1,40 @@
 package handlers
 
 import (
+	"crypto/sha256"
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"github.com/lucas/go-rest-api-mongo/internal/dto"
+	"github.com/lucas/go-rest-api-mongo/internal/middleware"
+	"github.com/lucas/go-rest-api-mongo/internal/models"
 	"github.com/lucas/go-rest-api-mongo/internal/services"
 	"github.com/lucas/go-rest-api-mongo/pkg/utils"
 )
 
+// PriorityHeader escolhe a lane do registro no /register-fast: high, normal (padrão)
+// ou low, para importações em massa. A rota é pública, então high só vale para
+// clientes com uma das chaves de priorityKeys; os demais ficam na normal.
+const PriorityHeader = "X-Priority"
+
 type UserHandler struct {
-	workerPool *services.WorkerPool
+	submitter    *services.RegistrationSubmitter
+	fairKey      middleware.KeyFunc // o cliente, para alternar os registros de clientes diferentes
+	priorityKeys map[[sha256.Size]byte]bool
 }
 
-func NewUserHandler(workerPool *services.WorkerPool) *UserHandler {
+// NewUserHandler recebe as chaves de API (X-API-Key) que podem usar a lane high.
+func NewUserHandler(submitter *services.RegistrationSubmitter, fairKey middleware.KeyFunc, priorityKeys []string) *UserHandler {
+	// Guarda só os hashes, para que a busca não dependa do conteúdo da chave
+	hashes := make(map[[sha256.Size]byte]bool, len(priorityKeys))
+	for _, key := range priorityKeys {
+		hashes[sha256.Sum256([]byte(key))] = true
+	}
 	return &UserHandler{
-		workerPool: workerPool,
+		submitter:    submitter,
+		fairKey:      fairKey,
+		priorityKeys: hashes,
 	}
 }
 
@@ -23,16 +42,36 @@ func (h *UserHandler) Register(c *gin.Context) {
 	var req dto.RegisterRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		utils.SendError(c, http.StatusBadRequest, "bad_request", err.Error())
+		utils.SendBindingError(c, err)
 		return
 	}
 
-	if err := h.workerPool.Submit(&req); err != nil {
-		utils.SendError(c, http.StatusServiceUnavailable, "service_unavailable", "too many requests")
+	// Sem preferência explícita, o usuário fica com o idioma da requisição
+	if req.Locale == "" {
+		req.Locale = c.GetString("locale")
+	}
+
+	opts := services.EnqueueOptions{Lane: c.GetHeader(PriorityHeader), FairKey: h.fairKey(c)}
+	if opts.Lane == models.JobLaneHigh && !h.canPrioritize(c) {
+		opts.Lane = models.JobLaneNormal
+	}
+	if err := h.submitter.Submit(c.Request.Context(), &req, opts); err != nil {
+		if errors.Is(err, services.ErrQueueFull) {
+			c.Header("Retry-After", "1")
+			utils.SendError(c, http.StatusServiceUnavailable, "service_unavailable", "too many requests")
+			return
+		}
+		sendServiceError(c, err, "failed to queue registration")
 		return
 	}
 
-	c.JSON(http.StatusAccepted, gin.H{
-		"message": "registration request accepted",
+	c.JSON(http.StatusAccepted, dto.MessageResponse{
+		Message: "registration request accepted",
 	})
 }
+
+// canPrioritize diz se o cliente tem uma chave de API que pode usar a lane high.
+func (h *UserHandler) canPrioritize(c *gin.Context) bool {
+	apiKey := c.GetHeader(middleware.APIKeyHeader)
+	return apiKey != "" && h.priorityKeys[sha256.Sum256([]byte(apiKey))]
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lucas/go-rest-api-mongo/internal/config"
+	"github.com/lucas/go-rest-api-mongo/internal/middleware"
+	"github.com/lucas/go-rest-api-mongo/internal/models"
+	"github.com/lucas/go-rest-api-mongo/internal/repositories"
+	"github.com/lucas/go-rest-api-mongo/internal/services"
+)
+
+func newTestUserRouter(priorityKeys ...string) (*gin.Engine, *services.Queue[*models.User]) {
+	gin.SetMode(gin.TestMode)
+	cfg := &config.Config{JWT: config.JWTConfig{SecretKey: "test-secret", Expiration: time.Hour}}
+	userService := services.NewUserService(repositories.NewMemoryUserRepository(), services.NewAuthService(cfg), nil)
+	queue := services.NewQueue[*models.User](repositories.NewMemoryJobRepository(0), services.RegistrationJobType,
+		services.Lane{Name: models.JobLaneHigh, Weight: 6},
+		services.Lane{Name: models.JobLaneNormal, Weight: 3},
+		services.Lane{Name: models.JobLaneLow, Weight: 1},
+	)
+	handler := NewUserHandler(services.NewRegistrationSubmitter(userService, queue), middleware.KeyByAPIKey, priorityKeys)
+
+	router := gin.New()
+	router.POST("/register-fast", handler.Register)
+	return router, queue
+}
+
+func registerFast(router *gin.Engine, email string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/register-fast", strings.NewReader(`{"name":"Ana","email":"`+email+`","password":"secret123"}`))
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// laneDepths devolve quantos registros há em cada lane da fila.
+func laneDepths(t *testing.T, queue *services.Queue[*models.User]) map[string]int64 {
+	t.Helper()
+	stats, err := queue.LaneStats(context.Background())
+	if err != nil {
+		t.Fatalf("LaneStats() error = %v", err)
+	}
+	depths := make(map[string]int64, len(stats))
+	for _, lane := range stats {
+		depths[lane.Lane] = lane.Depth
+	}
+	return depths
+}
+
+func TestRegisterFastPriority(t *testing.T) {
+	router, queue := newTestUserRouter("importer-key")
+
+	requests := []map[string]string{
+		{PriorityHeader: "high"}, // anônimo: vai para a normal
+		{PriorityHeader: "high", middleware.APIKeyHeader: "unknown-key"},  // chave sem prioridade
+		{PriorityHeader: "high", middleware.APIKeyHeader: "importer-key"}, // chave de REGISTRATION_PRIORITY_KEYS
+		{PriorityHeader: "low"}, // baixar a prioridade não precisa de chave
+	}
+	for i, headers := range requests {
+		if w := registerFast(router, string(rune('a'+i))+"@example.com", headers); w.Code != http.StatusAccepted {
+			t.Fatalf("request %d = %d %s, want 202", i, w.Code, w.Body)
+		}
+	}
+
+	depths := laneDepths(t, queue)
+	if depths[models.JobLaneHigh] != 1 || depths[models.JobLaneNormal] != 2 || depths[models.JobLaneLow] != 1 {
+		t.Errorf("lane depths = %v, want only the trusted key in the high lane", depths)
+	}
+
+	if w := registerFast(router, "e@example.com", map[string]string{PriorityHeader: "urgent"}); w.Code != http.StatusBadRequest {
+		t.Errorf("unknown priority = %d %s, want 400", w.Code, w.Body)
+	}
+}
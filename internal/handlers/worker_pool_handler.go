@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lucas/go-rest-api-mongo/internal/dto"
+	"github.com/lucas/go-rest-api-mongo/internal/services"
+	"github.com/lucas/go-rest-api-mongo/pkg/utils"
+)
+
+// WorkerPoolHandler expõe o tamanho dos worker pools do processo, um por tipo de job.
+// Na role api não há pools: os workers rodam nos processos da role worker, que servem
+// as mesmas rotas.
+type WorkerPoolHandler struct {
+	jobs *services.JobRunner
+}
+
+func NewWorkerPoolHandler(jobs *services.JobRunner) *WorkerPoolHandler {
+	return &WorkerPoolHandler{jobs: jobs}
+}
+
+// GetWorkerPools devolve as métricas de cada pool pelo tipo de job.
+func (h *WorkerPoolHandler) GetWorkerPools(c *gin.Context) {
+	if !h.available(c) {
+		return
+	}
+
+	stats, err := h.jobs.Stats(c.Request.Context())
+	if err != nil {
+		// O tamanho continua útil sem a profundidade das filas
+		log.Printf("Error reading the job queue depths: %v", err)
+	}
+	c.JSON(http.StatusOK, stats)
+}
+
+// UpdateWorkerPool muda o número de workers e/ou liga e desliga o autoscaler do pool
+// de um tipo de job.
+func (h *WorkerPoolHandler) UpdateWorkerPool(c *gin.Context) {
+	if !h.available(c) {
+		return
+	}
+	pool, ok := h.jobs.Pool(c.Param("type"))
+	if !ok {
+		sendServiceError(c, services.ErrJobTypeNotFound, "failed to resize worker pool")
+		return
+	}
+
+	var req dto.UpdateWorkerPoolRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendBindingError(c, err)
+		return
+	}
+
+	if req.Autoscale != nil {
+		pool.SetAutoscale(*req.Autoscale)
+	}
+	if req.Workers != nil {
+		if err := pool.Resize(*req.Workers); err != nil {
+			sendServiceError(c, err, "failed to resize worker pool")
+			return
+		}
+	}
+
+	stats, err := pool.Stats(c.Request.Context())
+	if err != nil {
+		log.Printf("Error reading the %s queue depth: %v", pool.Type(), err)
+	}
+	c.JSON(http.StatusOK, stats)
+}
+
+func (h *WorkerPoolHandler) available(c *gin.Context) bool {
+	if h.jobs == nil {
+		utils.SendError(c, http.StatusNotFound, "not_found", "worker pools run in the worker processes")
+		return false
+	}
+	return true
+}
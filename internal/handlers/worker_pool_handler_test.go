@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lucas/go-rest-api-mongo/internal/models"
+	"github.com/lucas/go-rest-api-mongo/internal/repositories"
+	"github.com/lucas/go-rest-api-mongo/internal/services"
+)
+
+func newTestWorkerPoolRouter(jobs *services.JobRunner) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	handler := NewWorkerPoolHandler(jobs)
+	router := gin.New()
+	router.GET("/workers", handler.GetWorkerPools)
+	router.PUT("/workers/:type", handler.UpdateWorkerPool)
+	return router
+}
+
+func TestWorkerPoolHandler(t *testing.T) {
+	jobs := services.NewJobRunner()
+	pool := services.RegisterJob(jobs, services.JobHandler[*models.User]{
+		Queue:        services.NewQueue[*models.User](repositories.NewMemoryJobRepository(0), services.RegistrationJobType),
+		BatchSize:    10,
+		Timeout:      time.Minute,
+		PollInterval: time.Second,
+		Concurrency:  2,
+		RetryPolicy:  services.RetryPolicy{MaxAttempts: 1},
+	}, nil)
+	pool.SetScaling(services.WorkerPoolScaling{Min: 1, Max: 8})
+	router := newTestWorkerPoolRouter(jobs)
+
+	var pools map[string]services.WorkerPoolStats
+	w := doJSON(router, http.MethodGet, "/workers", "", "")
+	json.Unmarshal(w.Body.Bytes(), &pools)
+	if stats := pools["registration"]; w.Code != http.StatusOK || stats.Workers != 2 || stats.MaxWorkers != 8 {
+		t.Fatalf("GET = %d %s, want 2 registration workers up to 8", w.Code, w.Body)
+	}
+
+	var stats services.WorkerPoolStats
+	w = doJSON(router, http.MethodPut, "/workers/registration", `{"workers":6,"autoscale":true}`, "")
+	json.Unmarshal(w.Body.Bytes(), &stats)
+	if w.Code != http.StatusOK || stats.Workers != 6 || !stats.Autoscale {
+		t.Fatalf("PUT = %d %s, want 6 workers with autoscaling", w.Code, w.Body)
+	}
+
+	if w = doJSON(router, http.MethodPut, "/workers/registration", `{"workers":9}`, ""); w.Code != http.StatusBadRequest {
+		t.Errorf("PUT above the max = %d %s, want 400", w.Code, w.Body)
+	}
+	if w = doJSON(router, http.MethodPut, "/workers/registration", `{"workers":0}`, ""); w.Code != http.StatusBadRequest {
+		t.Errorf("PUT with 0 workers = %d %s, want 400", w.Code, w.Body)
+	}
+	if w = doJSON(router, http.MethodPut, "/workers/export", `{"workers":2}`, ""); w.Code != http.StatusNotFound {
+		t.Errorf("PUT on an unknown job type = %d %s, want 404", w.Code, w.Body)
+	}
+}
+
+func TestWorkerPoolHandlerWithoutPool(t *testing.T) {
+	router := newTestWorkerPoolRouter(nil)
+	if w := doJSON(router, http.MethodGet, "/workers", "", ""); w.Code != http.StatusNotFound {
+		t.Errorf("GET on the api role = %d %s, want 404", w.Code, w.Body)
+	}
+}
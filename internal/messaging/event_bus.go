@@ -0,0 +1,72 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrBusClosed é retornado ao publicar ou assinar depois de Close.
+var ErrBusClosed = errors.New("event bus is closed")
+
+// Message é um evento em um tópico do EventBus. Key define a partição no Kafka,
+// então eventos de uma mesma entidade devem usar a mesma key.
+type Message struct {
+	Topic   string
+	Key     string
+	Headers map[string]string
+	Payload []byte
+	Time    time.Time
+}
+
+// Handler processa uma mensagem recebida por Subscribe.
+type Handler func(ctx context.Context, msg Message) error
+
+// EventBus publica e consome eventos sem depender do transporte. Os drivers são
+// KafkaBus, MemoryBus (canal em processo) e FileBus (JSON lines, para debug local).
+type EventBus interface {
+	Publish(ctx context.Context, topic, key string, headers map[string]string, payload []byte) error
+	// PublishBatch publica as mensagens em uma única escrita quando o driver permite.
+	// Em falhas parciais o erro é um PublishErrors, com o erro de cada mensagem.
+	PublishBatch(ctx context.Context, messages []Message) error
+	// Subscribe entrega as mensagens do tópico ao handler até o contexto ser cancelado
+	// (retorna nil) ou o handler falhar (retorna o erro). Assinaturas do mesmo grupo
+	// dividem as mensagens; um grupo novo começa pela mensagem mais antiga retida.
+	Subscribe(ctx context.Context, topic, group string, handler Handler) error
+	Close() error
+}
+
+var (
+	_ EventBus = (*KafkaBus)(nil)
+	_ EventBus = (*MemoryBus)(nil)
+	_ EventBus = (*FileBus)(nil)
+
+	_ StatsReporter = (*KafkaBus)(nil)
+)
+
+// PublishErrors tem um erro (ou nil) para cada mensagem de um PublishBatch.
+type PublishErrors []error
+
+func (e PublishErrors) Error() string {
+	failed := 0
+	var first error
+	for _, err := range e {
+		if err != nil {
+			if first == nil {
+				first = err
+			}
+			failed++
+		}
+	}
+	return fmt.Sprintf("%d of %d messages failed to publish: %v", failed, len(e), first)
+}
+
+// Unwrap permite usar errors.Is/As com os erros das mensagens.
+func (e PublishErrors) Unwrap() []error {
+	return e
+}
+
+func newMessage(topic, key string, headers map[string]string, payload []byte) Message {
+	return Message{Topic: topic, Key: key, Headers: headers, Payload: payload}
+}
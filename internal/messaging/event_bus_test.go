@@ -0,0 +1,132 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// busDrivers são os drivers testados sem infraestrutura externa.
+func busDrivers(t *testing.T) map[string]func(t *testing.T) EventBus {
+	return map[string]func(t *testing.T) EventBus{
+		"memory": func(t *testing.T) EventBus {
+			return NewMemoryBus(0)
+		},
+		"file": func(t *testing.T) EventBus {
+			bus, err := NewFileBus(filepath.Join(t.TempDir(), "events.jsonl"))
+			if err != nil {
+				t.Fatalf("NewFileBus() error = %v", err)
+			}
+			return bus
+		},
+	}
+}
+
+var errStop = errors.New("stop")
+
+// collect assina o tópico até receber n mensagens.
+func collect(t *testing.T, bus EventBus, topic, group string, n int) []Message {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var received []Message
+	err := bus.Subscribe(ctx, topic, group, func(ctx context.Context, msg Message) error {
+		received = append(received, msg)
+		if len(received) == n {
+			return errStop
+		}
+		return nil
+	})
+	if !errors.Is(err, errStop) {
+		t.Fatalf("Subscribe() error = %v after %d of %d messages", err, len(received), n)
+	}
+	return received
+}
+
+func TestEventBusDrivers(t *testing.T) {
+	for name, newBus := range busDrivers(t) {
+		t.Run(name, func(t *testing.T) {
+			bus := newBus(t)
+			defer bus.Close()
+			ctx := context.Background()
+
+			if err := bus.Publish(ctx, "users", "u1", map[string]string{"ce-type": "created"}, []byte(`{"n":1}`)); err != nil {
+				t.Fatalf("Publish() error = %v", err)
+			}
+			if err := bus.PublishBatch(ctx, []Message{
+				{Topic: "other", Key: "x", Payload: []byte(`{}`)},
+				{Topic: "users", Key: "u2", Payload: []byte{0x00, 0xff}},
+			}); err != nil {
+				t.Fatalf("PublishBatch() error = %v", err)
+			}
+
+			received := collect(t, bus, "users", "g1", 2)
+			if received[0].Key != "u1" || string(received[0].Payload) != `{"n":1}` || received[0].Headers["ce-type"] != "created" {
+				t.Errorf("first message = %+v", received[0])
+			}
+			if received[1].Key != "u2" || string(received[1].Payload) != "\x00\xff" {
+				t.Errorf("binary payload = %v, want [0 255]", received[1].Payload)
+			}
+			if received[0].Time.IsZero() {
+				t.Error("published messages must have a time")
+			}
+
+			// O grupo continua de onde parou; um grupo novo começa do início
+			go func() {
+				time.Sleep(50 * time.Millisecond)
+				bus.Publish(ctx, "users", "u3", nil, []byte(`{"n":3}`))
+			}()
+			if next := collect(t, bus, "users", "g1", 1); next[0].Key != "u3" {
+				t.Errorf("g1 resumed at %q, want u3", next[0].Key)
+			}
+			if again := collect(t, bus, "users", "g2", 3); again[0].Key != "u1" {
+				t.Errorf("g2 started at %q, want u1", again[0].Key)
+			}
+		})
+	}
+}
+
+func TestEventBusSubscribeStopsOnCancel(t *testing.T) {
+	for name, newBus := range busDrivers(t) {
+		t.Run(name, func(t *testing.T) {
+			bus := newBus(t)
+			defer bus.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+			if err := bus.Subscribe(ctx, "users", "g", func(context.Context, Message) error { return nil }); err != nil {
+				t.Errorf("Subscribe() error = %v, want nil on cancel", err)
+			}
+		})
+	}
+}
+
+func TestMemoryBusRetention(t *testing.T) {
+	bus := NewMemoryBus(2)
+	for _, key := range []string{"a", "b", "c"} {
+		bus.Publish(context.Background(), "users", key, nil, nil)
+	}
+
+	messages := bus.Messages("users")
+	if len(messages) != 2 || messages[0].Key != "b" {
+		t.Fatalf("retained = %+v, want the last 2 messages", messages)
+	}
+	if first := collect(t, bus, "users", "g", 1); first[0].Key != "b" {
+		t.Errorf("new group started at %q, want the oldest retained message", first[0].Key)
+	}
+}
+
+func TestEventBusPublishAfterClose(t *testing.T) {
+	for name, newBus := range busDrivers(t) {
+		t.Run(name, func(t *testing.T) {
+			bus := newBus(t)
+			bus.Close()
+			if err := bus.Publish(context.Background(), "users", "", nil, nil); !errors.Is(err, ErrBusClosed) {
+				t.Errorf("Publish() error = %v, want ErrBusClosed", err)
+			}
+		})
+	}
+}
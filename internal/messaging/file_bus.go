@@ -0,0 +1,216 @@
+package messaging
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// filePollInterval é o intervalo em que os assinantes do FileBus procuram novas linhas.
+const filePollInterval = 200 * time.Millisecond
+
+// FileBus grava cada mensagem como uma linha JSON em um arquivo, para inspecionar
+// os eventos localmente (tail -f, jq). Os offsets dos grupos ficam só em memória:
+// um processo novo relê o arquivo desde o início.
+type FileBus struct {
+	path    string
+	mu      sync.Mutex
+	file    *os.File
+	cursors map[string]*fileCursor
+	closed  bool
+}
+
+// fileRecord é o formato de cada linha. Payloads JSON são gravados inline (compactados);
+// os demais vão em base64.
+type fileRecord struct {
+	Topic         string            `json:"topic"`
+	Key           string            `json:"key,omitempty"`
+	Headers       map[string]string `json:"headers,omitempty"`
+	Time          time.Time         `json:"time"`
+	Payload       json.RawMessage   `json:"payload,omitempty"`
+	PayloadBase64 []byte            `json:"payload_base64,omitempty"`
+}
+
+// fileCursor é a posição de leitura de um grupo em um tópico, compartilhada pelas
+// assinaturas do grupo.
+type fileCursor struct {
+	mu      sync.Mutex
+	file    *os.File
+	reader  *bufio.Reader
+	partial []byte // linha ainda sendo escrita
+}
+
+func NewFileBus(path string) (*FileBus, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("opening event file: %w", err)
+	}
+	return &FileBus{
+		path:    path,
+		file:    file,
+		cursors: make(map[string]*fileCursor),
+	}, nil
+}
+
+func (b *FileBus) Publish(ctx context.Context, topic, key string, headers map[string]string, payload []byte) error {
+	return b.PublishBatch(ctx, []Message{newMessage(topic, key, headers, payload)})
+}
+
+// PublishBatch grava todas as linhas em uma única escrita.
+func (b *FileBus) PublishBatch(ctx context.Context, messages []Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	now := time.Now().UTC()
+	for _, msg := range messages {
+		record := fileRecord{Topic: msg.Topic, Key: msg.Key, Headers: msg.Headers, Time: msg.Time}
+		if record.Time.IsZero() {
+			record.Time = now
+		}
+		if json.Valid(msg.Payload) {
+			record.Payload = json.RawMessage(msg.Payload)
+		} else {
+			record.PayloadBase64 = msg.Payload
+		}
+
+		line, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return ErrBusClosed
+	}
+	_, err := b.file.Write(buf.Bytes())
+	return err
+}
+
+func (b *FileBus) Subscribe(ctx context.Context, topic, group string, handler Handler) error {
+	cursor, err := b.cursor(topic, group)
+	if err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(filePollInterval)
+	defer ticker.Stop()
+
+	for {
+		msg, ok, err := b.next(cursor, topic)
+		if err != nil {
+			return err
+		}
+		if ok {
+			if err := handler(ctx, msg); err != nil {
+				return err
+			}
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// next lê a próxima mensagem do tópico a partir do cursor; ok é false quando o
+// arquivo ainda não tem linhas novas.
+func (b *FileBus) next(cursor *fileCursor, topic string) (Message, bool, error) {
+	b.mu.Lock()
+	closed := b.closed
+	b.mu.Unlock()
+	if closed {
+		return Message{}, false, ErrBusClosed
+	}
+
+	cursor.mu.Lock()
+	defer cursor.mu.Unlock()
+
+	for {
+		chunk, err := cursor.reader.ReadBytes('\n')
+		cursor.partial = append(cursor.partial, chunk...)
+		if err == io.EOF {
+			return Message{}, false, nil
+		}
+		if err != nil {
+			return Message{}, false, err
+		}
+
+		line := cursor.partial
+		cursor.partial = nil
+
+		var record fileRecord
+		if err := json.Unmarshal(line, &record); err != nil {
+			return Message{}, false, fmt.Errorf("decoding %s: %w", b.path, err)
+		}
+		if record.Topic != topic {
+			continue
+		}
+
+		payload := []byte(record.Payload)
+		if record.PayloadBase64 != nil {
+			payload = record.PayloadBase64
+		}
+		return Message{
+			Topic:   record.Topic,
+			Key:     record.Key,
+			Headers: record.Headers,
+			Payload: payload,
+			Time:    record.Time,
+		}, true, nil
+	}
+}
+
+func (b *FileBus) cursor(topic, group string) (*fileCursor, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return nil, ErrBusClosed
+	}
+
+	id := topic + "\x00" + group
+	if cursor, ok := b.cursors[id]; ok {
+		return cursor, nil
+	}
+
+	file, err := os.Open(b.path)
+	if err != nil {
+		return nil, fmt.Errorf("opening event file: %w", err)
+	}
+	cursor := &fileCursor{file: file, reader: bufio.NewReader(file)}
+	b.cursors[id] = cursor
+	return cursor, nil
+}
+
+func (b *FileBus) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return nil
+	}
+	b.closed = true
+
+	for _, cursor := range b.cursors {
+		cursor.mu.Lock()
+		cursor.file.Close()
+		cursor.mu.Unlock()
+	}
+	return b.file.Close()
+}
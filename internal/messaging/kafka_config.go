@@ -0,0 +1,133 @@
+package messaging
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/lucas/go-rest-api-mongo/internal/config"
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl"
+	"github.com/segmentio/kafka-go/sasl/plain"
+	"github.com/segmentio/kafka-go/sasl/scram"
+)
+
+// Valores de KAFKA_ACKS, KAFKA_BALANCER e KAFKA_COMPRESSION aceitos por NewKafkaBus.
+var (
+	kafkaAcks = map[string]kafka.RequiredAcks{
+		"all":  kafka.RequireAll,
+		"one":  kafka.RequireOne,
+		"none": kafka.RequireNone,
+	}
+
+	kafkaCompressions = map[string]kafka.Compression{
+		"none":   0,
+		"gzip":   kafka.Gzip,
+		"snappy": kafka.Snappy,
+		"lz4":    kafka.Lz4,
+		"zstd":   kafka.Zstd,
+	}
+)
+
+// kafkaBalancer escolhe a partição de cada mensagem. "hash" (FNV-1a, como o sarama)
+// e "murmur2" (como o client Java) mandam a mesma key sempre para a mesma partição,
+// o que mantém a ordem dos eventos de cada usuário.
+func kafkaBalancer(name string) (kafka.Balancer, error) {
+	switch strings.ToLower(name) {
+	case "", "hash":
+		return &kafka.Hash{}, nil
+	case "murmur2":
+		return kafka.Murmur2Balancer{}, nil
+	case "round_robin":
+		return &kafka.RoundRobin{}, nil
+	case "least_bytes":
+		return &kafka.LeastBytes{}, nil
+	default:
+		return nil, fmt.Errorf("unknown kafka balancer %q", name)
+	}
+}
+
+func kafkaRequiredAcks(name string) (kafka.RequiredAcks, error) {
+	if name == "" {
+		return kafka.RequireAll, nil
+	}
+	acks, ok := kafkaAcks[strings.ToLower(name)]
+	if !ok {
+		return 0, fmt.Errorf("unknown kafka acks %q", name)
+	}
+	return acks, nil
+}
+
+func kafkaCompression(name string) (kafka.Compression, error) {
+	if name == "" {
+		return 0, nil
+	}
+	codec, ok := kafkaCompressions[strings.ToLower(name)]
+	if !ok {
+		return 0, fmt.Errorf("unknown kafka compression %q", name)
+	}
+	return codec, nil
+}
+
+func kafkaSASL(cfg config.KafkaSASLConfig) (sasl.Mechanism, error) {
+	switch strings.ToUpper(cfg.Mechanism) {
+	case "":
+		return nil, nil
+	case "PLAIN":
+		return plain.Mechanism{Username: cfg.Username, Password: cfg.Password}, nil
+	case "SCRAM-SHA-256":
+		return scram.Mechanism(scram.SHA256, cfg.Username, cfg.Password)
+	case "SCRAM-SHA-512":
+		return scram.Mechanism(scram.SHA512, cfg.Username, cfg.Password)
+	default:
+		return nil, fmt.Errorf("unknown kafka SASL mechanism %q", cfg.Mechanism)
+	}
+}
+
+// kafkaTLS monta a configuração TLS. Sem CA, vale a cadeia do sistema; certificado e
+// chave são opcionais e habilitam mTLS.
+func kafkaTLS(cfg config.KafkaTLSConfig) (*tls.Config, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+	}
+
+	if cfg.CAFile != "" {
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading kafka CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("kafka CA file %s has no PEM certificates", cfg.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if cfg.CertFile != "" || cfg.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("loading kafka client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+// newKafkaDialer é usado pelos readers de Subscribe, com a mesma autenticação do writer.
+func newKafkaDialer(mechanism sasl.Mechanism, tlsConfig *tls.Config) *kafka.Dialer {
+	return &kafka.Dialer{
+		Timeout:       10 * time.Second,
+		DualStack:     true,
+		SASLMechanism: mechanism,
+		TLS:           tlsConfig,
+	}
+}
@@ -1 +1,45 @@
 package messaging
+
+import (
+	"context"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// Subscribe consome o tópico com um consumer group do Kafka (KAFKA_GROUP_ID quando
+// group é vazio). O offset só é commitado depois que o handler retorna sem erro.
+func (kb *KafkaBus) Subscribe(ctx context.Context, topic, group string, handler Handler) error {
+	if group == "" {
+		group = kb.groupID
+	}
+
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:     kb.brokers,
+		Dialer:      kb.dialer,
+		GroupID:     group,
+		Topic:       topic,
+		StartOffset: kafka.FirstOffset,
+	})
+	defer reader.Close()
+
+	for {
+		m, err := reader.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+
+		if err := handler(ctx, fromKafkaMessage(m)); err != nil {
+			return err
+		}
+
+		if err := reader.CommitMessages(ctx, m); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+	}
+}
@@ -2,33 +2,148 @@ package messaging
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"log"
 
 	"github.com/lucas/go-rest-api-mongo/internal/config"
 	"github.com/segmentio/kafka-go"
 )
 
-type KafkaProducer struct {
-	writer *kafka.Writer
+// KafkaBus é o EventBus sobre o Kafka. O tópico vem de cada mensagem, então um
+// único writer atende todos os tópicos.
+//
+// No modo assíncrono (KAFKA_ASYNC) PublishBatch retorna assim que as mensagens entram
+// no buffer do writer: falhas de entrega não chegam a quem publicou, ficam apenas no
+// log e nas métricas de Stats. Nesse modo os retries e dead letters do WorkerPool não
+// cobrem erros do broker.
+type KafkaBus struct {
+	writer  *kafka.Writer
+	dialer  *kafka.Dialer
+	brokers []string
+	groupID string
+	metrics *producerMetrics
 }
 
-func NewKafkaProducer(cfg *config.Config) *KafkaProducer {
-	return &KafkaProducer{
+func NewKafkaBus(cfg *config.Config) (*KafkaBus, error) {
+	acks, err := kafkaRequiredAcks(cfg.Kafka.Acks)
+	if err != nil {
+		return nil, err
+	}
+	balancer, err := kafkaBalancer(cfg.Kafka.Balancer)
+	if err != nil {
+		return nil, err
+	}
+	compression, err := kafkaCompression(cfg.Kafka.Compression)
+	if err != nil {
+		return nil, err
+	}
+	mechanism, err := kafkaSASL(cfg.Kafka.SASL)
+	if err != nil {
+		return nil, fmt.Errorf("configuring kafka SASL: %w", err)
+	}
+	tlsConfig, err := kafkaTLS(cfg.Kafka.TLS)
+	if err != nil {
+		return nil, err
+	}
+
+	metrics := &producerMetrics{stats: ProducerStats{Async: cfg.Kafka.Async}}
+	async := cfg.Kafka.Async
+
+	return &KafkaBus{
 		writer: &kafka.Writer{
 			Addr:         kafka.TCP(cfg.Kafka.Brokers...),
-			Topic:        cfg.Kafka.TopicUserRegistration,
-			Balancer:     &kafka.LeastBytes{},
-			RequiredAcks: kafka.RequireOne,
-			Async:        false,
+			Balancer:     balancer,
+			RequiredAcks: acks,
+			Compression:  compression,
+			BatchSize:    cfg.Kafka.BatchSize,
+			BatchTimeout: cfg.Kafka.BatchTimeout,
+			Async:        async,
+			Transport: &kafka.Transport{
+				SASL: mechanism,
+				TLS:  tlsConfig,
+			},
+			Completion: func(messages []kafka.Message, err error) {
+				metrics.record(messages, err)
+				// No modo síncrono o erro volta para quem publicou, que decide o que fazer
+				if err != nil && async {
+					log.Printf("Error delivering %d messages to kafka: %v", len(messages), err)
+				}
+			},
 		},
+		dialer:  newKafkaDialer(mechanism, tlsConfig),
+		brokers: cfg.Kafka.Brokers,
+		groupID: cfg.Kafka.GroupID,
+		metrics: metrics,
+	}, nil
+}
+
+// Stats retorna as métricas de entrega acumuladas pelo writer.
+func (kb *KafkaBus) Stats() ProducerStats {
+	return kb.metrics.snapshot()
+}
+
+func (kb *KafkaBus) Publish(ctx context.Context, topic, key string, headers map[string]string, payload []byte) error {
+	err := kb.PublishBatch(ctx, []Message{newMessage(topic, key, headers, payload)})
+
+	var publishErrs PublishErrors
+	if errors.As(err, &publishErrs) {
+		return publishErrs[0]
 	}
+	return err
 }
 
-func (kp *KafkaProducer) PublishUserRegistration(ctx context.Context, data []byte) error {
-	return kp.writer.WriteMessages(ctx, kafka.Message{
-		Value: data,
-	})
+// PublishBatch publica todas as mensagens em uma única chamada a WriteMessages.
+func (kb *KafkaBus) PublishBatch(ctx context.Context, messages []Message) error {
+	if len(messages) == 0 {
+		return nil
+	}
+
+	kafkaMessages := make([]kafka.Message, len(messages))
+	for i, msg := range messages {
+		kafkaMessages[i] = toKafkaMessage(msg)
+	}
+
+	err := kb.writer.WriteMessages(ctx, kafkaMessages...)
+
+	var writeErrs kafka.WriteErrors
+	if errors.As(err, &writeErrs) {
+		return PublishErrors(writeErrs)
+	}
+	return err
 }
 
-func (kp *KafkaProducer) Close() error {
-	return kp.writer.Close()
+func (kb *KafkaBus) Close() error {
+	return kb.writer.Close()
+}
+
+func toKafkaMessage(msg Message) kafka.Message {
+	m := kafka.Message{
+		Topic: msg.Topic,
+		Value: msg.Payload,
+		Time:  msg.Time,
+	}
+	if msg.Key != "" {
+		m.Key = []byte(msg.Key)
+	}
+	for name, value := range msg.Headers {
+		m.Headers = append(m.Headers, kafka.Header{Key: name, Value: []byte(value)})
+	}
+	return m
+}
+
+func fromKafkaMessage(m kafka.Message) Message {
+	msg := Message{
+		Topic:   m.Topic,
+		Key:     string(m.Key),
+		Payload: m.Value,
+		Time:    m.Time,
+	}
+	if len(m.Headers) > 0 {
+		msg.Headers = make(map[string]string, len(m.Headers))
+		for _, header := range m.Headers {
+			msg.Headers[header.Key] = string(header.Value)
+		}
+	}
+	return msg
 }
@@ -0,0 +1,84 @@
+package messaging
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/lucas/go-rest-api-mongo/internal/config"
+	"github.com/segmentio/kafka-go"
+)
+
+func TestNewKafkaBusAppliesProducerConfig(t *testing.T) {
+	cfg := &config.Config{Kafka: config.KafkaConfig{
+		Brokers:      []string{"broker-1:9092", "broker-2:9092"},
+		Acks:         "all",
+		Balancer:     "hash",
+		Compression:  "zstd",
+		BatchSize:    50,
+		BatchTimeout: 5 * time.Millisecond,
+		Async:        true,
+		SASL:         config.KafkaSASLConfig{Mechanism: "SCRAM-SHA-512", Username: "app", Password: "secret"},
+	}}
+
+	bus, err := NewKafkaBus(cfg)
+	if err != nil {
+		t.Fatalf("NewKafkaBus() error = %v", err)
+	}
+	defer bus.Close()
+
+	w := bus.writer
+	if w.RequiredAcks != kafka.RequireAll || w.Compression != kafka.Zstd || !w.Async {
+		t.Errorf("writer acks = %v, compression = %v, async = %t", w.RequiredAcks, w.Compression, w.Async)
+	}
+	if _, ok := w.Balancer.(*kafka.Hash); !ok {
+		t.Errorf("writer balancer = %T, want *kafka.Hash", w.Balancer)
+	}
+	if w.BatchSize != 50 || w.BatchTimeout != 5*time.Millisecond {
+		t.Errorf("writer batch = %d/%s, want 50/5ms", w.BatchSize, w.BatchTimeout)
+	}
+	if w.Addr.String() != "broker-1:9092,broker-2:9092" {
+		t.Errorf("writer addr = %s, want both brokers", w.Addr)
+	}
+
+	transport := w.Transport.(*kafka.Transport)
+	if transport.SASL == nil || transport.SASL.Name() != "SCRAM-SHA-512" || bus.dialer.SASLMechanism != transport.SASL {
+		t.Errorf("SASL mechanism must be shared by writer and readers")
+	}
+}
+
+func TestNewKafkaBusRejectsInvalidConfig(t *testing.T) {
+	for name, kafkaCfg := range map[string]config.KafkaConfig{
+		"acks":        {Acks: "most"},
+		"balancer":    {Balancer: "random"},
+		"compression": {Compression: "brotli"},
+		"sasl":        {SASL: config.KafkaSASLConfig{Mechanism: "GSSAPI"}},
+		"tls ca":      {TLS: config.KafkaTLSConfig{Enabled: true, CAFile: "missing-ca.pem"}},
+	} {
+		if _, err := NewKafkaBus(&config.Config{Kafka: kafkaCfg}); err == nil {
+			t.Errorf("NewKafkaBus(%s) error = nil, want an error", name)
+		}
+	}
+}
+
+func TestProducerMetrics(t *testing.T) {
+	var metrics producerMetrics
+	sent := time.Now().Add(-20 * time.Millisecond)
+
+	metrics.record([]kafka.Message{
+		{Key: []byte("k1"), Value: []byte("value"), Time: sent},
+		{Key: []byte("k2"), Value: []byte("value"), Time: sent},
+	}, nil)
+	metrics.record([]kafka.Message{{Value: []byte("lost")}}, errors.New("leader not available"))
+
+	stats := metrics.snapshot()
+	if stats.Delivered != 2 || stats.Failed != 1 || stats.Batches != 2 || stats.Bytes != 14 {
+		t.Errorf("stats = %+v, want 2 delivered, 1 failed, 2 batches and 14 bytes", stats)
+	}
+	if stats.AvgLatencyMs < 20 {
+		t.Errorf("avg latency = %.2fms, want at least 20ms", stats.AvgLatencyMs)
+	}
+	if stats.LastError != "leader not available" || stats.LastErrorAt == nil {
+		t.Errorf("last error = %q at %v", stats.LastError, stats.LastErrorAt)
+	}
+}
@@ -0,0 +1,171 @@
+package messaging
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DefaultMemoryRetention é quantas mensagens por tópico o MemoryBus guarda
+// quando a retenção não é configurada.
+const DefaultMemoryRetention = 10000
+
+// MemoryBus é um EventBus em processo. Cada tópico é um log com as últimas
+// retention mensagens e cada grupo tem seu offset nesse log, como no Kafka.
+// A entrega é at-most-once: uma mensagem cujo handler falha não é reentregue.
+type MemoryBus struct {
+	mu        sync.Mutex
+	cond      *sync.Cond
+	topics    map[string]*memoryTopic
+	retention int
+	closed    bool
+}
+
+type memoryTopic struct {
+	base     int // offset da primeira mensagem ainda retida
+	messages []Message
+	offsets  map[string]int
+}
+
+func NewMemoryBus(retention int) *MemoryBus {
+	if retention <= 0 {
+		retention = DefaultMemoryRetention
+	}
+	b := &MemoryBus{
+		topics:    make(map[string]*memoryTopic),
+		retention: retention,
+	}
+	b.cond = sync.NewCond(&b.mu)
+	return b
+}
+
+func (b *MemoryBus) Publish(ctx context.Context, topic, key string, headers map[string]string, payload []byte) error {
+	return b.PublishBatch(ctx, []Message{newMessage(topic, key, headers, payload)})
+}
+
+func (b *MemoryBus) PublishBatch(ctx context.Context, messages []Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return ErrBusClosed
+	}
+
+	now := time.Now()
+	for _, msg := range messages {
+		t := b.topic(msg.Topic)
+		t.messages = append(t.messages, copyMessage(msg, now))
+
+		if excess := len(t.messages) - b.retention; excess > 0 {
+			t.messages = append([]Message(nil), t.messages[excess:]...)
+			t.base += excess
+		}
+	}
+	b.cond.Broadcast()
+	return nil
+}
+
+func (b *MemoryBus) Subscribe(ctx context.Context, topic, group string, handler Handler) error {
+	// Acorda os assinantes bloqueados em cond.Wait quando o contexto é cancelado
+	stop := context.AfterFunc(ctx, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.cond.Broadcast()
+	})
+	defer stop()
+
+	for {
+		msg, ok, err := b.next(ctx, topic, group)
+		if err != nil || !ok {
+			return err
+		}
+		if err := handler(ctx, msg); err != nil {
+			return err
+		}
+	}
+}
+
+// next reserva a próxima mensagem do grupo, bloqueando até haver uma.
+func (b *MemoryBus) next(ctx context.Context, topic, group string) (Message, bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for {
+		if b.closed {
+			return Message{}, false, ErrBusClosed
+		}
+		if ctx.Err() != nil {
+			return Message{}, false, nil
+		}
+
+		t := b.topic(topic)
+		offset, ok := t.offsets[group]
+		if !ok || offset < t.base {
+			// Grupo novo ou mensagens descartadas pela retenção: começa pela mais antiga retida
+			offset = t.base
+		}
+
+		if offset < t.base+len(t.messages) {
+			t.offsets[group] = offset + 1
+			return copyMessage(t.messages[offset-t.base], time.Time{}), true, nil
+		}
+
+		t.offsets[group] = offset
+		b.cond.Wait()
+	}
+}
+
+// Messages retorna uma cópia das mensagens retidas no tópico.
+func (b *MemoryBus) Messages(topic string) []Message {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	t, ok := b.topics[topic]
+	if !ok {
+		return nil
+	}
+	messages := make([]Message, len(t.messages))
+	for i, msg := range t.messages {
+		messages[i] = copyMessage(msg, time.Time{})
+	}
+	return messages
+}
+
+func (b *MemoryBus) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	b.cond.Broadcast()
+	return nil
+}
+
+func (b *MemoryBus) topic(name string) *memoryTopic {
+	t, ok := b.topics[name]
+	if !ok {
+		t = &memoryTopic{offsets: make(map[string]int)}
+		b.topics[name] = t
+	}
+	return t
+}
+
+// copyMessage evita que o publisher ou o handler alterem a mensagem retida.
+// Um now não zero preenche Time quando a mensagem não traz um.
+func copyMessage(msg Message, now time.Time) Message {
+	out := msg
+	out.Payload = append([]byte(nil), msg.Payload...)
+	if msg.Headers != nil {
+		out.Headers = make(map[string]string, len(msg.Headers))
+		for name, value := range msg.Headers {
+			out.Headers[name] = value
+		}
+	}
+	if out.Time.IsZero() && !now.IsZero() {
+		out.Time = now
+	}
+	return out
+}
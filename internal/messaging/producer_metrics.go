@@ -0,0 +1,67 @@
+package messaging
+
+import (
+	"sync"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// ProducerStats são os contadores acumulados do producer desde o início do processo.
+type ProducerStats struct {
+	Async        bool       `json:"async"`
+	Delivered    int64      `json:"delivered"`
+	Failed       int64      `json:"failed"`
+	Bytes        int64      `json:"bytes"`
+	Batches      int64      `json:"batches"`
+	AvgLatencyMs float64    `json:"avg_latency_ms"` // da criação da mensagem até a confirmação do broker
+	LastError    string     `json:"last_error,omitempty"`
+	LastErrorAt  *time.Time `json:"last_error_at,omitempty"`
+}
+
+// StatsReporter é implementado pelos EventBus que expõem métricas do producer.
+type StatsReporter interface {
+	Stats() ProducerStats
+}
+
+// producerMetrics é alimentado pelo callback Completion do kafka.Writer, chamado a
+// cada lote entregue ou com falha, tanto no modo síncrono quanto no assíncrono.
+type producerMetrics struct {
+	mu      sync.Mutex
+	stats   ProducerStats
+	latency time.Duration
+}
+
+func (m *producerMetrics) record(messages []kafka.Message, err error) {
+	now := time.Now()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.stats.Batches++
+	if err != nil {
+		m.stats.Failed += int64(len(messages))
+		m.stats.LastError = err.Error()
+		m.stats.LastErrorAt = &now
+		return
+	}
+
+	m.stats.Delivered += int64(len(messages))
+	for _, msg := range messages {
+		m.stats.Bytes += int64(len(msg.Key) + len(msg.Value))
+		if !msg.Time.IsZero() {
+			m.latency += now.Sub(msg.Time)
+		}
+	}
+}
+
+func (m *producerMetrics) snapshot() ProducerStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats := m.stats
+	if stats.Delivered > 0 {
+		stats.AvgLatencyMs = float64(m.latency.Microseconds()) / float64(stats.Delivered) / 1000
+	}
+	return stats
+}
@@ -1,16 +1,30 @@
 package middleware
 
 import (
+	"context"
+	"errors"
+	"log"
 	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/lucas/go-rest-api-mongo/internal/models"
 	"github.com/lucas/go-rest-api-mongo/internal/services"
+	"github.com/lucas/go-rest-api-mongo/pkg/i18n"
 	"github.com/lucas/go-rest-api-mongo/pkg/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
-func AuthMiddleware(authService *services.AuthService) gin.HandlerFunc {
+// UserLookup busca o usuário do token; *services.UserService a implementa.
+type UserLookup interface {
+	GetByID(ctx context.Context, userID primitive.ObjectID) (*models.User, error)
+}
+
+// AuthMiddleware valida o JWT e carrega o usuário a cada requisição: tokens de
+// usuários apagados ou desativados deixam de valer na hora, e email, role e idioma
+// vêm do usuário gravado, não das claims emitidas no login.
+func AuthMiddleware(authService *services.AuthService, users UserLookup) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// 1. Pega o header Authorization
 		authHeader := c.GetHeader("Authorization")
@@ -46,15 +60,41 @@ func AuthMiddleware(authService *services.AuthService) gin.HandlerFunc {
 			return
 		}
 
-		// 6. Guarda user_id e email no context para uso nos handlers
-		if userID, ok := claims["user_id"].(string); ok {
-			c.Set("user_id", userID)
+		// 6. O usuário precisa existir e não pode estar desativado
+		userID, _ := claims["user_id"].(string)
+		objectID, err := primitive.ObjectIDFromHex(userID)
+		if err != nil {
+			utils.SendError(c, http.StatusUnauthorized, "unauthorized", "invalid token claims")
+			c.Abort()
+			return
 		}
-		if email, ok := claims["email"].(string); ok {
-			c.Set("email", email)
+		user, err := users.GetByID(c.Request.Context(), objectID)
+		switch {
+		case errors.Is(err, services.ErrUserNotFound):
+			utils.SendError(c, http.StatusUnauthorized, "unauthorized", "invalid token")
+			c.Abort()
+			return
+		case err != nil:
+			log.Printf("Error checking user %s: %v", userID, err)
+			utils.SendError(c, http.StatusInternalServerError, "internal_error", "failed to check user")
+			c.Abort()
+			return
+		case user.Disabled():
+			utils.SendError(c, http.StatusUnauthorized, "unauthorized", "user is disabled")
+			c.Abort()
+			return
+		}
+
+		// 7. Guarda user_id, email e role no context para uso nos handlers
+		c.Set("user_id", userID)
+		c.Set("email", user.Email)
+		c.Set("role", user.Role)
+		if i18n.IsSupported(user.Locale) {
+			c.Set("locale", user.Locale)
+			c.Header("Content-Language", user.Locale)
 		}
 
-		// 7. Continua para o próximo handler
+		// 8. Continua para o próximo handler
 		c.Next()
 	}
 }
@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lucas/go-rest-api-mongo/internal/config"
+	"github.com/lucas/go-rest-api-mongo/internal/dto"
+	"github.com/lucas/go-rest-api-mongo/internal/models"
+	"github.com/lucas/go-rest-api-mongo/internal/repositories"
+	"github.com/lucas/go-rest-api-mongo/internal/services"
+)
+
+func doAuthenticated(router *gin.Engine, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/profile", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestAuthMiddlewareChecksTheStoredUser(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := &config.Config{JWT: config.JWTConfig{SecretKey: "test-secret", Expiration: time.Hour}}
+	authService := services.NewAuthService(cfg)
+	userService := services.NewUserService(repositories.NewMemoryUserRepository(), authService, nil)
+	ctx := context.Background()
+
+	router := gin.New()
+	router.GET("/profile", AuthMiddleware(authService, userService), func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetString("user_id")+" "+c.GetString("role"))
+	})
+
+	user, err := userService.CreateWithRole(ctx, &dto.RegisterRequest{Name: "Ana", Email: "ana@example.com", Password: "secret123"}, models.RoleAdmin)
+	if err != nil {
+		t.Fatalf("CreateWithRole() error = %v", err)
+	}
+	token, _ := authService.GenerateToken(user)
+
+	if w := doAuthenticated(router, token); w.Code != http.StatusOK || w.Body.String() != user.ID.Hex()+" admin" {
+		t.Fatalf("active user = %d %s, want 200 with the user id and role", w.Code, w.Body)
+	}
+
+	// A role vem do usuário gravado, não da claim do token
+	userService.SetRole(ctx, user.ID, models.RoleUser)
+	if w := doAuthenticated(router, token); w.Code != http.StatusOK || w.Body.String() != user.ID.Hex()+" user" {
+		t.Errorf("demoted user = %d %s, want the new role", w.Code, w.Body)
+	}
+
+	// Um token emitido antes da desativação deixa de valer na hora
+	userService.SetDisabled(ctx, user.ID, true)
+	if w := doAuthenticated(router, token); w.Code != http.StatusUnauthorized || !strings.Contains(w.Body.String(), "user is disabled") {
+		t.Errorf("disabled user = %d %s, want 401", w.Code, w.Body)
+	}
+
+	userService.SetDisabled(ctx, user.ID, false)
+	if w := doAuthenticated(router, token); w.Code != http.StatusOK {
+		t.Errorf("enabled again = %d %s, want 200", w.Code, w.Body)
+	}
+
+	userService.Delete(ctx, user.ID)
+	if w := doAuthenticated(router, token); w.Code != http.StatusUnauthorized || !strings.Contains(w.Body.String(), "invalid token") {
+		t.Errorf("deleted user = %d %s, want 401", w.Code, w.Body)
+	}
+}
@@ -0,0 +1,154 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lucas/go-rest-api-mongo/internal/models"
+	"github.com/lucas/go-rest-api-mongo/pkg/utils"
+)
+
+const (
+	IdempotencyKeyHeader      = "Idempotency-Key"
+	IdempotencyReplayedHeader = "Idempotency-Replayed"
+
+	maxIdempotencyKeyLength = 255
+	maxIdempotentBodySize   = 1 << 20
+)
+
+type IdempotencyStore interface {
+	Reserve(ctx context.Context, record *models.IdempotencyRecord) (*models.IdempotencyRecord, error)
+	Complete(ctx context.Context, key string, status int, body []byte, contentType string) error
+	Release(ctx context.Context, key string) error
+}
+
+// Idempotency faz com que requisições repetidas com o mesmo Idempotency-Key recebam
+// a resposta da primeira execução. Requisições sem o header passam direto.
+func Idempotency(store IdempotencyStore, ttl time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			utils.SendError(c, http.StatusBadRequest, "bad_request", "idempotency key is too long")
+			c.Abort()
+			return
+		}
+
+		// 1. Lê o body para calcular a fingerprint e o devolve para o handler
+		body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxIdempotentBodySize+1))
+		if err != nil || len(body) > maxIdempotentBodySize {
+			utils.SendError(c, http.StatusBadRequest, "bad_request", "invalid request body")
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		// 2. A chave vale apenas para a rota e o cliente (API key ou IP) que a usaram, para
+		// que um cliente não receba a resposta guardada para outro com a mesma chave
+		now := time.Now()
+		record := &models.IdempotencyRecord{
+			Key:         idempotencyKey(c.Request.Method, c.FullPath(), KeyByAPIKey(c), key),
+			Fingerprint: fingerprint(c.Request.Method, c.FullPath(), body),
+			Status:      models.IdempotencyStatusProcessing,
+			CreatedAt:   now,
+			ExpiresAt:   now.Add(ttl),
+		}
+
+		// 3. Reserva a chave; se já existir, decide entre replay e conflito
+		existing, err := store.Reserve(c.Request.Context(), record)
+		if err != nil {
+			log.Printf("Error reserving idempotency key: %v", err)
+			utils.SendError(c, http.StatusInternalServerError, "internal_error", "failed to process idempotency key")
+			c.Abort()
+			return
+		}
+		if existing != nil {
+			replayIdempotent(c, existing, record.Fingerprint)
+			c.Abort()
+			return
+		}
+
+		// 4. Executa o handler guardando a resposta
+		writer := &bodyCaptureWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+
+		storeCtx := context.WithoutCancel(c.Request.Context())
+		finished := false
+		defer func() {
+			// Em caso de panic a reserva é liberada antes de o Recovery do gin responder
+			if !finished {
+				if err := store.Release(storeCtx, record.Key); err != nil {
+					log.Printf("Error releasing idempotency key: %v", err)
+				}
+			}
+		}()
+
+		c.Next()
+
+		// 5. Erros 5xx não são guardados, para que o cliente possa tentar de novo
+		status := writer.Status()
+		if status >= http.StatusInternalServerError {
+			return
+		}
+		if err := store.Complete(storeCtx, record.Key, status, writer.body.Bytes(), writer.Header().Get("Content-Type")); err != nil {
+			log.Printf("Error completing idempotency key: %v", err)
+			return
+		}
+		finished = true
+	}
+}
+
+func replayIdempotent(c *gin.Context, existing *models.IdempotencyRecord, fingerprint string) {
+	if existing.Fingerprint != fingerprint {
+		utils.SendError(c, http.StatusConflict, "conflict", "idempotency key was already used with a different request")
+		return
+	}
+
+	if existing.Status != models.IdempotencyStatusCompleted {
+		c.Header("Retry-After", "1")
+		utils.SendError(c, http.StatusConflict, "conflict", "a request with this idempotency key is still being processed")
+		return
+	}
+
+	c.Header(IdempotencyReplayedHeader, "true")
+	c.Data(existing.ResponseStatus, existing.ContentType, existing.ResponseBody)
+}
+
+func idempotencyKey(method, path, client, key string) string {
+	return fingerprint(method, path, []byte(client+"\x00"+key))
+}
+
+func fingerprint(method, path string, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(method))
+	hash.Write([]byte{0})
+	hash.Write([]byte(path))
+	hash.Write([]byte{0})
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+type bodyCaptureWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *bodyCaptureWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *bodyCaptureWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
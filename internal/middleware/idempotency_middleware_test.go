@@ -0,0 +1,166 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lucas/go-rest-api-mongo/internal/repositories"
+)
+
+// idempotentHandler conta as execuções; cada uma responde com o próprio número, ou
+// com status quando ele é diferente de zero. Com block, espera o canal antes de responder.
+type idempotentHandler struct {
+	calls   atomic.Int32
+	status  atomic.Int32
+	started chan struct{}
+	block   chan struct{}
+}
+
+func newTestIdempotencyRouter(h *idempotentHandler) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/users", Idempotency(repositories.NewMemoryIdempotencyRepository(), time.Hour), func(c *gin.Context) {
+		call := h.calls.Add(1)
+		if h.block != nil {
+			h.started <- struct{}{}
+			<-h.block
+		}
+		if status := int(h.status.Load()); status != 0 {
+			c.JSON(status, gin.H{"error": "unavailable"})
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{"call": call})
+	})
+	return router
+}
+
+func doIdempotent(router *gin.Engine, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if key != "" {
+		req.Header.Set(IdempotencyKeyHeader, key)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestIdempotencyReplaysTheFirstResponse(t *testing.T) {
+	h := &idempotentHandler{}
+	router := newTestIdempotencyRouter(h)
+
+	first := doIdempotent(router, "key-1", `{"name":"Ana"}`)
+	if first.Code != http.StatusCreated || first.Header().Get(IdempotencyReplayedHeader) != "" {
+		t.Fatalf("first request = %d %v, want 201 not replayed", first.Code, first.Header())
+	}
+
+	replay := doIdempotent(router, "key-1", `{"name":"Ana"}`)
+	if replay.Code != http.StatusCreated || replay.Body.String() != first.Body.String() || replay.Header().Get(IdempotencyReplayedHeader) != "true" {
+		t.Errorf("replay = %d %s %v, want the first response replayed", replay.Code, replay.Body, replay.Header())
+	}
+	if !strings.HasPrefix(replay.Header().Get("Content-Type"), "application/json") {
+		t.Errorf("replay Content-Type = %q, want the original one", replay.Header().Get("Content-Type"))
+	}
+
+	// Outra chave e requisições sem chave executam o handler
+	doIdempotent(router, "key-2", `{"name":"Ana"}`)
+	doIdempotent(router, "", `{"name":"Ana"}`)
+	if h.calls.Load() != 3 {
+		t.Errorf("handler ran %d times, want 3", h.calls.Load())
+	}
+}
+
+func TestIdempotencyKeysArePerClient(t *testing.T) {
+	h := &idempotentHandler{}
+	router := newTestIdempotencyRouter(h)
+	do := func(apiKey, ip string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{"name":"Ana"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(IdempotencyKeyHeader, "shared-key")
+		if apiKey != "" {
+			req.Header.Set(APIKeyHeader, apiKey)
+		}
+		req.RemoteAddr = ip + ":1234"
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// A mesma chave em clientes diferentes (API keys ou IPs) executa o handler para cada um
+	first := do("key-a", "10.0.0.1")
+	other := do("key-b", "10.0.0.1")
+	anonymous := do("", "10.0.0.2")
+	if other.Header().Get(IdempotencyReplayedHeader) != "" || anonymous.Header().Get(IdempotencyReplayedHeader) != "" || other.Body.String() == first.Body.String() {
+		t.Errorf("other clients got %s and %s, want their own responses instead of %s", other.Body, anonymous.Body, first.Body)
+	}
+	if h.calls.Load() != 3 {
+		t.Errorf("handler ran %d times, want 3", h.calls.Load())
+	}
+
+	// O mesmo cliente recebe a própria resposta, mesmo vindo de outro IP
+	if replay := do("key-a", "10.0.0.3"); replay.Header().Get(IdempotencyReplayedHeader) != "true" || replay.Body.String() != first.Body.String() {
+		t.Errorf("replay = %d %s, want %s replayed", replay.Code, replay.Body, first.Body)
+	}
+}
+
+func TestIdempotencyConflictOnADifferentBody(t *testing.T) {
+	h := &idempotentHandler{}
+	router := newTestIdempotencyRouter(h)
+
+	doIdempotent(router, "key-1", `{"name":"Ana"}`)
+	w := doIdempotent(router, "key-1", `{"name":"Bia"}`)
+	if w.Code != http.StatusConflict || !strings.Contains(w.Body.String(), "different request") {
+		t.Errorf("same key with another body = %d %s, want 409", w.Code, w.Body)
+	}
+	if h.calls.Load() != 1 {
+		t.Errorf("handler ran %d times, want only the first request", h.calls.Load())
+	}
+
+	if w := doIdempotent(router, strings.Repeat("k", 256), `{}`); w.Code != http.StatusBadRequest {
+		t.Errorf("key over 255 characters = %d, want 400", w.Code)
+	}
+}
+
+func TestIdempotencyConflictWhileInFlight(t *testing.T) {
+	h := &idempotentHandler{started: make(chan struct{}), block: make(chan struct{})}
+	router := newTestIdempotencyRouter(h)
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- doIdempotent(router, "key-1", `{"name":"Ana"}`) }()
+	<-h.started
+
+	w := doIdempotent(router, "key-1", `{"name":"Ana"}`)
+	if w.Code != http.StatusConflict || w.Header().Get("Retry-After") != "1" || !strings.Contains(w.Body.String(), "still being processed") {
+		t.Errorf("request while the first is running = %d %s %v, want 409 with Retry-After", w.Code, w.Body, w.Header())
+	}
+
+	close(h.block)
+	if first := <-done; first.Code != http.StatusCreated {
+		t.Fatalf("first request = %d %s, want 201", first.Code, first.Body)
+	}
+	if w := doIdempotent(router, "key-1", `{"name":"Ana"}`); w.Header().Get(IdempotencyReplayedHeader) != "true" {
+		t.Errorf("request after the first finished = %d %v, want a replay", w.Code, w.Header())
+	}
+}
+
+func TestIdempotencyReleasesTheKeyAfterA5xx(t *testing.T) {
+	h := &idempotentHandler{}
+	h.status.Store(http.StatusServiceUnavailable)
+	router := newTestIdempotencyRouter(h)
+
+	if w := doIdempotent(router, "key-1", `{"name":"Ana"}`); w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("first request = %d, want 503", w.Code)
+	}
+
+	// A falha não é guardada: a nova tentativa executa o handler
+	h.status.Store(0)
+	w := doIdempotent(router, "key-1", `{"name":"Ana"}`)
+	if w.Code != http.StatusCreated || w.Header().Get(IdempotencyReplayedHeader) != "" || w.Body.String() != `{"call":2}` {
+		t.Errorf("retry after a 5xx = %d %s %v, want the handler run again", w.Code, w.Body, w.Header())
+	}
+}
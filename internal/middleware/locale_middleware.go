@@ -0,0 +1,17 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/lucas/go-rest-api-mongo/pkg/i18n"
+)
+
+// Locale resolve o idioma da resposta a partir do Accept-Language e guarda no context
+// (chave "locale"). AuthMiddleware sobrescreve com a preferência do usuário, se houver.
+func Locale(translator *i18n.Translator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		locale := translator.Resolve("", c.GetHeader("Accept-Language"))
+		c.Set("locale", locale)
+		c.Header("Content-Language", locale)
+		c.Next()
+	}
+}
@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const memoryStoreSweepInterval = time.Minute
+
+type rateLimitWindow struct {
+	start    time.Time
+	window   time.Duration
+	current  int64
+	previous int64
+}
+
+// MemoryRateLimitStore mantém os contadores em memória. Serve para uma única réplica;
+// com várias réplicas use um store compartilhado.
+type MemoryRateLimitStore struct {
+	mu        sync.Mutex
+	windows   map[string]*rateLimitWindow
+	lastSweep time.Time
+}
+
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{
+		windows: make(map[string]*rateLimitWindow),
+	}
+}
+
+func (s *MemoryRateLimitStore) Increment(ctx context.Context, key string, window time.Duration, now time.Time) (int64, int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(now)
+
+	start := now.Truncate(window)
+	entry, ok := s.windows[key]
+	switch {
+	case !ok:
+		entry = &rateLimitWindow{start: start, window: window}
+		s.windows[key] = entry
+	case entry.start.Equal(start):
+		// mesma janela
+	case entry.start.Add(window).Equal(start):
+		entry.previous, entry.current, entry.start = entry.current, 0, start
+	default:
+		entry.previous, entry.current, entry.start = 0, 0, start
+	}
+
+	entry.current++
+	return entry.current, entry.previous, nil
+}
+
+// sweep remove entradas que já não influenciam nenhuma janela.
+func (s *MemoryRateLimitStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < memoryStoreSweepInterval {
+		return
+	}
+	s.lastSweep = now
+
+	for key, entry := range s.windows {
+		if now.Sub(entry.start) >= 2*entry.window {
+			delete(s.windows, key)
+		}
+	}
+}
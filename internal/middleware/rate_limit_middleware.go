@@ -0,0 +1,139 @@
+package middleware
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lucas/go-rest-api-mongo/pkg/utils"
+)
+
+const APIKeyHeader = "X-API-Key"
+
+// rateLimitNow é o relógio do RateLimit, trocado nos testes.
+var rateLimitNow = time.Now
+
+// RateLimitStore guarda os contadores das janelas fixas usadas pelo algoritmo de
+// janela deslizante. Increment soma 1 à janela que contém now e retorna o contador
+// dessa janela e o da janela imediatamente anterior.
+type RateLimitStore interface {
+	Increment(ctx context.Context, key string, window time.Duration, now time.Time) (current, previous int64, err error)
+}
+
+// KeyFunc identifica o cliente de uma requisição.
+type KeyFunc func(c *gin.Context) string
+
+type RateLimitPolicy struct {
+	Name   string
+	Limit  int
+	Window time.Duration
+	KeyBy  KeyFunc
+}
+
+// RateLimiters agrupa as policies por grupo de rotas. As policies podem ser trocadas
+// com o servidor rodando, sem registrar as rotas de novo.
+type RateLimiters struct {
+	handlers atomic.Pointer[map[string]gin.HandlerFunc]
+}
+
+func NewRateLimiters(handlers map[string]gin.HandlerFunc) *RateLimiters {
+	r := &RateLimiters{}
+	r.Replace(handlers)
+	return r
+}
+
+// Replace troca os middlewares de todos os grupos; grupos ausentes ficam sem limite.
+func (r *RateLimiters) Replace(handlers map[string]gin.HandlerFunc) {
+	r.handlers.Store(&handlers)
+}
+
+// For retorna o middleware do grupo, que aplica a policy atual do grupo ou nenhuma,
+// se o grupo não tiver limite.
+func (r *RateLimiters) For(group string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if handler, ok := (*r.handlers.Load())[group]; ok {
+			handler(c)
+			return
+		}
+		c.Next()
+	}
+}
+
+func KeyByIP(c *gin.Context) string {
+	return "ip:" + c.ClientIP()
+}
+
+// KeyByUserID deve ser usado depois de AuthMiddleware; sem usuário, cai para o IP.
+func KeyByUserID(c *gin.Context) string {
+	if userID := c.GetString("user_id"); userID != "" {
+		return "user:" + userID
+	}
+	return KeyByIP(c)
+}
+
+// KeyByAPIKey usa um hash do header X-API-Key, para não gravar a chave no store.
+func KeyByAPIKey(c *gin.Context) string {
+	if apiKey := c.GetHeader(APIKeyHeader); apiKey != "" {
+		sum := sha256.Sum256([]byte(apiKey))
+		return "key:" + hex.EncodeToString(sum[:])
+	}
+	return KeyByIP(c)
+}
+
+// KeyFuncByName converte o nome usado na configuração ("ip", "user" ou "api_key").
+func KeyFuncByName(name string) (KeyFunc, error) {
+	switch name {
+	case "", "ip":
+		return KeyByIP, nil
+	case "user":
+		return KeyByUserID, nil
+	case "api_key":
+		return KeyByAPIKey, nil
+	default:
+		return nil, fmt.Errorf("unknown rate limit key %q", name)
+	}
+}
+
+// RateLimit aplica a policy usando uma janela deslizante aproximada: o contador da
+// janela anterior entra com peso proporcional ao tempo que ainda se sobrepõe à janela
+// atual. Falhas no store não bloqueiam a requisição.
+func RateLimit(policy RateLimitPolicy, store RateLimitStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		now := rateLimitNow()
+		key := policy.Name + ":" + policy.KeyBy(c)
+
+		current, previous, err := store.Increment(c.Request.Context(), key, policy.Window, now)
+		if err != nil {
+			log.Printf("Error checking rate limit for %s: %v", policy.Name, err)
+			c.Next()
+			return
+		}
+
+		elapsed := now.Sub(now.Truncate(policy.Window))
+		weight := 1 - float64(elapsed)/float64(policy.Window)
+		used := int(math.Ceil(float64(previous)*weight)) + int(current)
+		reset := int(math.Ceil((policy.Window - elapsed).Seconds()))
+
+		c.Header("RateLimit-Policy", fmt.Sprintf("%d;w=%d", policy.Limit, int(policy.Window.Seconds())))
+		c.Header("RateLimit-Limit", strconv.Itoa(policy.Limit))
+		c.Header("RateLimit-Remaining", strconv.Itoa(max(policy.Limit-used, 0)))
+		c.Header("RateLimit-Reset", strconv.Itoa(reset))
+
+		if used > policy.Limit {
+			c.Header("Retry-After", strconv.Itoa(reset))
+			utils.SendError(c, http.StatusTooManyRequests, "too_many_requests", "rate limit exceeded")
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
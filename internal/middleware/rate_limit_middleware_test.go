@@ -0,0 +1,98 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setRateLimitClock fixa o relógio do RateLimit em *now durante o teste.
+func setRateLimitClock(t *testing.T, now *time.Time) {
+	t.Helper()
+	rateLimitNow = func() time.Time { return *now }
+	t.Cleanup(func() { rateLimitNow = time.Now })
+}
+
+func newTestRateLimitRouter(store RateLimitStore) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	policy := RateLimitPolicy{Name: "login", Limit: 3, Window: time.Minute, KeyBy: KeyByIP}
+	router.POST("/login", RateLimit(policy, store), func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+	return router
+}
+
+func doFrom(router *gin.Engine, ip string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/login", nil)
+	req.RemoteAddr = ip + ":40000"
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestRateLimitSlidingWindow(t *testing.T) {
+	now := time.Date(2026, time.March, 10, 12, 0, 0, 0, time.UTC)
+	setRateLimitClock(t, &now)
+	router := newTestRateLimitRouter(NewMemoryRateLimitStore())
+
+	for i, remaining := range []string{"2", "1", "0"} {
+		w := doFrom(router, "192.0.2.1")
+		if w.Code != http.StatusNoContent || w.Header().Get("RateLimit-Remaining") != remaining {
+			t.Fatalf("request %d = %d remaining %q, want 204 with %s remaining", i+1, w.Code, w.Header().Get("RateLimit-Remaining"), remaining)
+		}
+	}
+
+	w := doFrom(router, "192.0.2.1")
+	if w.Code != http.StatusTooManyRequests || !strings.Contains(w.Body.String(), "too_many_requests") {
+		t.Fatalf("request over the limit = %d %s, want 429", w.Code, w.Body)
+	}
+	headers := map[string]string{"RateLimit-Policy": "3;w=60", "RateLimit-Limit": "3", "RateLimit-Remaining": "0", "RateLimit-Reset": "60", "Retry-After": "60"}
+	for name, want := range headers {
+		if got := w.Header().Get(name); got != want {
+			t.Errorf("429 header %s = %q, want %q", name, got, want)
+		}
+	}
+
+	// Cada cliente tem o seu contador
+	if w := doFrom(router, "192.0.2.2"); w.Code != http.StatusNoContent || w.Header().Get("RateLimit-Remaining") != "2" {
+		t.Errorf("request from another IP = %d remaining %q, want 204 with 2 remaining", w.Code, w.Header().Get("RateLimit-Remaining"))
+	}
+
+	// Na metade da janela seguinte, as 4 requisições da anterior pesam 2
+	now = now.Add(90 * time.Second)
+	if w := doFrom(router, "192.0.2.1"); w.Code != http.StatusNoContent || w.Header().Get("RateLimit-Remaining") != "0" || w.Header().Get("RateLimit-Reset") != "30" {
+		t.Fatalf("request after the rollover = %d remaining %q reset %q, want 204 with 0 remaining and reset 30",
+			w.Code, w.Header().Get("RateLimit-Remaining"), w.Header().Get("RateLimit-Reset"))
+	}
+	if w := doFrom(router, "192.0.2.1"); w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "30" {
+		t.Fatalf("second request after the rollover = %d Retry-After %q, want 429 retrying in 30s", w.Code, w.Header().Get("Retry-After"))
+	}
+
+	// Duas janelas depois, o que ficou para trás não conta mais
+	now = now.Add(2 * time.Minute)
+	if w := doFrom(router, "192.0.2.1"); w.Code != http.StatusNoContent || w.Header().Get("RateLimit-Remaining") != "2" {
+		t.Errorf("request two windows later = %d remaining %q, want 204 with 2 remaining", w.Code, w.Header().Get("RateLimit-Remaining"))
+	}
+}
+
+type failingRateLimitStore struct{}
+
+func (failingRateLimitStore) Increment(ctx context.Context, key string, window time.Duration, now time.Time) (int64, int64, error) {
+	return 0, 0, errors.New("redis unavailable")
+}
+
+func TestRateLimitAllowsRequestsWhenTheStoreFails(t *testing.T) {
+	router := newTestRateLimitRouter(failingRateLimitStore{})
+	for range 5 {
+		if w := doFrom(router, "192.0.2.1"); w.Code != http.StatusNoContent || w.Header().Get("RateLimit-Limit") != "" {
+			t.Fatalf("request with a failing store = %d %v, want 204 without rate limit headers", w.Code, w.Header())
+		}
+	}
+}
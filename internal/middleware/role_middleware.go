@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lucas/go-rest-api-mongo/pkg/utils"
+)
+
+// RequireRole deve ser usado depois de AuthMiddleware, que guarda a role do token no context.
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := c.GetString("role")
+		for _, allowed := range roles {
+			if role == allowed {
+				c.Next()
+				return
+			}
+		}
+
+		utils.SendError(c, http.StatusForbidden, "forbidden", "insufficient permissions")
+		c.Abort()
+	}
+}
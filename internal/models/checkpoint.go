@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// Checkpoint guarda até onde um processo longo (backfill, change stream) chegou,
+// para que ele continue do mesmo ponto depois de um restart.
+type Checkpoint struct {
+	ID        string    `bson:"_id" json:"id"`
+	RunID     string    `bson:"run_id,omitempty" json:"run_id,omitempty"`
+	Position  string    `bson:"position" json:"position"`                 // último item processado (ID, resume token...)
+	Filter    string    `bson:"filter,omitempty" json:"filter,omitempty"` // filtros com que a execução começou
+	Processed int64     `bson:"processed" json:"processed"`
+	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"`
+}
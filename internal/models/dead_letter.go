@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	DeadLetterKindRegistration = "registration"
+	DeadLetterKindEvent        = "event"
+	DeadLetterKindJob          = "job" // um job de outro tipo, com o payload em Payload
+	// DeadLetterKindUnencoded é um evento que não pôde ser codificado, guardado em JSON
+	// no Payload com o tipo em EventType; o replay o codifica de novo.
+	DeadLetterKindUnencoded = "unencoded_event"
+)
+
+// DeadLetter guarda um job do worker pool que falhou de forma permanente
+// ou esgotou as tentativas, para inspeção e reprocessamento manual.
+type DeadLetter struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Kind          string             `bson:"kind" json:"kind"`
+	JobType       string             `bson:"job_type,omitempty" json:"job_type,omitempty"`
+	EventType     string             `bson:"event_type,omitempty" json:"event_type,omitempty"`
+	User          *User              `bson:"user,omitempty" json:"user,omitempty"`
+	Topic         string             `bson:"topic,omitempty" json:"topic,omitempty"`
+	Key           string             `bson:"key,omitempty" json:"key,omitempty"`
+	Headers       map[string]string  `bson:"headers,omitempty" json:"headers,omitempty"`
+	Payload       string             `bson:"payload,omitempty" json:"payload,omitempty"`
+	Error         string             `bson:"error" json:"error"`
+	Attempts      int                `bson:"attempts" json:"attempts"`
+	CreatedAt     time.Time          `bson:"created_at" json:"created_at"`
+	LastAttemptAt time.Time          `bson:"last_attempt_at" json:"last_attempt_at"`
+}
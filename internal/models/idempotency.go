@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+const (
+	IdempotencyStatusProcessing = "processing"
+	IdempotencyStatusCompleted  = "completed"
+)
+
+// IdempotencyRecord guarda a primeira execução de uma requisição com Idempotency-Key
+// para que retries do cliente recebam a mesma resposta em vez de executar de novo.
+type IdempotencyRecord struct {
+	Key            string    `bson:"_id"`
+	Fingerprint    string    `bson:"fingerprint"`
+	Status         string    `bson:"status"`
+	ResponseStatus int       `bson:"response_status,omitempty"`
+	ResponseBody   []byte    `bson:"response_body,omitempty"`
+	ContentType    string    `bson:"content_type,omitempty"`
+	CreatedAt      time.Time `bson:"created_at"`
+	ExpiresAt      time.Time `bson:"expires_at"`
+}
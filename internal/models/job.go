@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Lanes de prioridade de uma fila de jobs (ver services.Lane).
+const (
+	JobLaneHigh   = "high"
+	JobLaneNormal = "normal" // a padrão, e a dos jobs enfileirados antes das lanes
+	JobLaneLow    = "low"
+)
+
+// Job é um trabalho na fila entre a API e os workers. Payload é o documento BSON do
+// tipo do job (ver services.Queue); o registro do /register-fast, por exemplo, leva o
+// usuário já preparado, com ID gerado e senha em hash, para que a fila nunca guarde
+// senhas em texto e processar o mesmo job duas vezes não duplique o usuário.
+type Job struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Type        string             `bson:"type" json:"type"`
+	Lane        string             `bson:"lane" json:"lane"`
+	FairKey     string             `bson:"fair_key,omitempty" json:"fair_key,omitempty"` // tenant ou chave de API
+	FairRank    float64            `bson:"fair_rank" json:"fair_rank"`                   // ordem dentro da lane; ver JobStore
+	Payload     bson.Raw           `bson:"payload" json:"payload"`
+	EnqueuedAt  time.Time          `bson:"enqueued_at" json:"enqueued_at"`
+	Attempts    int                `bson:"attempts" json:"attempts"` // entregas a um worker
+	LockedBy    string             `bson:"locked_by,omitempty" json:"locked_by,omitempty"`
+	LockedUntil time.Time          `bson:"locked_until" json:"locked_until"`
+}
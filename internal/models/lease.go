@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// Lease é o lock distribuído de um processo que deve rodar em uma única réplica.
+// Quem segura o lease precisa renová-lo antes de ExpiresAt.
+type Lease struct {
+	Name      string    `bson:"_id" json:"name"`
+	Owner     string    `bson:"owner" json:"owner"`
+	ExpiresAt time.Time `bson:"expires_at" json:"expires_at"`
+	RenewedAt time.Time `bson:"renewed_at" json:"renewed_at"`
+}
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Origens e estados de uma ScheduleRun.
+const (
+	ScheduleTriggerCron   = "schedule" // disparada pela expressão cron
+	ScheduleTriggerManual = "manual"   // disparada pelo endpoint de admin
+
+	ScheduleRunRunning   = "running"
+	ScheduleRunSucceeded = "succeeded"
+	ScheduleRunFailed    = "failed"
+)
+
+// ScheduleState é o estado de um job agendado compartilhado entre as réplicas.
+// LastScheduledAt é o último horário do cron já executado, para que as réplicas que
+// chegam depois (pelo jitter) não executem o mesmo horário de novo.
+type ScheduleState struct {
+	Name            string    `bson:"_id" json:"name"`
+	Paused          bool      `bson:"paused" json:"paused"`
+	LastScheduledAt time.Time `bson:"last_scheduled_at" json:"last_scheduled_at"`
+	UpdatedAt       time.Time `bson:"updated_at" json:"updated_at"`
+}
+
+// ScheduleRun é uma execução de um job agendado, guardada até ExpiresAt.
+type ScheduleRun struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Job         string             `bson:"job" json:"job"`
+	Trigger     string             `bson:"trigger" json:"trigger"`
+	Owner       string             `bson:"owner" json:"owner"`                                   // réplica que executou
+	ScheduledAt *time.Time         `bson:"scheduled_at,omitempty" json:"scheduled_at,omitempty"` // horário do cron; nil nas manuais
+	StartedAt   time.Time          `bson:"started_at" json:"started_at"`
+	FinishedAt  *time.Time         `bson:"finished_at,omitempty" json:"finished_at,omitempty"`
+	Status      string             `bson:"status" json:"status"`
+	Result      string             `bson:"result,omitempty" json:"result,omitempty"`
+	Error       string             `bson:"error,omitempty" json:"error,omitempty"`
+	ExpiresAt   time.Time          `bson:"expires_at" json:"-"`
+}
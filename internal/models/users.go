@@ -6,11 +6,27 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
 type User struct {
 	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
 	Name      string             `bson:"name" json:"name"`
 	Email     string             `bson:"email" json:"email"`
 	Password  string             `bson:"password" json:"-"`
+	Role      string             `bson:"role,omitempty" json:"role,omitempty"`
+	Locale    string             `bson:"locale,omitempty" json:"locale,omitempty"`
 	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
 	UpdatedAt time.Time          `bson:"updated_at" json:"updated_at"`
+	// DisabledAt marca um usuário desativado, que não consegue mais fazer login.
+	DisabledAt *time.Time `bson:"disabled_at,omitempty" json:"disabled_at,omitempty"`
+}
+
+// Roles são os valores aceitos em User.Role.
+var Roles = []string{RoleUser, RoleAdmin}
+
+func (u *User) Disabled() bool {
+	return u.DisabledAt != nil
 }
@@ -0,0 +1,218 @@
+package openapi
+
+import (
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const Version = "3.1.0"
+
+// Document é o subconjunto do OpenAPI 3.1 usado pela API.
+type Document struct {
+	OpenAPI    string               `json:"openapi"`
+	Info       Info                 `json:"info"`
+	Paths      map[string]PathItem  `json:"paths"`
+	Components Components           `json:"components"`
+	Tags       []Tag                `json:"tags,omitempty"`
+	schemas    *SchemaRegistry      `json:"-"`
+	operations map[string]Operation `json:"-"`
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+type Tag struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// PathItem mapeia o método HTTP em minúsculas para a operação.
+type PathItem map[string]*Operation
+
+type Operation struct {
+	OperationID string                `json:"operationId"`
+	Summary     string                `json:"summary"`
+	Tags        []string              `json:"tags,omitempty"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]*Response  `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+}
+
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Required    bool    `json:"required,omitempty"`
+	Description string  `json:"description,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+}
+
+// Route descreve uma rota para o documento. Os tipos de body e resposta são structs
+// (ou ponteiros/slices de structs) das quais o schema é gerado por reflection.
+type Route struct {
+	Method      string
+	Path        string // no formato do gin, ex.: /dead-letters/:id
+	Summary     string
+	Tag         string
+	Secured     bool
+	Parameters  []Parameter // de query e de header; os do path vêm de Path
+	Request     interface{}
+	Responses   map[int]interface{}
+	ContentType string // content type da resposta de sucesso; vazio = application/json
+}
+
+func NewDocument(title, version, description string) *Document {
+	schemas := NewSchemaRegistry()
+	return &Document{
+		OpenAPI: Version,
+		Info:    Info{Title: title, Version: version, Description: description},
+		Paths:   make(map[string]PathItem),
+		Components: Components{
+			Schemas: schemas.Schemas,
+			SecuritySchemes: map[string]*SecurityScheme{
+				"bearerAuth": {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+			},
+		},
+		schemas:    schemas,
+		operations: make(map[string]Operation),
+	}
+}
+
+// Add registra a rota no documento.
+func (d *Document) Add(route Route) {
+	path, params := convertPath(route.Path)
+
+	op := &Operation{
+		OperationID: operationID(route.Method, path),
+		Summary:     route.Summary,
+		Parameters:  append(params, route.Parameters...),
+		Responses:   make(map[string]*Response),
+	}
+	if route.Tag != "" {
+		op.Tags = []string{route.Tag}
+	}
+	if route.Secured {
+		op.Security = []map[string][]string{{"bearerAuth": {}}}
+	}
+
+	if route.Request != nil {
+		op.RequestBody = &RequestBody{
+			Required: true,
+			Content: map[string]MediaType{
+				"application/json": {Schema: d.schemas.For(reflect.TypeOf(route.Request))},
+			},
+		}
+	}
+
+	for status, body := range route.Responses {
+		response := &Response{Description: http.StatusText(status)}
+		if body != nil {
+			contentType := "application/json"
+			if status >= http.StatusBadRequest {
+				contentType = "application/problem+json"
+			} else if route.ContentType != "" {
+				contentType = route.ContentType
+			}
+			response.Content = map[string]MediaType{
+				contentType: {Schema: d.schemas.For(reflect.TypeOf(body))},
+			}
+		}
+		op.Responses[strconv.Itoa(status)] = response
+	}
+
+	item, ok := d.Paths[path]
+	if !ok {
+		item = make(PathItem)
+		d.Paths[path] = item
+	}
+	item[strings.ToLower(route.Method)] = op
+	d.operations[route.Method+" "+route.Path] = *op
+}
+
+// Operation retorna a operação registrada para o método e o path no formato do gin.
+func (d *Document) Operation(method, ginPath string) (*Operation, bool) {
+	op, ok := d.operations[method+" "+ginPath]
+	if !ok {
+		return nil, false
+	}
+	return &op, true
+}
+
+// Routes lista "MÉTODO path" (no formato do gin) de todas as operações, em ordem.
+func (d *Document) Routes() []string {
+	routes := make([]string, 0, len(d.operations))
+	for key := range d.operations {
+		routes = append(routes, key)
+	}
+	sort.Strings(routes)
+	return routes
+}
+
+// Resolve segue um $ref para components/schemas.
+func (d *Document) Resolve(schema *Schema) *Schema {
+	for schema != nil && schema.Ref != "" {
+		schema = d.Components.Schemas[strings.TrimPrefix(schema.Ref, componentsPrefix)]
+	}
+	return schema
+}
+
+// convertPath troca os parâmetros :id do gin por {id} e gera os parameters do path.
+func convertPath(ginPath string) (string, []Parameter) {
+	segments := strings.Split(ginPath, "/")
+	var params []Parameter
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") {
+			name := segment[1:]
+			segments[i] = "{" + name + "}"
+			params = append(params, Parameter{
+				Name:     name,
+				In:       "path",
+				Required: true,
+				Schema:   &Schema{Type: "string"},
+			})
+		}
+	}
+	return strings.Join(segments, "/"), params
+}
+
+func operationID(method, path string) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(method))
+	for _, part := range strings.FieldsFunc(path, func(r rune) bool {
+		return r == '/' || r == '-' || r == '{' || r == '}' || r == '.' || r == '_'
+	}) {
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return b.String()
+}
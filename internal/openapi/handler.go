@@ -0,0 +1,42 @@
+package openapi
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"html/template"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+//go:embed docs.html
+var docsPage string
+
+var docsTemplate = template.Must(template.New("docs").Parse(docsPage))
+
+// Handler serve o documento em JSON; o documento é serializado uma única vez.
+func Handler(doc *Document) gin.HandlerFunc {
+	body, err := json.MarshalIndent(doc, "", "  ")
+	return func(c *gin.Context) {
+		if err != nil {
+			c.AbortWithStatus(http.StatusInternalServerError)
+			return
+		}
+		c.Data(http.StatusOK, "application/json", body)
+	}
+}
+
+// DocsHandler serve a página do Redoc que lê /openapi.json. script é a URL do bundle,
+// numa versão exata; com integrity (hash SRI), o navegador recusa um bundle alterado.
+func DocsHandler(script, integrity string) gin.HandlerFunc {
+	var body bytes.Buffer
+	err := docsTemplate.Execute(&body, struct{ Script, Integrity string }{script, integrity})
+	return func(c *gin.Context) {
+		if err != nil {
+			c.AbortWithStatus(http.StatusInternalServerError)
+			return
+		}
+		c.Data(http.StatusOK, "text/html; charset=utf-8", body.Bytes())
+	}
+}
@@ -0,0 +1,33 @@
+package openapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestDocsHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name      string
+		integrity string
+		want      string
+	}{
+		{"without integrity", "", `<script src="https://cdn.example.com/redoc@2.5.0/redoc.js"></script>`},
+		{"with integrity", "sha384-abc+/=", `<script src="https://cdn.example.com/redoc@2.5.0/redoc.js" integrity="sha384-abc&#43;/=" crossorigin="anonymous"></script>`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.GET("/docs", DocsHandler("https://cdn.example.com/redoc@2.5.0/redoc.js", tt.integrity))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/docs", nil))
+			if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), tt.want) {
+				t.Errorf("GET /docs = %d %s, want the script %s", w.Code, w.Body, tt.want)
+			}
+		})
+	}
+}
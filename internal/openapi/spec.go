@@ -0,0 +1,259 @@
+package openapi
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/lucas/go-rest-api-mongo/internal/dto"
+	"github.com/lucas/go-rest-api-mongo/internal/events"
+	"github.com/lucas/go-rest-api-mongo/internal/messaging"
+	"github.com/lucas/go-rest-api-mongo/internal/models"
+	"github.com/lucas/go-rest-api-mongo/internal/services"
+	"github.com/lucas/go-rest-api-mongo/pkg/utils"
+)
+
+var (
+	specOnce sync.Once
+	spec     *Document
+)
+
+// Spec retorna o documento da API. Toda rota registrada em setupRoutes precisa de uma
+// entrada aqui; o teste de rotas em cmd falha se alguma estiver faltando.
+func Spec() *Document {
+	specOnce.Do(func() {
+		spec = buildSpec()
+	})
+	return spec
+}
+
+func buildSpec() *Document {
+	doc := NewDocument("Go REST API Mongo", "1.0.0", "API de usuários com MongoDB, Kafka e worker pool.")
+	doc.Tags = []Tag{
+		{Name: "auth", Description: "Registro, login e perfil"},
+		{Name: "admin", Description: "Operação (requer role admin)"},
+		{Name: "meta", Description: "Saúde e documentação"},
+	}
+
+	problem := utils.Problem{}
+
+	doc.Add(Route{
+		Method: http.MethodGet, Path: "/health", Summary: "Health check da role do processo", Tag: "meta",
+		Responses: map[int]interface{}{
+			http.StatusOK:                 dto.HealthResponse{},
+			http.StatusServiceUnavailable: dto.HealthResponse{},
+		},
+	})
+	doc.Add(Route{
+		Method: http.MethodGet, Path: "/metrics", Summary: "Métricas da role do processo", Tag: "meta",
+		Responses: map[int]interface{}{http.StatusOK: dto.MetricsResponse{}},
+	})
+	doc.Add(Route{
+		Method: http.MethodGet, Path: "/openapi.json", Summary: "Este documento OpenAPI", Tag: "meta",
+		Responses: map[int]interface{}{http.StatusOK: map[string]interface{}{}},
+	})
+	doc.Add(Route{
+		Method: http.MethodGet, Path: "/docs", Summary: "Documentação interativa (HTML)", Tag: "meta",
+		Responses: map[int]interface{}{http.StatusOK: ""}, ContentType: "text/html",
+	})
+
+	doc.Add(Route{
+		Method: http.MethodGet, Path: "/schemas/events", Summary: "Lista os JSON Schemas dos eventos publicados", Tag: "meta",
+		Responses: map[int]interface{}{http.StatusOK: []events.SchemaInfo{}},
+	})
+	doc.Add(Route{
+		Method: http.MethodGet, Path: "/schemas/events/:name", Summary: "Schema do data de um evento (JSON Schema, Avro ou Protobuf)", Tag: "meta",
+		Parameters: []Parameter{
+			{Name: "format", In: "query", Schema: &Schema{Type: "string", Enum: []interface{}{string(events.FormatJSON), string(events.FormatAvro), string(events.FormatProtobuf)}}},
+		},
+		Responses: map[int]interface{}{
+			http.StatusOK:       map[string]interface{}{},
+			http.StatusNotFound: problem,
+		},
+		ContentType: "application/schema+json",
+	})
+
+	doc.Add(Route{
+		Method: http.MethodPost, Path: "/api/v1/register", Summary: "Registra um usuário de forma síncrona", Tag: "auth",
+		Request: dto.RegisterRequest{},
+		Responses: map[int]interface{}{
+			http.StatusCreated:             dto.RegisterResponse{},
+			http.StatusBadRequest:          problem,
+			http.StatusConflict:            problem,
+			http.StatusTooManyRequests:     problem,
+			http.StatusInternalServerError: problem,
+		},
+	})
+	doc.Add(Route{
+		Method: http.MethodPost, Path: "/api/v1/login", Summary: "Autentica e retorna um JWT", Tag: "auth",
+		Request: dto.LoginRequest{},
+		Responses: map[int]interface{}{
+			http.StatusOK:                  dto.LoginResponse{},
+			http.StatusBadRequest:          problem,
+			http.StatusUnauthorized:        problem,
+			http.StatusForbidden:           problem,
+			http.StatusTooManyRequests:     problem,
+			http.StatusInternalServerError: problem,
+		},
+	})
+	doc.Add(Route{
+		Method: http.MethodPost, Path: "/api/v1/register-fast", Summary: "Enfileira um registro para os workers", Tag: "auth",
+		Parameters: []Parameter{
+			{Name: "X-Priority", In: "header", Description: "high só vale com uma chave de REGISTRATION_PRIORITY_KEYS em X-API-Key; sem ela, vira normal", Schema: &Schema{Type: "string", Enum: []interface{}{models.JobLaneHigh, models.JobLaneNormal, models.JobLaneLow}}},
+		},
+		Request: dto.RegisterRequest{},
+		Responses: map[int]interface{}{
+			http.StatusAccepted:            dto.MessageResponse{},
+			http.StatusBadRequest:          problem,
+			http.StatusConflict:            problem,
+			http.StatusTooManyRequests:     problem,
+			http.StatusInternalServerError: problem,
+			http.StatusServiceUnavailable:  problem,
+		},
+	})
+	doc.Add(Route{
+		Method: http.MethodGet, Path: "/api/v1/profile", Summary: "Perfil do usuário autenticado", Tag: "auth", Secured: true,
+		Responses: map[int]interface{}{
+			http.StatusOK:                  dto.UserResponse{},
+			http.StatusUnauthorized:        problem,
+			http.StatusNotFound:            problem,
+			http.StatusInternalServerError: problem,
+		},
+	})
+	doc.Add(Route{
+		Method: http.MethodPatch, Path: "/api/v1/profile", Summary: "Atualiza nome e/ou email do usuário autenticado", Tag: "auth", Secured: true,
+		Request: dto.UpdateProfileRequest{},
+		Responses: map[int]interface{}{
+			http.StatusOK:                  dto.UserResponse{},
+			http.StatusBadRequest:          problem,
+			http.StatusUnauthorized:        problem,
+			http.StatusNotFound:            problem,
+			http.StatusConflict:            problem,
+			http.StatusInternalServerError: problem,
+		},
+	})
+	doc.Add(Route{
+		Method: http.MethodPut, Path: "/api/v1/profile/password", Summary: "Troca a senha do usuário autenticado", Tag: "auth", Secured: true,
+		Request: dto.ChangePasswordRequest{},
+		Responses: map[int]interface{}{
+			http.StatusOK:                  dto.MessageResponse{},
+			http.StatusBadRequest:          problem,
+			http.StatusUnauthorized:        problem,
+			http.StatusForbidden:           problem,
+			http.StatusNotFound:            problem,
+			http.StatusInternalServerError: problem,
+		},
+	})
+	doc.Add(Route{
+		Method: http.MethodDelete, Path: "/api/v1/profile", Summary: "Exclui a conta do usuário autenticado", Tag: "auth", Secured: true,
+		Responses: map[int]interface{}{
+			http.StatusNoContent:           nil,
+			http.StatusUnauthorized:        problem,
+			http.StatusNotFound:            problem,
+			http.StatusInternalServerError: problem,
+		},
+	})
+
+	adminErrors := func(responses map[int]interface{}) map[int]interface{} {
+		responses[http.StatusUnauthorized] = problem
+		responses[http.StatusForbidden] = problem
+		responses[http.StatusInternalServerError] = problem
+		return responses
+	}
+	doc.Add(Route{
+		Method: http.MethodGet, Path: "/api/v1/admin/dead-letters", Summary: "Lista dead letters", Tag: "admin", Secured: true,
+		Parameters: []Parameter{
+			{Name: "kind", In: "query", Schema: &Schema{Type: "string", Enum: []interface{}{models.DeadLetterKindRegistration, models.DeadLetterKindEvent, models.DeadLetterKindJob, models.DeadLetterKindUnencoded}}},
+			{Name: "limit", In: "query", Schema: &Schema{Type: "integer"}},
+			{Name: "offset", In: "query", Schema: &Schema{Type: "integer"}},
+		},
+		Responses: adminErrors(map[int]interface{}{
+			http.StatusOK:         dto.DeadLetterListResponse{},
+			http.StatusBadRequest: problem,
+		}),
+	})
+	doc.Add(Route{
+		Method: http.MethodGet, Path: "/api/v1/admin/dead-letters/:id", Summary: "Detalha uma dead letter", Tag: "admin", Secured: true,
+		Responses: adminErrors(map[int]interface{}{
+			http.StatusOK:         models.DeadLetter{},
+			http.StatusBadRequest: problem,
+			http.StatusNotFound:   problem,
+		}),
+	})
+	doc.Add(Route{
+		Method: http.MethodPost, Path: "/api/v1/admin/dead-letters/:id/replay", Summary: "Reprocessa uma dead letter", Tag: "admin", Secured: true,
+		Responses: adminErrors(map[int]interface{}{
+			http.StatusOK:         dto.MessageResponse{},
+			http.StatusBadRequest: problem,
+			http.StatusNotFound:   problem,
+			http.StatusConflict:   problem,
+		}),
+	})
+	doc.Add(Route{
+		Method: http.MethodDelete, Path: "/api/v1/admin/dead-letters/:id", Summary: "Descarta uma dead letter", Tag: "admin", Secured: true,
+		Responses: adminErrors(map[int]interface{}{
+			http.StatusNoContent:  nil,
+			http.StatusBadRequest: problem,
+			http.StatusNotFound:   problem,
+		}),
+	})
+	doc.Add(Route{
+		Method: http.MethodGet, Path: "/api/v1/admin/event-bus/stats", Summary: "Métricas de entrega do producer Kafka", Tag: "admin", Secured: true,
+		Responses: adminErrors(map[int]interface{}{
+			http.StatusOK:       messaging.ProducerStats{},
+			http.StatusNotFound: problem,
+		}),
+	})
+	doc.Add(Route{
+		Method: http.MethodGet, Path: "/api/v1/admin/workers", Summary: "Tamanho, limites e métricas dos worker pools do processo, por tipo de job", Tag: "admin", Secured: true,
+		Responses: adminErrors(map[int]interface{}{
+			http.StatusOK:       map[string]services.WorkerPoolStats{},
+			http.StatusNotFound: problem,
+		}),
+	})
+	doc.Add(Route{
+		Method: http.MethodPut, Path: "/api/v1/admin/workers/:type", Summary: "Muda o número de workers e liga ou desliga o autoscaler de um tipo de job", Tag: "admin", Secured: true,
+		Request: dto.UpdateWorkerPoolRequest{},
+		Responses: adminErrors(map[int]interface{}{
+			http.StatusOK:         services.WorkerPoolStats{},
+			http.StatusBadRequest: problem,
+			http.StatusNotFound:   problem,
+		}),
+	})
+	doc.Add(Route{
+		Method: http.MethodGet, Path: "/api/v1/admin/schedules", Summary: "Jobs agendados de manutenção, com o próximo horário e a última execução", Tag: "admin", Secured: true,
+		Responses: adminErrors(map[int]interface{}{
+			http.StatusOK:       []services.ScheduleStatus{},
+			http.StatusNotFound: problem,
+		}),
+	})
+	doc.Add(Route{
+		Method: http.MethodPut, Path: "/api/v1/admin/schedules/:name", Summary: "Pausa ou retoma um job agendado em todas as réplicas", Tag: "admin", Secured: true,
+		Request: dto.UpdateScheduleRequest{},
+		Responses: adminErrors(map[int]interface{}{
+			http.StatusOK:         services.ScheduleStatus{},
+			http.StatusBadRequest: problem,
+			http.StatusNotFound:   problem,
+		}),
+	})
+	doc.Add(Route{
+		Method: http.MethodPost, Path: "/api/v1/admin/schedules/:name/trigger", Summary: "Dispara um job agendado agora, mesmo pausado", Tag: "admin", Secured: true,
+		Responses: adminErrors(map[int]interface{}{
+			http.StatusAccepted: models.ScheduleRun{},
+			http.StatusNotFound: problem,
+			http.StatusConflict: problem,
+		}),
+	})
+	doc.Add(Route{
+		Method: http.MethodGet, Path: "/api/v1/admin/schedules/:name/runs", Summary: "Histórico de execuções de um job agendado", Tag: "admin", Secured: true,
+		Parameters: []Parameter{
+			{Name: "limit", In: "query", Schema: &Schema{Type: "integer"}},
+		},
+		Responses: adminErrors(map[int]interface{}{
+			http.StatusOK:         dto.ScheduleRunListResponse{},
+			http.StatusBadRequest: problem,
+			http.StatusNotFound:   problem,
+		}),
+	})
+
+	return doc
+}
@@ -0,0 +1,249 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/mail"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lucas/go-rest-api-mongo/pkg/utils"
+)
+
+// Validator valida requisições e respostas contra o documento. Bodies de requisição
+// inválidos são rejeitados com 400; respostas fora do contrato só geram log, pois já
+// foram enviadas. Pensado para desenvolvimento: bufferiza todas as respostas.
+func Validator(doc *Document) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		path := c.FullPath()
+		if path == "" {
+			c.Next()
+			return
+		}
+
+		op, ok := doc.Operation(c.Request.Method, path)
+		if !ok {
+			log.Printf("openapi: %s %s is not documented", c.Request.Method, path)
+			c.Next()
+			return
+		}
+
+		if op.RequestBody != nil {
+			body, err := io.ReadAll(c.Request.Body)
+			if err != nil {
+				utils.SendError(c, http.StatusBadRequest, "bad_request", "invalid request body")
+				c.Abort()
+				return
+			}
+			c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+			// Bodies que nem são JSON ficam para o binding do handler
+			var value interface{}
+			if json.Unmarshal(body, &value) == nil {
+				schema := op.RequestBody.Content["application/json"].Schema
+				if errs := doc.Validate(schema, value); len(errs) > 0 {
+					problem := utils.NewProblem(http.StatusBadRequest, "validation_error", "request body has invalid fields")
+					problem.Errors = errs
+					utils.SendProblem(c, problem)
+					c.Abort()
+					return
+				}
+			}
+		}
+
+		writer := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+
+		response, ok := op.Responses[strconv.Itoa(writer.Status())]
+		if !ok {
+			log.Printf("openapi: %s %s returned undocumented status %d", c.Request.Method, path, writer.Status())
+			return
+		}
+		for contentType, media := range response.Content {
+			if !strings.Contains(contentType, "json") {
+				continue
+			}
+			var value interface{}
+			if err := json.Unmarshal(writer.body.Bytes(), &value); err != nil {
+				log.Printf("openapi: %s %s returned a non-JSON body for status %d", c.Request.Method, path, writer.Status())
+				continue
+			}
+			for _, fieldErr := range doc.Validate(media.Schema, value) {
+				log.Printf("openapi: %s %s response %d: %s: %s", c.Request.Method, path, writer.Status(), fieldErr.Field, fieldErr.Message)
+			}
+		}
+	}
+}
+
+// Validate checa value (já decodificado de JSON) contra schema.
+func (d *Document) Validate(schema *Schema, value interface{}) []utils.FieldError {
+	var errs []utils.FieldError
+	d.validate(schema, value, "", &errs)
+	return errs
+}
+
+func (d *Document) validate(schema *Schema, value interface{}, field string, errs *[]utils.FieldError) {
+	schema = d.Resolve(schema)
+	if schema == nil {
+		return
+	}
+
+	fail := func(rule, param, msg string) {
+		addFieldError(errs, field, rule, param, msg)
+	}
+
+	if !matchesType(schema.Type, value) {
+		fail("type", fmt.Sprint(schema.Type), fmt.Sprintf("must be of type %v", schema.Type))
+		return
+	}
+
+	if len(schema.Enum) > 0 {
+		found := false
+		for _, allowed := range schema.Enum {
+			if allowed == value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			fail("oneof", strings.TrimSuffix(strings.TrimPrefix(fmt.Sprint(schema.Enum), "["), "]"), "must be one of the allowed values")
+		}
+	}
+
+	switch v := value.(type) {
+	case string:
+		length := utf8.RuneCountInString(v)
+		if schema.MinLength != nil && length < *schema.MinLength {
+			fail("min", strconv.Itoa(*schema.MinLength), fmt.Sprintf("must be at least %d characters long", *schema.MinLength))
+		}
+		if schema.MaxLength != nil && length > *schema.MaxLength {
+			fail("max", strconv.Itoa(*schema.MaxLength), fmt.Sprintf("must be at most %d characters long", *schema.MaxLength))
+		}
+		if schema.Format == "email" {
+			if _, err := mail.ParseAddress(v); err != nil {
+				fail("email", "", "must be a valid email address")
+			}
+		}
+		if schema.Pattern != "" {
+			if re, err := regexp.Compile(schema.Pattern); err == nil && !re.MatchString(v) {
+				fail("pattern", schema.Pattern, "does not match the expected pattern")
+			}
+		}
+
+	case float64:
+		if schema.Minimum != nil && v < *schema.Minimum {
+			fail("min", fmt.Sprint(*schema.Minimum), fmt.Sprintf("must be at least %v", *schema.Minimum))
+		}
+		if schema.Maximum != nil && v > *schema.Maximum {
+			fail("max", fmt.Sprint(*schema.Maximum), fmt.Sprintf("must be at most %v", *schema.Maximum))
+		}
+
+	case []interface{}:
+		for i, item := range v {
+			d.validate(schema.Items, item, fmt.Sprintf("%s[%d]", field, i), errs)
+		}
+
+	case map[string]interface{}:
+		for _, name := range schema.Required {
+			if _, ok := v[name]; !ok {
+				addFieldError(errs, joinField(field, name), "required", "", "is required")
+			}
+		}
+
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if prop, ok := schema.Properties[name]; ok {
+				d.validate(prop, v[name], joinField(field, name), errs)
+			} else if extra, ok := schema.AdditionalProperties.(*Schema); ok {
+				d.validate(extra, v[name], joinField(field, name), errs)
+			}
+		}
+	}
+}
+
+func matchesType(schemaType interface{}, value interface{}) bool {
+	switch t := schemaType.(type) {
+	case nil:
+		return true
+	case string:
+		return matchesSingleType(t, value)
+	case []interface{}:
+		for _, option := range t {
+			if name, ok := option.(string); ok && matchesSingleType(name, value) {
+				return true
+			}
+		}
+		return false
+	default:
+		return true
+	}
+}
+
+func matchesSingleType(name string, value interface{}) bool {
+	switch name {
+	case "null":
+		return value == nil
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		n, ok := value.(float64)
+		return ok && n == float64(int64(n))
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	default:
+		return true
+	}
+}
+
+func addFieldError(errs *[]utils.FieldError, field, rule, param, msg string) {
+	if field == "" {
+		field = "(body)"
+	}
+	*errs = append(*errs, utils.FieldError{Field: field, Rule: rule, Param: param, Message: field + " " + msg})
+}
+
+func joinField(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "." + name
+}
+
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,44 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"github.com/lucas/go-rest-api-mongo/internal/database"
+	"github.com/lucas/go-rest-api-mongo/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type CheckpointRepository struct {
+	collection func() *mongo.Collection
+}
+
+func NewCheckpointRepository(db *database.MongoDB) *CheckpointRepository {
+	return &CheckpointRepository{
+		collection: db.Collection("checkpoints"),
+	}
+}
+
+func (r *CheckpointRepository) Get(ctx context.Context, id string) (*models.Checkpoint, error) {
+	var checkpoint models.Checkpoint
+	err := r.collection().FindOne(ctx, bson.M{"_id": id}).Decode(&checkpoint)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &checkpoint, nil
+}
+
+func (r *CheckpointRepository) Save(ctx context.Context, checkpoint *models.Checkpoint) error {
+	_, err := r.collection().ReplaceOne(ctx, bson.M{"_id": checkpoint.ID}, checkpoint, options.Replace().SetUpsert(true))
+	return err
+}
+
+func (r *CheckpointRepository) Delete(ctx context.Context, id string) error {
+	_, err := r.collection().DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
@@ -0,0 +1,99 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/lucas/go-rest-api-mongo/internal/database"
+	"github.com/lucas/go-rest-api-mongo/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type DeadLetterRepository struct {
+	collection func() *mongo.Collection
+}
+
+func NewDeadLetterRepository(db *database.MongoDB) *DeadLetterRepository {
+	return &DeadLetterRepository{
+		collection: db.Collection("dead_letters"),
+	}
+}
+
+func (r *DeadLetterRepository) CreateMany(ctx context.Context, letters []*models.DeadLetter) error {
+	if len(letters) == 0 {
+		return nil
+	}
+
+	docs := make([]interface{}, len(letters))
+	for i, letter := range letters {
+		if letter.ID.IsZero() {
+			letter.ID = primitive.NewObjectID()
+		}
+		docs[i] = letter
+	}
+
+	_, err := r.collection().InsertMany(ctx, docs)
+	return err
+}
+
+// List retorna as dead letters mais recentes primeiro; kind vazio não filtra.
+func (r *DeadLetterRepository) List(ctx context.Context, kind string, limit, offset int64) ([]*models.DeadLetter, error) {
+	filter := bson.M{}
+	if kind != "" {
+		filter["kind"] = kind
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetLimit(limit).
+		SetSkip(offset)
+
+	cursor, err := r.collection().Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	letters := make([]*models.DeadLetter, 0)
+	if err := cursor.All(ctx, &letters); err != nil {
+		return nil, err
+	}
+	return letters, nil
+}
+
+func (r *DeadLetterRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.DeadLetter, error) {
+	var letter models.DeadLetter
+	err := r.collection().FindOne(ctx, bson.M{"_id": id}).Decode(&letter)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &letter, nil
+}
+
+func (r *DeadLetterRepository) Update(ctx context.Context, letter *models.DeadLetter) error {
+	_, err := r.collection().ReplaceOne(ctx, bson.M{"_id": letter.ID}, letter)
+	return err
+}
+
+// Delete remove a dead letter e informa se ela existia.
+func (r *DeadLetterRepository) Delete(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	result, err := r.collection().DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return false, err
+	}
+	return result.DeletedCount > 0, nil
+}
+
+func (r *DeadLetterRepository) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.collection().DeleteMany(ctx, bson.M{"created_at": bson.M{"$lt": before}})
+	if err != nil {
+		return 0, err
+	}
+	return result.DeletedCount, nil
+}
@@ -0,0 +1,85 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/lucas/go-rest-api-mongo/internal/database"
+	"github.com/lucas/go-rest-api-mongo/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type IdempotencyRepository struct {
+	collection func() *mongo.Collection
+}
+
+func NewIdempotencyRepository(db *database.MongoDB) *IdempotencyRepository {
+	return &IdempotencyRepository{
+		collection: db.Collection("idempotency_keys"),
+	}
+}
+
+// EnsureIndexes cria o índice TTL que remove as chaves depois de expires_at.
+func (r *IdempotencyRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection().Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expires_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0).SetName("expires_at_ttl"),
+	})
+	return err
+}
+
+// Reserve tenta registrar a chave como "processing". Se ela já existir (e não tiver
+// expirado), nada é gravado e o registro existente é retornado. O insert com _id
+// único garante que apenas uma de várias requisições concorrentes vence a reserva.
+func (r *IdempotencyRepository) Reserve(ctx context.Context, record *models.IdempotencyRecord) (*models.IdempotencyRecord, error) {
+	_, err := r.collection().InsertOne(ctx, record)
+	if err == nil {
+		return nil, nil
+	}
+	if !mongo.IsDuplicateKeyError(err) {
+		return nil, err
+	}
+
+	// O monitor TTL do Mongo roda a cada minuto, então uma chave vencida ainda pode existir
+	result := r.collection().FindOneAndReplace(ctx,
+		bson.M{"_id": record.Key, "expires_at": bson.M{"$lte": time.Now()}},
+		record,
+	)
+	if result.Err() == nil {
+		return nil, nil
+	}
+	if result.Err() != mongo.ErrNoDocuments {
+		return nil, result.Err()
+	}
+
+	var existing models.IdempotencyRecord
+	err = r.collection().FindOne(ctx, bson.M{"_id": record.Key}).Decode(&existing)
+	if err == mongo.ErrNoDocuments {
+		// A chave foi liberada entre o insert e a leitura; tenta reservar de novo
+		return r.Reserve(ctx, record)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &existing, nil
+}
+
+func (r *IdempotencyRepository) Complete(ctx context.Context, key string, status int, body []byte, contentType string) error {
+	_, err := r.collection().UpdateOne(ctx, bson.M{"_id": key}, bson.M{
+		"$set": bson.M{
+			"status":          models.IdempotencyStatusCompleted,
+			"response_status": status,
+			"response_body":   body,
+			"content_type":    contentType,
+		},
+	})
+	return err
+}
+
+// Release apaga uma reserva ainda em andamento, permitindo que o cliente tente de novo.
+func (r *IdempotencyRepository) Release(ctx context.Context, key string) error {
+	_, err := r.collection().DeleteOne(ctx, bson.M{"_id": key, "status": models.IdempotencyStatusProcessing})
+	return err
+}
@@ -0,0 +1,192 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lucas/go-rest-api-mongo/internal/database"
+	"github.com/lucas/go-rest-api-mongo/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrQueueFull é retornado por Enqueue quando a fila atingiu a capacidade.
+var ErrQueueFull = errors.New("queue is full")
+
+// legacyRegistrationJobs é a coleção da fila de registros antes da fila genérica; os
+// jobs que sobraram nela são movidos para jobs por EnsureIndexes.
+const legacyRegistrationJobs = "registration_jobs"
+
+// JobRepository guarda os jobs de todos os tipos na coleção jobs. Cada job é
+// reservado com um findOneAndUpdate atômico, então vários workers podem consumir a
+// mesma fila sem receber o mesmo job.
+type JobRepository struct {
+	collection func() *mongo.Collection
+	legacy     func() *mongo.Collection
+}
+
+func NewJobRepository(db *database.MongoDB) *JobRepository {
+	return &JobRepository{
+		collection: db.Collection("jobs"),
+		legacy:     db.Collection(legacyRegistrationJobs),
+	}
+}
+
+// EnsureIndexes cria os índices usados para achar o próximo job de uma lane e o último
+// de uma chave, move os jobs da antiga coleção registration_jobs e põe na lane
+// normal os jobs enfileirados antes das lanes.
+func (r *JobRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection().Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "type", Value: 1}, {Key: "lane", Value: 1}, {Key: "fair_rank", Value: 1}, {Key: "_id", Value: 1}},
+			Options: options.Index().SetName("type_lane_fair_rank_id"),
+		},
+		{
+			Keys:    bson.D{{Key: "type", Value: 1}, {Key: "lane", Value: 1}, {Key: "fair_key", Value: 1}, {Key: "fair_rank", Value: -1}},
+			Options: options.Index().SetName("type_lane_fair_key_fair_rank"),
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if err := r.migrateRegistrationJobs(ctx); err != nil {
+		return err
+	}
+
+	_, err = r.collection().UpdateMany(ctx,
+		bson.M{"lane": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"lane": models.JobLaneNormal, "fair_rank": 0}},
+	)
+	return err
+}
+
+func (r *JobRepository) migrateRegistrationJobs(ctx context.Context) error {
+	count, err := r.legacy().CountDocuments(ctx, bson.M{})
+	if err != nil || count == 0 {
+		return err
+	}
+
+	// O _id é mantido, então rodar de novo depois de uma falha não duplica jobs
+	pipeline := mongo.Pipeline{
+		{{Key: "$project", Value: bson.M{
+			"type":         "registration",
+			"lane":         models.JobLaneNormal,
+			"fair_rank":    0,
+			"payload":      "$user",
+			"enqueued_at":  1,
+			"attempts":     1,
+			"locked_by":    1,
+			"locked_until": 1,
+		}}},
+		{{Key: "$merge", Value: bson.M{"into": r.collection().Name(), "whenMatched": "keepExisting"}}},
+	}
+	cursor, err := r.legacy().Aggregate(ctx, pipeline)
+	if err != nil {
+		return fmt.Errorf("moving %s to %s: %w", legacyRegistrationJobs, r.collection().Name(), err)
+	}
+	cursor.Close(ctx)
+	return r.legacy().Drop(ctx)
+}
+
+// Enqueue calcula o FairRank do job antes de gravá-lo. Réplicas que enfileiram ao
+// mesmo tempo para a mesma chave podem gravar o mesmo rank; os empates saem em ordem
+// de chegada.
+func (r *JobRepository) Enqueue(ctx context.Context, job *models.Job) error {
+	if job.ID.IsZero() {
+		job.ID = primitive.NewObjectID()
+	}
+
+	lane := bson.M{"type": job.Type, "lane": job.Lane}
+	head, err := r.rank(ctx, lane, 1)
+	if err != nil {
+		return err
+	}
+	last, err := r.rank(ctx, bson.M{"type": job.Type, "lane": job.Lane, "fair_key": job.FairKey}, -1)
+	if err != nil {
+		return err
+	}
+	job.FairRank = nextFairRank(head, last)
+
+	_, err = r.collection().InsertOne(ctx, job)
+	return err
+}
+
+// rank devolve o menor (order 1) ou o maior (order -1) FairRank dos jobs de filter, ou
+// nil se não há nenhum.
+func (r *JobRepository) rank(ctx context.Context, filter bson.M, order int) (*float64, error) {
+	opts := options.FindOne().
+		SetSort(bson.D{{Key: "fair_rank", Value: order}}).
+		SetProjection(bson.M{"fair_rank": 1})
+
+	var job models.Job
+	err := r.collection().FindOne(ctx, filter, opts).Decode(&job)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &job.FairRank, nil
+}
+
+func (r *JobRepository) Dequeue(ctx context.Context, jobType, lane, owner string, limit int, visibility time.Duration) ([]*models.Job, error) {
+	var jobs []*models.Job
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "fair_rank", Value: 1}, {Key: "_id", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	for len(jobs) < limit {
+		now := time.Now()
+		filter := bson.M{"type": jobType, "lane": lane, "locked_until": bson.M{"$lte": now}}
+		update := bson.M{
+			"$set": bson.M{"locked_by": owner, "locked_until": now.Add(visibility)},
+			"$inc": bson.M{"attempts": 1},
+		}
+
+		var job models.Job
+		err := r.collection().FindOneAndUpdate(ctx, filter, update, opts).Decode(&job)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			break
+		}
+		if err != nil {
+			// Os jobs já reservados voltam para a fila depois de visibility
+			return jobs, err
+		}
+		jobs = append(jobs, &job)
+	}
+	return jobs, nil
+}
+
+func (r *JobRepository) Ack(ctx context.Context, ids []primitive.ObjectID) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	_, err := r.collection().DeleteMany(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	return err
+}
+
+func (r *JobRepository) Depth(ctx context.Context, jobType, lane string) (int64, error) {
+	filter := bson.M{"type": jobType}
+	if lane != "" {
+		filter["lane"] = lane
+	}
+	return r.collection().CountDocuments(ctx, filter)
+}
+
+// nextFairRank é o FairRank de um job novo: logo depois do último job pendente da
+// mesma chave (last), sem ficar antes do job mais antigo da lane (head). Uma chave sem
+// jobs pendentes entra junto com o início da lane.
+func nextFairRank(head, last *float64) float64 {
+	rank := 0.0
+	if head != nil {
+		rank = *head
+	}
+	if last != nil && *last+1 > rank {
+		rank = *last + 1
+	}
+	return rank
+}
@@ -0,0 +1,70 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/lucas/go-rest-api-mongo/internal/database"
+	"github.com/lucas/go-rest-api-mongo/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// LeaseRepository guarda um documento por lease. O filtro do upsert só casa com um
+// lease expirado ou do mesmo owner; se outro owner o segura, o upsert tenta inserir
+// o mesmo _id e falha com chave duplicada. As réplicas precisam de relógios
+// sincronizados (NTP), já que a expiração usa a hora local.
+type LeaseRepository struct {
+	collection func() *mongo.Collection
+}
+
+func NewLeaseRepository(db *database.MongoDB) *LeaseRepository {
+	return &LeaseRepository{
+		collection: db.Collection("leases"),
+	}
+}
+
+func (r *LeaseRepository) TryAcquire(ctx context.Context, name, owner string, ttl time.Duration) (bool, error) {
+	now := time.Now()
+	filter := bson.M{
+		"_id": name,
+		"$or": bson.A{
+			bson.M{"owner": owner},
+			bson.M{"expires_at": bson.M{"$lte": now}},
+		},
+	}
+	update := bson.M{"$set": bson.M{
+		"owner":      owner,
+		"expires_at": now.Add(ttl),
+		"renewed_at": now,
+	}}
+
+	_, err := r.collection().UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Release expira o lease na hora, para que outra réplica não espere o TTL.
+func (r *LeaseRepository) Release(ctx context.Context, name, owner string) error {
+	_, err := r.collection().UpdateOne(ctx,
+		bson.M{"_id": name, "owner": owner},
+		bson.M{"$set": bson.M{"expires_at": time.Now()}},
+	)
+	return err
+}
+
+func (r *LeaseRepository) Get(ctx context.Context, name string) (*models.Lease, error) {
+	var lease models.Lease
+	err := r.collection().FindOne(ctx, bson.M{"_id": name}).Decode(&lease)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &lease, nil
+}
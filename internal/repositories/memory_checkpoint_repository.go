@@ -0,0 +1,48 @@
+package repositories
+
+import (
+	"context"
+	"sync"
+
+	"github.com/lucas/go-rest-api-mongo/internal/models"
+)
+
+type MemoryCheckpointRepository struct {
+	mu          sync.RWMutex
+	checkpoints map[string]models.Checkpoint
+}
+
+func NewMemoryCheckpointRepository() *MemoryCheckpointRepository {
+	return &MemoryCheckpointRepository{
+		checkpoints: make(map[string]models.Checkpoint),
+	}
+}
+
+func (r *MemoryCheckpointRepository) Get(ctx context.Context, id string) (*models.Checkpoint, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	checkpoint, ok := r.checkpoints[id]
+	if !ok {
+		return nil, nil
+	}
+	return &checkpoint, nil
+}
+
+func (r *MemoryCheckpointRepository) Save(ctx context.Context, checkpoint *models.Checkpoint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	saved := *checkpoint
+	saved.UpdatedAt = bsonTime(saved.UpdatedAt)
+	r.checkpoints[checkpoint.ID] = saved
+	return nil
+}
+
+func (r *MemoryCheckpointRepository) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.checkpoints, id)
+	return nil
+}
@@ -0,0 +1,125 @@
+package repositories
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/lucas/go-rest-api-mongo/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type MemoryDeadLetterRepository struct {
+	mu      sync.RWMutex
+	letters map[primitive.ObjectID]*models.DeadLetter
+}
+
+func NewMemoryDeadLetterRepository() *MemoryDeadLetterRepository {
+	return &MemoryDeadLetterRepository{
+		letters: make(map[primitive.ObjectID]*models.DeadLetter),
+	}
+}
+
+func (r *MemoryDeadLetterRepository) CreateMany(ctx context.Context, letters []*models.DeadLetter) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, letter := range letters {
+		if letter.ID.IsZero() {
+			letter.ID = primitive.NewObjectID()
+		}
+		if _, exists := r.letters[letter.ID]; exists {
+			return ErrDuplicateKey
+		}
+		r.letters[letter.ID] = copyDeadLetter(letter)
+	}
+	return nil
+}
+
+func (r *MemoryDeadLetterRepository) List(ctx context.Context, kind string, limit, offset int64) ([]*models.DeadLetter, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	letters := make([]*models.DeadLetter, 0)
+	for _, letter := range r.letters {
+		if kind == "" || letter.Kind == kind {
+			letters = append(letters, copyDeadLetter(letter))
+		}
+	}
+
+	sort.Slice(letters, func(i, j int) bool {
+		return letters[i].CreatedAt.After(letters[j].CreatedAt)
+	})
+
+	if offset >= int64(len(letters)) {
+		return letters[:0], nil
+	}
+	letters = letters[offset:]
+	if limit > 0 && limit < int64(len(letters)) {
+		letters = letters[:limit]
+	}
+	return letters, nil
+}
+
+func (r *MemoryDeadLetterRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.DeadLetter, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	letter, ok := r.letters[id]
+	if !ok {
+		return nil, nil
+	}
+	return copyDeadLetter(letter), nil
+}
+
+func (r *MemoryDeadLetterRepository) Update(ctx context.Context, letter *models.DeadLetter) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.letters[letter.ID]; ok {
+		r.letters[letter.ID] = copyDeadLetter(letter)
+	}
+	return nil
+}
+
+func (r *MemoryDeadLetterRepository) Delete(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.letters[id]; !ok {
+		return false, nil
+	}
+	delete(r.letters, id)
+	return true, nil
+}
+
+func (r *MemoryDeadLetterRepository) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var deleted int64
+	for id, letter := range r.letters {
+		if letter.CreatedAt.Before(before) {
+			delete(r.letters, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+func copyDeadLetter(letter *models.DeadLetter) *models.DeadLetter {
+	clone := *letter
+	clone.CreatedAt = bsonTime(letter.CreatedAt)
+	clone.LastAttemptAt = bsonTime(letter.LastAttemptAt)
+	if letter.User != nil {
+		clone.User = copyUser(letter.User)
+	}
+	if letter.Headers != nil {
+		clone.Headers = make(map[string]string, len(letter.Headers))
+		for name, value := range letter.Headers {
+			clone.Headers[name] = value
+		}
+	}
+	return &clone
+}
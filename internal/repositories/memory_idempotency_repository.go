@@ -0,0 +1,59 @@
+package repositories
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/lucas/go-rest-api-mongo/internal/models"
+)
+
+// MemoryIdempotencyRepository implementa o mesmo contrato do IdempotencyRepository
+// para uma única réplica; chaves vencidas são tratadas como inexistentes.
+type MemoryIdempotencyRepository struct {
+	mu      sync.Mutex
+	records map[string]*models.IdempotencyRecord
+}
+
+func NewMemoryIdempotencyRepository() *MemoryIdempotencyRepository {
+	return &MemoryIdempotencyRepository{
+		records: make(map[string]*models.IdempotencyRecord),
+	}
+}
+
+func (r *MemoryIdempotencyRepository) Reserve(ctx context.Context, record *models.IdempotencyRecord) (*models.IdempotencyRecord, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if existing, ok := r.records[record.Key]; ok && existing.ExpiresAt.After(time.Now()) {
+		clone := *existing
+		return &clone, nil
+	}
+
+	clone := *record
+	r.records[record.Key] = &clone
+	return nil, nil
+}
+
+func (r *MemoryIdempotencyRepository) Complete(ctx context.Context, key string, status int, body []byte, contentType string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if record, ok := r.records[key]; ok {
+		record.Status = models.IdempotencyStatusCompleted
+		record.ResponseStatus = status
+		record.ResponseBody = append([]byte(nil), body...)
+		record.ContentType = contentType
+	}
+	return nil
+}
+
+func (r *MemoryIdempotencyRepository) Release(ctx context.Context, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if record, ok := r.records[key]; ok && record.Status == models.IdempotencyStatusProcessing {
+		delete(r.records, key)
+	}
+	return nil
+}
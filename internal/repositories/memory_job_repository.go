@@ -0,0 +1,123 @@
+package repositories
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/lucas/go-rest-api-mongo/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryJobRepository é a fila de jobs em memória, com capacidade limitada por tipo
+// (somando as lanes).
+// Ela só liga a API aos workers do mesmo processo (role "all") e perde os jobs em um
+// restart.
+type MemoryJobRepository struct {
+	mu       sync.Mutex
+	jobs     map[string][]*models.Job // por tipo, em ordem de chegada
+	capacity int
+}
+
+func NewMemoryJobRepository(capacity int) *MemoryJobRepository {
+	return &MemoryJobRepository{jobs: make(map[string][]*models.Job), capacity: capacity}
+}
+
+func (r *MemoryJobRepository) Enqueue(ctx context.Context, job *models.Job) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.capacity > 0 && len(r.jobs[job.Type]) >= r.capacity {
+		return ErrQueueFull
+	}
+	if job.ID.IsZero() {
+		job.ID = primitive.NewObjectID()
+	}
+
+	var head, last *float64
+	for _, queued := range r.jobs[job.Type] {
+		if queued.Lane != job.Lane {
+			continue
+		}
+		if head == nil || queued.FairRank < *head {
+			head = &queued.FairRank
+		}
+		if queued.FairKey == job.FairKey && (last == nil || queued.FairRank > *last) {
+			last = &queued.FairRank
+		}
+	}
+	job.FairRank = nextFairRank(head, last)
+
+	clone := *job
+	clone.Payload = slices.Clone(job.Payload)
+	r.jobs[job.Type] = append(r.jobs[job.Type], &clone)
+	return nil
+}
+
+func (r *MemoryJobRepository) Dequeue(ctx context.Context, jobType, lane, owner string, limit int, visibility time.Duration) ([]*models.Job, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Os jobs ficam em ordem de chegada; a ordenação estável desempata os ranks iguais
+	now := time.Now()
+	var available []*models.Job
+	for _, job := range r.jobs[jobType] {
+		if job.Lane == lane && !job.LockedUntil.After(now) {
+			available = append(available, job)
+		}
+	}
+	slices.SortStableFunc(available, func(a, b *models.Job) int { return cmp.Compare(a.FairRank, b.FairRank) })
+
+	var jobs []*models.Job
+	for _, job := range available {
+		if len(jobs) >= limit {
+			break
+		}
+		job.LockedBy = owner
+		job.LockedUntil = now.Add(visibility)
+		job.Attempts++
+		clone := *job
+		jobs = append(jobs, &clone)
+	}
+	return jobs, nil
+}
+
+func (r *MemoryJobRepository) Ack(ctx context.Context, ids []primitive.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	acked := make(map[primitive.ObjectID]bool, len(ids))
+	for _, id := range ids {
+		acked[id] = true
+	}
+	for jobType, jobs := range r.jobs {
+		r.jobs[jobType] = slices.DeleteFunc(jobs, func(job *models.Job) bool { return acked[job.ID] })
+	}
+	return nil
+}
+
+func (r *MemoryJobRepository) Depth(ctx context.Context, jobType, lane string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if lane == "" {
+		return int64(len(r.jobs[jobType])), nil
+	}
+	var depth int64
+	for _, job := range r.jobs[jobType] {
+		if job.Lane == lane {
+			depth++
+		}
+	}
+	return depth, nil
+}
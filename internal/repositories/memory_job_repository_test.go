@@ -0,0 +1,70 @@
+package repositories
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lucas/go-rest-api-mongo/internal/models"
+)
+
+func TestMemoryJobRepositoryAlternatesFairKeys(t *testing.T) {
+	repo := NewMemoryJobRepository(0)
+	ctx := context.Background()
+
+	// Um tenant com backlog, um que chega depois e um job em outra lane
+	for _, key := range []string{"bulk", "bulk", "bulk", "signup", "signup"} {
+		if err := repo.Enqueue(ctx, &models.Job{Type: "registration", Lane: models.JobLaneNormal, FairKey: key}); err != nil {
+			t.Fatalf("Enqueue() error = %v", err)
+		}
+	}
+	if err := repo.Enqueue(ctx, &models.Job{Type: "registration", Lane: models.JobLaneHigh, FairKey: "bulk"}); err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+
+	jobs, err := repo.Dequeue(ctx, "registration", models.JobLaneNormal, "worker", 10, time.Minute)
+	if err != nil {
+		t.Fatalf("Dequeue() error = %v", err)
+	}
+	var order []string
+	for _, job := range jobs {
+		order = append(order, job.FairKey)
+	}
+	want := []string{"bulk", "signup", "bulk", "signup", "bulk"}
+	if len(order) != len(want) {
+		t.Fatalf("Dequeue() = %v, want %v", order, want)
+	}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Fatalf("Dequeue() = %v, want the keys alternated as %v", order, want)
+		}
+	}
+
+	if depth, _ := repo.Depth(ctx, "registration", models.JobLaneHigh); depth != 1 {
+		t.Errorf("Depth(high) = %d, want 1", depth)
+	}
+	if depth, _ := repo.Depth(ctx, "registration", ""); depth != 6 {
+		t.Errorf("Depth(all lanes) = %d, want 6", depth)
+	}
+}
+
+func TestNextFairRank(t *testing.T) {
+	rank := func(v float64) *float64 { return &v }
+	tests := []struct {
+		name       string
+		head, last *float64
+		want       float64
+	}{
+		{"empty lane", nil, nil, 0},
+		{"new key starts at the head of the lane", rank(5), nil, 5},
+		{"key goes after its last job", rank(5), rank(7), 8},
+		{"key never goes before the head", rank(5), rank(2), 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nextFairRank(tt.head, tt.last); got != tt.want {
+				t.Errorf("nextFairRank() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package repositories
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/lucas/go-rest-api-mongo/internal/models"
+)
+
+// MemoryLeaseRepository só coordena quem está no mesmo processo, o que basta para
+// testes e para uma única réplica.
+type MemoryLeaseRepository struct {
+	mu     sync.Mutex
+	leases map[string]models.Lease
+}
+
+func NewMemoryLeaseRepository() *MemoryLeaseRepository {
+	return &MemoryLeaseRepository{
+		leases: make(map[string]models.Lease),
+	}
+}
+
+func (r *MemoryLeaseRepository) TryAcquire(ctx context.Context, name, owner string, ttl time.Duration) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	if lease, ok := r.leases[name]; ok && lease.Owner != owner && lease.ExpiresAt.After(now) {
+		return false, nil
+	}
+	r.leases[name] = models.Lease{Name: name, Owner: owner, ExpiresAt: now.Add(ttl), RenewedAt: now}
+	return true, nil
+}
+
+func (r *MemoryLeaseRepository) Release(ctx context.Context, name, owner string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if lease, ok := r.leases[name]; ok && lease.Owner == owner {
+		lease.ExpiresAt = time.Now()
+		r.leases[name] = lease
+	}
+	return nil
+}
+
+func (r *MemoryLeaseRepository) Get(ctx context.Context, name string) (*models.Lease, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	lease, ok := r.leases[name]
+	if !ok {
+		return nil, nil
+	}
+	return &lease, nil
+}
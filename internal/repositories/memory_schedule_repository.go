@@ -0,0 +1,100 @@
+package repositories
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/lucas/go-rest-api-mongo/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryScheduleRepository implementa o ScheduleStore para uma única réplica; as
+// execuções vencidas são removidas a cada SaveRun.
+type MemoryScheduleRepository struct {
+	mu     sync.Mutex
+	states map[string]models.ScheduleState
+	runs   map[primitive.ObjectID]models.ScheduleRun
+}
+
+func NewMemoryScheduleRepository() *MemoryScheduleRepository {
+	return &MemoryScheduleRepository{
+		states: make(map[string]models.ScheduleState),
+		runs:   make(map[primitive.ObjectID]models.ScheduleRun),
+	}
+}
+
+func (r *MemoryScheduleRepository) GetState(ctx context.Context, name string) (*models.ScheduleState, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	state, ok := r.states[name]
+	if !ok {
+		return nil, nil
+	}
+	return &state, nil
+}
+
+func (r *MemoryScheduleRepository) SetPaused(ctx context.Context, name string, paused bool) (*models.ScheduleState, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	state := r.states[name]
+	state.Name = name
+	state.Paused = paused
+	state.UpdatedAt = bsonTime(time.Now())
+	r.states[name] = state
+	return &state, nil
+}
+
+func (r *MemoryScheduleRepository) SetLastScheduled(ctx context.Context, name string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	state := r.states[name]
+	state.Name = name
+	state.LastScheduledAt = bsonTime(at)
+	state.UpdatedAt = bsonTime(time.Now())
+	r.states[name] = state
+	return nil
+}
+
+func (r *MemoryScheduleRepository) SaveRun(ctx context.Context, run *models.ScheduleRun) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for id, stored := range r.runs {
+		if !stored.ExpiresAt.IsZero() && stored.ExpiresAt.Before(now) {
+			delete(r.runs, id)
+		}
+	}
+
+	if run.ID.IsZero() {
+		run.ID = primitive.NewObjectID()
+	}
+	r.runs[run.ID] = *run
+	return nil
+}
+
+func (r *MemoryScheduleRepository) ListRuns(ctx context.Context, job string, limit int64) ([]*models.ScheduleRun, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	runs := make([]*models.ScheduleRun, 0)
+	for _, run := range r.runs {
+		if job == "" || run.Job == job {
+			clone := run
+			runs = append(runs, &clone)
+		}
+	}
+
+	sort.Slice(runs, func(i, j int) bool {
+		return runs[i].StartedAt.After(runs[j].StartedAt)
+	})
+	if limit > 0 && limit < int64(len(runs)) {
+		runs = runs[:limit]
+	}
+	return runs, nil
+}
@@ -0,0 +1,336 @@
+package repositories
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/lucas/go-rest-api-mongo/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// memoryChangeRetention é quantas mudanças o MemoryUserRepository guarda para WatchChanges.
+const memoryChangeRetention = 10000
+
+// ErrChangeHistoryLost é retornado por WatchChanges quando o resume token é mais
+// antigo que as mudanças retidas, como o ChangeStreamHistoryLost do Mongo.
+var ErrChangeHistoryLost = errors.New("resume token is no longer in the change history")
+
+// MemoryUserRepository é um UserStore em memória, seguro para uso concorrente, que
+// imita o comportamento do Mongo: email único, IDs gerados no insert, busca vazia
+// como (nil, nil) e datas guardadas em UTC com precisão de milissegundos. As
+// mudanças ficam em um log para WatchChanges, com pre-images sempre disponíveis.
+type MemoryUserRepository struct {
+	mu      sync.RWMutex
+	byID    map[primitive.ObjectID]*models.User
+	byEmail map[string]primitive.ObjectID
+
+	changes     []UserChange
+	changesBase int64 // sequência da primeira mudança retida
+	changed     *sync.Cond
+}
+
+func NewMemoryUserRepository() *MemoryUserRepository {
+	r := &MemoryUserRepository{
+		byID:        make(map[primitive.ObjectID]*models.User),
+		byEmail:     make(map[string]primitive.ObjectID),
+		changesBase: 1,
+	}
+	r.changed = sync.NewCond(&r.mu)
+	return r
+}
+
+func (r *MemoryUserRepository) EnsureIndexes(ctx context.Context) error {
+	return nil
+}
+
+func (r *MemoryUserRepository) Create(ctx context.Context, user *models.User) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.insert(user)
+}
+
+func (r *MemoryUserRepository) CreateMany(ctx context.Context, users []*models.User) []error {
+	errs := make([]error, len(users))
+	if err := ctx.Err(); err != nil {
+		for i := range errs {
+			errs[i] = err
+		}
+		return errs
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Como no InsertMany não ordenado, cada documento é inserido de forma independente
+	for i, user := range users {
+		errs[i] = r.insert(user)
+	}
+	return errs
+}
+
+func (r *MemoryUserRepository) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	id, ok := r.byEmail[email]
+	if !ok {
+		return nil, nil
+	}
+	return copyUser(r.byID[id]), nil
+}
+
+func (r *MemoryUserRepository) FindByEmails(ctx context.Context, emails []string) ([]*models.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var users []*models.User
+	seen := make(map[primitive.ObjectID]bool, len(emails))
+	for _, email := range emails {
+		if id, ok := r.byEmail[email]; ok && !seen[id] {
+			seen[id] = true
+			users = append(users, copyUser(r.byID[id]))
+		}
+	}
+	return users, nil
+}
+
+func (r *MemoryUserRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user, ok := r.byID[id]
+	if !ok {
+		return nil, nil
+	}
+	return copyUser(user), nil
+}
+
+func (r *MemoryUserRepository) Update(ctx context.Context, user *models.User) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	current, ok := r.byID[user.ID]
+	if !ok {
+		return false, nil
+	}
+	if owner, exists := r.byEmail[user.Email]; exists && owner != user.ID {
+		return false, ErrDuplicateKey
+	}
+
+	updated := copyUser(user)
+	updated.CreatedAt = current.CreatedAt
+	delete(r.byEmail, current.Email)
+	r.byID[user.ID] = updated
+	r.byEmail[user.Email] = user.ID
+	r.recordChange(UserChange{
+		Operation:     ChangeUpdate,
+		UserID:        user.ID,
+		User:          copyUser(updated),
+		Before:        copyUser(current),
+		UpdatedFields: ChangedUserFields(current, updated),
+	})
+	return true, nil
+}
+
+func (r *MemoryUserRepository) Delete(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.byID[id]
+	if !ok {
+		return false, nil
+	}
+	delete(r.byID, id)
+	delete(r.byEmail, user.Email)
+	r.recordChange(UserChange{Operation: ChangeDelete, UserID: id, Before: copyUser(user)})
+	return true, nil
+}
+
+func (r *MemoryUserRepository) Scan(ctx context.Context, filter UserScanFilter, limit int64) ([]*models.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var ids map[primitive.ObjectID]bool
+	if len(filter.IDs) > 0 {
+		ids = make(map[primitive.ObjectID]bool, len(filter.IDs))
+		for _, id := range filter.IDs {
+			ids[id] = true
+		}
+	}
+
+	users := make([]*models.User, 0)
+	for id, user := range r.byID {
+		switch {
+		case !filter.AfterID.IsZero() && bytes.Compare(id[:], filter.AfterID[:]) <= 0:
+		case ids != nil && !ids[id]:
+		case !filter.CreatedFrom.IsZero() && user.CreatedAt.Before(filter.CreatedFrom):
+		case !filter.CreatedTo.IsZero() && !user.CreatedAt.Before(filter.CreatedTo):
+		default:
+			users = append(users, copyUser(user))
+		}
+	}
+
+	sort.Slice(users, func(i, j int) bool {
+		return bytes.Compare(users[i].ID[:], users[j].ID[:]) < 0
+	})
+	if limit > 0 && int64(len(users)) > limit {
+		users = users[:limit]
+	}
+	return users, nil
+}
+
+// insert deve ser chamado com o lock de escrita.
+func (r *MemoryUserRepository) insert(user *models.User) error {
+	if _, exists := r.byEmail[user.Email]; exists {
+		return ErrDuplicateKey
+	}
+	if user.ID.IsZero() {
+		user.ID = primitive.NewObjectID()
+	}
+	if _, exists := r.byID[user.ID]; exists {
+		return ErrDuplicateKey
+	}
+
+	r.byID[user.ID] = copyUser(user)
+	r.byEmail[user.Email] = user.ID
+	r.recordChange(UserChange{Operation: ChangeInsert, UserID: user.ID, User: copyUser(user)})
+	return nil
+}
+
+// WatchChanges entrega as mudanças do log a partir do resume token (a sequência da
+// última mudança processada).
+func (r *MemoryUserRepository) WatchChanges(ctx context.Context, resumeToken string, handler func(ctx context.Context, change UserChange) error) error {
+	// Acorda o watcher bloqueado em changed.Wait quando o contexto é cancelado
+	stop := context.AfterFunc(ctx, func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.changed.Broadcast()
+	})
+	defer stop()
+
+	r.mu.Lock()
+	next := r.changesBase + int64(len(r.changes))
+	r.mu.Unlock()
+	if resumeToken != "" {
+		seq, err := strconv.ParseInt(resumeToken, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid resume token %q", resumeToken)
+		}
+		next = seq + 1
+	}
+
+	for {
+		change, ok, err := r.nextChange(ctx, next)
+		if err != nil || !ok {
+			return err
+		}
+		if err := handler(ctx, change); err != nil {
+			return err
+		}
+		next++
+	}
+}
+
+func (r *MemoryUserRepository) nextChange(ctx context.Context, seq int64) (UserChange, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for {
+		if ctx.Err() != nil {
+			return UserChange{}, false, nil
+		}
+		if seq < r.changesBase {
+			return UserChange{}, false, ErrChangeHistoryLost
+		}
+		if i := seq - r.changesBase; i < int64(len(r.changes)) {
+			return r.changes[i], true, nil
+		}
+		r.changed.Wait()
+	}
+}
+
+// recordChange deve ser chamado com o lock de escrita.
+func (r *MemoryUserRepository) recordChange(change UserChange) {
+	change.Token = strconv.FormatInt(r.changesBase+int64(len(r.changes)), 10)
+	change.Time = time.Now().UTC()
+	r.changes = append(r.changes, change)
+
+	if excess := len(r.changes) - memoryChangeRetention; excess > 0 {
+		r.changes = append([]UserChange(nil), r.changes[excess:]...)
+		r.changesBase += int64(excess)
+	}
+	r.changed.Broadcast()
+}
+
+// ChangedUserFields lista os campos com valor diferente entre duas versões do
+// usuário, com os nomes do BSON.
+func ChangedUserFields(before, after *models.User) []string {
+	var fields []string
+	if before.Name != after.Name {
+		fields = append(fields, "name")
+	}
+	if before.Email != after.Email {
+		fields = append(fields, "email")
+	}
+	if before.Password != after.Password {
+		fields = append(fields, "password")
+	}
+	if before.Role != after.Role {
+		fields = append(fields, "role")
+	}
+	if before.Locale != after.Locale {
+		fields = append(fields, "locale")
+	}
+	if !before.UpdatedAt.Equal(after.UpdatedAt) {
+		fields = append(fields, "updated_at")
+	}
+	if (before.DisabledAt == nil) != (after.DisabledAt == nil) ||
+		(before.DisabledAt != nil && !before.DisabledAt.Equal(*after.DisabledAt)) {
+		fields = append(fields, "disabled_at")
+	}
+	return fields
+}
+
+// copyUser evita que quem chamou altere o documento guardado, como aconteceria com
+// um documento lido do banco, e normaliza as datas como o BSON faz.
+func copyUser(user *models.User) *models.User {
+	clone := *user
+	clone.CreatedAt = bsonTime(user.CreatedAt)
+	clone.UpdatedAt = bsonTime(user.UpdatedAt)
+	if user.DisabledAt != nil {
+		disabledAt := bsonTime(*user.DisabledAt)
+		clone.DisabledAt = &disabledAt
+	}
+	return &clone
+}
+
+func bsonTime(t time.Time) time.Time {
+	return t.Truncate(time.Millisecond).UTC()
+}
@@ -0,0 +1,154 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/lucas/go-rest-api-mongo/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestMemoryUserRepositoryUniqueEmail(t *testing.T) {
+	repo := NewMemoryUserRepository()
+	ctx := context.Background()
+
+	if err := repo.Create(ctx, &models.User{Email: "ana@example.com"}); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	err := repo.Create(ctx, &models.User{Email: "ana@example.com"})
+	if !errors.Is(err, ErrDuplicateKey) {
+		t.Fatalf("Create() duplicate error = %v, want ErrDuplicateKey", err)
+	}
+}
+
+func TestMemoryUserRepositoryCreateManyIsUnordered(t *testing.T) {
+	repo := NewMemoryUserRepository()
+	ctx := context.Background()
+
+	users := []*models.User{
+		{Email: "a@example.com"},
+		{Email: "a@example.com"},
+		{Email: "b@example.com"},
+	}
+	errs := repo.CreateMany(ctx, users)
+
+	if errs[0] != nil || errs[2] != nil {
+		t.Fatalf("CreateMany() errs = %v, want only the duplicate to fail", errs)
+	}
+	if !errors.Is(errs[1], ErrDuplicateKey) {
+		t.Fatalf("CreateMany() errs[1] = %v, want ErrDuplicateKey", errs[1])
+	}
+	if users[0].ID.IsZero() || users[2].ID.IsZero() {
+		t.Fatal("CreateMany() did not assign IDs to inserted users")
+	}
+
+	found, err := repo.FindByEmails(ctx, []string{"a@example.com", "b@example.com", "c@example.com"})
+	if err != nil || len(found) != 2 {
+		t.Fatalf("FindByEmails() = %d users, %v; want 2 users", len(found), err)
+	}
+}
+
+func TestMemoryUserRepositoryNotFound(t *testing.T) {
+	repo := NewMemoryUserRepository()
+	ctx := context.Background()
+
+	user, err := repo.FindByID(ctx, primitive.NewObjectID())
+	if user != nil || err != nil {
+		t.Fatalf("FindByID() = %v, %v; want nil, nil", user, err)
+	}
+	user, err = repo.FindByEmail(ctx, "nobody@example.com")
+	if user != nil || err != nil {
+		t.Fatalf("FindByEmail() = %v, %v; want nil, nil", user, err)
+	}
+}
+
+func TestMemoryUserRepositoryStoresCopiesWithBSONTimestamps(t *testing.T) {
+	repo := NewMemoryUserRepository()
+	ctx := context.Background()
+
+	createdAt := time.Date(2024, 5, 1, 12, 0, 0, 123456789, time.FixedZone("BRT", -3*60*60))
+	user := &models.User{Email: "ana@example.com", Name: "Ana", CreatedAt: createdAt}
+	if err := repo.Create(ctx, user); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	user.Name = "changed after insert"
+
+	stored, err := repo.FindByID(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("FindByID() error = %v", err)
+	}
+	if stored.Name != "Ana" {
+		t.Errorf("stored name = %q, want the value at insert time", stored.Name)
+	}
+	if want := createdAt.Truncate(time.Millisecond).UTC(); !stored.CreatedAt.Equal(want) || stored.CreatedAt.Location() != time.UTC {
+		t.Errorf("stored CreatedAt = %v, want %v in UTC", stored.CreatedAt, want)
+	}
+}
+
+func TestMemoryUserRepositoryUpdateAndDelete(t *testing.T) {
+	repo := NewMemoryUserRepository()
+	ctx := context.Background()
+
+	ana := &models.User{Name: "Ana", Email: "ana@example.com"}
+	bia := &models.User{Name: "Bia", Email: "bia@example.com"}
+	repo.Create(ctx, ana)
+	repo.Create(ctx, bia)
+
+	ana.Email = "bia@example.com"
+	if _, err := repo.Update(ctx, ana); !errors.Is(err, ErrDuplicateKey) {
+		t.Fatalf("Update() to a taken email error = %v, want ErrDuplicateKey", err)
+	}
+
+	ana.Email = "ana.maria@example.com"
+	if found, err := repo.Update(ctx, ana); !found || err != nil {
+		t.Fatalf("Update() = %v, %v", found, err)
+	}
+	if old, _ := repo.FindByEmail(ctx, "ana@example.com"); old != nil {
+		t.Error("the old email must be released after an update")
+	}
+	if found, _ := repo.Update(ctx, &models.User{ID: primitive.NewObjectID(), Email: "x@example.com"}); found {
+		t.Error("Update() of a missing user must return false")
+	}
+
+	if deleted, err := repo.Delete(ctx, ana.ID); !deleted || err != nil {
+		t.Fatalf("Delete() = %v, %v", deleted, err)
+	}
+	if user, _ := repo.FindByEmail(ctx, "ana.maria@example.com"); user != nil {
+		t.Error("Delete() must remove the email index")
+	}
+	if deleted, _ := repo.Delete(ctx, ana.ID); deleted {
+		t.Error("Delete() of a missing user must return false")
+	}
+}
+
+func TestMemoryUserRepositoryScan(t *testing.T) {
+	repo := NewMemoryUserRepository()
+	ctx := context.Background()
+
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	users := make([]*models.User, 4)
+	for i := range users {
+		users[i] = &models.User{ID: primitive.NewObjectID(), Email: string(rune('a'+i)) + "@example.com", CreatedAt: base.AddDate(0, 0, i)}
+		repo.Create(ctx, users[i])
+	}
+
+	page, _ := repo.Scan(ctx, UserScanFilter{}, 2)
+	if len(page) != 2 || page[0].ID != users[0].ID || page[1].ID != users[1].ID {
+		t.Fatalf("first page = %v, want the two lowest IDs", page)
+	}
+	page, _ = repo.Scan(ctx, UserScanFilter{AfterID: page[1].ID}, 10)
+	if len(page) != 2 || page[0].ID != users[2].ID {
+		t.Fatalf("second page = %v, want the users after the first page", page)
+	}
+
+	page, _ = repo.Scan(ctx, UserScanFilter{CreatedFrom: base.AddDate(0, 0, 1), CreatedTo: base.AddDate(0, 0, 3)}, 10)
+	if len(page) != 2 || page[0].ID != users[1].ID || page[1].ID != users[2].ID {
+		t.Errorf("date range = %v, want users 1 and 2", page)
+	}
+	page, _ = repo.Scan(ctx, UserScanFilter{IDs: []primitive.ObjectID{users[3].ID}}, 10)
+	if len(page) != 1 || page[0].ID != users[3].ID {
+		t.Errorf("IDs filter = %v, want only user 3", page)
+	}
+}
@@ -0,0 +1,65 @@
+package repositories
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/lucas/go-rest-api-mongo/internal/database"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// RateLimitRepository guarda os contadores de rate limit no Mongo, compartilhados
+// entre todas as réplicas da API. Cada janela é um documento que expira via TTL.
+type RateLimitRepository struct {
+	collection func() *mongo.Collection
+}
+
+type rateLimitCounter struct {
+	Count int64 `bson:"count"`
+}
+
+func NewRateLimitRepository(db *database.MongoDB) *RateLimitRepository {
+	return &RateLimitRepository{
+		collection: db.Collection("rate_limits"),
+	}
+}
+
+func (r *RateLimitRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection().Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expires_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0).SetName("expires_at_ttl"),
+	})
+	return err
+}
+
+func (r *RateLimitRepository) Increment(ctx context.Context, key string, window time.Duration, now time.Time) (int64, int64, error) {
+	start := now.Truncate(window)
+
+	var current rateLimitCounter
+	err := r.collection().FindOneAndUpdate(ctx,
+		bson.M{"_id": windowID(key, start)},
+		bson.M{
+			"$inc":         bson.M{"count": 1},
+			"$setOnInsert": bson.M{"expires_at": start.Add(2 * window)},
+		},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&current)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var previous rateLimitCounter
+	err = r.collection().FindOne(ctx, bson.M{"_id": windowID(key, start.Add(-window))}).Decode(&previous)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return 0, 0, err
+	}
+
+	return current.Count, previous.Count, nil
+}
+
+func windowID(key string, start time.Time) string {
+	return key + "|" + strconv.FormatInt(start.Unix(), 10)
+}
@@ -0,0 +1,108 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/lucas/go-rest-api-mongo/internal/database"
+	"github.com/lucas/go-rest-api-mongo/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ScheduleRepository guarda um documento por job agendado na coleção schedules e as
+// execuções em schedule_runs, removidas pelo índice TTL de expires_at.
+type ScheduleRepository struct {
+	states func() *mongo.Collection
+	runs   func() *mongo.Collection
+}
+
+func NewScheduleRepository(db *database.MongoDB) *ScheduleRepository {
+	return &ScheduleRepository{
+		states: db.Collection("schedules"),
+		runs:   db.Collection("schedule_runs"),
+	}
+}
+
+// EnsureIndexes cria o índice do histórico por job e o índice TTL das execuções.
+func (r *ScheduleRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.runs().Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "job", Value: 1}, {Key: "started_at", Value: -1}},
+			Options: options.Index().SetName("job_started_at"),
+		},
+		{
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0).SetName("expires_at_ttl"),
+		},
+	})
+	return err
+}
+
+func (r *ScheduleRepository) GetState(ctx context.Context, name string) (*models.ScheduleState, error) {
+	var state models.ScheduleState
+	err := r.states().FindOne(ctx, bson.M{"_id": name}).Decode(&state)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+func (r *ScheduleRepository) SetPaused(ctx context.Context, name string, paused bool) (*models.ScheduleState, error) {
+	var state models.ScheduleState
+	err := r.states().FindOneAndUpdate(ctx,
+		bson.M{"_id": name},
+		bson.M{"$set": bson.M{"paused": paused, "updated_at": time.Now()}},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&state)
+	if err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+func (r *ScheduleRepository) SetLastScheduled(ctx context.Context, name string, at time.Time) error {
+	_, err := r.states().UpdateOne(ctx,
+		bson.M{"_id": name},
+		bson.M{"$set": bson.M{"last_scheduled_at": at, "updated_at": time.Now()}},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+func (r *ScheduleRepository) SaveRun(ctx context.Context, run *models.ScheduleRun) error {
+	if run.ID.IsZero() {
+		run.ID = primitive.NewObjectID()
+	}
+	_, err := r.runs().ReplaceOne(ctx, bson.M{"_id": run.ID}, run, options.Replace().SetUpsert(true))
+	return err
+}
+
+func (r *ScheduleRepository) ListRuns(ctx context.Context, job string, limit int64) ([]*models.ScheduleRun, error) {
+	filter := bson.M{}
+	if job != "" {
+		filter["job"] = job
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "started_at", Value: -1}}).
+		SetLimit(limit)
+
+	cursor, err := r.runs().Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	runs := make([]*models.ScheduleRun, 0)
+	if err := cursor.All(ctx, &runs); err != nil {
+		return nil, err
+	}
+	return runs, nil
+}
@@ -0,0 +1,137 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/lucas/go-rest-api-mongo/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// UserStore é implementado pelo repositório do Mongo e pelo repositório em memória.
+// Buscas sem resultado retornam (nil, nil); email duplicado retorna ErrDuplicateKey.
+type UserStore interface {
+	EnsureIndexes(ctx context.Context) error
+	Create(ctx context.Context, user *models.User) error
+	CreateMany(ctx context.Context, users []*models.User) []error
+	FindByEmail(ctx context.Context, email string) (*models.User, error)
+	FindByEmails(ctx context.Context, emails []string) ([]*models.User, error)
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.User, error)
+	// Update grava nome, email, senha, role e locale; retorna false se o usuário não existe.
+	Update(ctx context.Context, user *models.User) (bool, error)
+	Delete(ctx context.Context, id primitive.ObjectID) (bool, error)
+	// Scan pagina os usuários em ordem de ID, a partir de filter.AfterID.
+	Scan(ctx context.Context, filter UserScanFilter, limit int64) ([]*models.User, error)
+}
+
+// UserScanFilter seleciona os usuários de UserStore.Scan. Campos vazios não filtram.
+type UserScanFilter struct {
+	AfterID     primitive.ObjectID   // apenas IDs maiores que este (paginação)
+	IDs         []primitive.ObjectID // apenas estes usuários
+	CreatedFrom time.Time            // created_at >= CreatedFrom
+	CreatedTo   time.Time            // created_at < CreatedTo
+}
+
+// Operações de UserChange, com os mesmos nomes do operationType dos change streams.
+const (
+	ChangeInsert  = "insert"
+	ChangeUpdate  = "update"
+	ChangeReplace = "replace"
+	ChangeDelete  = "delete"
+)
+
+// UserChange é uma alteração na coleção de usuários, feita pela API ou fora dela.
+type UserChange struct {
+	Token         string // resume token: WatchChanges(token) continua depois desta mudança
+	Operation     string
+	UserID        primitive.ObjectID
+	User          *models.User // documento depois da mudança; nil em deletes
+	Before        *models.User // documento antes da mudança, quando disponível
+	UpdatedFields []string     // campos alterados em updates
+	Time          time.Time
+}
+
+// UserChangeStream entrega as mudanças na coleção de usuários em ordem. Sem token,
+// começa pelas mudanças feitas a partir da chamada. WatchChanges retorna nil quando
+// ctx é cancelado ou o erro do handler.
+type UserChangeStream interface {
+	WatchChanges(ctx context.Context, resumeToken string, handler func(ctx context.Context, change UserChange) error) error
+}
+
+// LeaseStore implementa leases com expiração. TryAcquire adquire um lease livre ou
+// expirado, ou renova um lease do mesmo owner; retorna false se outro owner o segura.
+type LeaseStore interface {
+	TryAcquire(ctx context.Context, name, owner string, ttl time.Duration) (bool, error)
+	Release(ctx context.Context, name, owner string) error
+	Get(ctx context.Context, name string) (*models.Lease, error)
+}
+
+// CheckpointStore guarda a posição de processos que precisam continuar de onde
+// pararam. Get sem resultado retorna (nil, nil).
+type CheckpointStore interface {
+	Get(ctx context.Context, id string) (*models.Checkpoint, error)
+	Save(ctx context.Context, checkpoint *models.Checkpoint) error
+	Delete(ctx context.Context, id string) error
+}
+
+// JobStore é a fila durável de jobs entre as réplicas da API e os workers, com uma
+// fila por tipo de job e, dentro dela, uma por lane de prioridade. Dequeue reserva os
+// jobs por visibility: um job que não é confirmado com Ack volta para a fila depois
+// desse tempo, então um worker que cai não perde o job (entrega at-least-once).
+// Enqueue retorna ErrQueueFull quando a fila tem limite.
+//
+// Dentro de uma lane, os jobs saem em ordem de FairRank, que Enqueue calcula por
+// FairKey (start-time fair queuing): o job de uma chave entra depois do último job
+// pendente da mesma chave, mas nunca antes do job mais antigo da lane. Uma chave com
+// um backlog grande não atrasa os jobs das outras: as chaves se alternam.
+type JobStore interface {
+	Enqueue(ctx context.Context, job *models.Job) error
+	Dequeue(ctx context.Context, jobType, lane, owner string, limit int, visibility time.Duration) ([]*models.Job, error)
+	Ack(ctx context.Context, ids []primitive.ObjectID) error
+	// Depth conta os jobs do tipo na lane, ou em todas com lane vazia, inclusive os
+	// reservados.
+	Depth(ctx context.Context, jobType, lane string) (int64, error)
+}
+
+type DeadLetterStore interface {
+	CreateMany(ctx context.Context, letters []*models.DeadLetter) error
+	List(ctx context.Context, kind string, limit, offset int64) ([]*models.DeadLetter, error)
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.DeadLetter, error)
+	Update(ctx context.Context, letter *models.DeadLetter) error
+	Delete(ctx context.Context, id primitive.ObjectID) (bool, error)
+	// DeleteBefore remove as dead letters criadas antes de before e diz quantas eram.
+	DeleteBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+// ScheduleStore guarda o estado dos jobs agendados, compartilhado entre as réplicas, e
+// o histórico das suas execuções. GetState sem resultado retorna (nil, nil); SaveRun
+// insere a execução (atribuindo o ID) ou a substitui. As execuções somem depois de
+// ExpiresAt.
+type ScheduleStore interface {
+	GetState(ctx context.Context, name string) (*models.ScheduleState, error)
+	SetPaused(ctx context.Context, name string, paused bool) (*models.ScheduleState, error)
+	SetLastScheduled(ctx context.Context, name string, at time.Time) error
+	SaveRun(ctx context.Context, run *models.ScheduleRun) error
+	// ListRuns devolve as execuções mais recentes primeiro; job vazio não filtra.
+	ListRuns(ctx context.Context, job string, limit int64) ([]*models.ScheduleRun, error)
+}
+
+var (
+	_ UserStore       = (*UserRepository)(nil)
+	_ UserStore       = (*MemoryUserRepository)(nil)
+	_ DeadLetterStore = (*DeadLetterRepository)(nil)
+	_ DeadLetterStore = (*MemoryDeadLetterRepository)(nil)
+	_ CheckpointStore = (*CheckpointRepository)(nil)
+	_ CheckpointStore = (*MemoryCheckpointRepository)(nil)
+	_ LeaseStore      = (*LeaseRepository)(nil)
+	_ LeaseStore      = (*MemoryLeaseRepository)(nil)
+
+	_ JobStore = (*JobRepository)(nil)
+	_ JobStore = (*MemoryJobRepository)(nil)
+
+	_ ScheduleStore = (*ScheduleRepository)(nil)
+	_ ScheduleStore = (*MemoryScheduleRepository)(nil)
+
+	_ UserChangeStream = (*UserRepository)(nil)
+	_ UserChangeStream = (*MemoryUserRepository)(nil)
+)
@@ -0,0 +1,91 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/lucas/go-rest-api-mongo/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// changeEvent é o documento de um change stream na forma que interessa a UserChange.
+type changeEvent struct {
+	ID struct {
+		Data string `bson:"_data"`
+	} `bson:"_id"`
+	OperationType string `bson:"operationType"`
+	DocumentKey   struct {
+		ID primitive.ObjectID `bson:"_id"`
+	} `bson:"documentKey"`
+	FullDocument             *models.User `bson:"fullDocument"`
+	FullDocumentBeforeChange *models.User `bson:"fullDocumentBeforeChange"`
+	UpdateDescription        struct {
+		UpdatedFields bson.M   `bson:"updatedFields"`
+		RemovedFields []string `bson:"removedFields"`
+	} `bson:"updateDescription"`
+	ClusterTime primitive.Timestamp `bson:"clusterTime"`
+}
+
+// EnableChangeStreamPreImages liga os pre-images da coleção (MongoDB 6.0+), que
+// trazem o documento apagado nos deletes. Sem eles, UserChange.Before fica nil.
+func (r *UserRepository) EnableChangeStreamPreImages(ctx context.Context) error {
+	return r.collection().Database().RunCommand(ctx, bson.D{
+		{Key: "collMod", Value: r.collection().Name()},
+		{Key: "changeStreamPreAndPostImages", Value: bson.M{"enabled": true}},
+	}).Err()
+}
+
+// WatchChanges abre um change stream na coleção users (requer replica set). Updates
+// trazem o documento atual via updateLookup.
+func (r *UserRepository) WatchChanges(ctx context.Context, resumeToken string, handler func(ctx context.Context, change UserChange) error) error {
+	pipeline := mongo.Pipeline{{{Key: "$match", Value: bson.M{
+		"operationType": bson.M{"$in": bson.A{ChangeInsert, ChangeUpdate, ChangeReplace, ChangeDelete}},
+	}}}}
+	opts := options.ChangeStream().
+		SetFullDocument(options.UpdateLookup).
+		SetFullDocumentBeforeChange(options.WhenAvailable)
+	if resumeToken != "" {
+		opts.SetResumeAfter(bson.M{"_data": resumeToken})
+	}
+
+	stream, err := r.collection().Watch(ctx, pipeline, opts)
+	if err != nil {
+		return err
+	}
+	defer stream.Close(context.Background())
+
+	for stream.Next(ctx) {
+		var event changeEvent
+		if err := stream.Decode(&event); err != nil {
+			return err
+		}
+
+		change := UserChange{
+			Token:     event.ID.Data,
+			Operation: event.OperationType,
+			UserID:    event.DocumentKey.ID,
+			User:      event.FullDocument,
+			Before:    event.FullDocumentBeforeChange,
+			Time:      time.Unix(int64(event.ClusterTime.T), 0).UTC(),
+		}
+		if event.OperationType == ChangeDelete {
+			change.User = nil
+		}
+		for field := range event.UpdateDescription.UpdatedFields {
+			change.UpdatedFields = append(change.UpdatedFields, field)
+		}
+		change.UpdatedFields = append(change.UpdatedFields, event.UpdateDescription.RemovedFields...)
+
+		if err := handler(ctx, change); err != nil {
+			return err
+		}
+	}
+
+	if ctx.Err() != nil {
+		return nil
+	}
+	return stream.Err()
+}
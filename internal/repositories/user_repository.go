@@ -2,36 +2,95 @@ package repositories
 
 import (
 	"context"
+	"errors"
 
 	"github.com/lucas/go-rest-api-mongo/internal/database"
 	"github.com/lucas/go-rest-api-mongo/internal/models"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+var ErrDuplicateKey = errors.New("duplicate key")
+
 type UserRepository struct {
-	collection *mongo.Collection
+	collection func() *mongo.Collection
 }
 
 func NewUserRepository(db *database.MongoDB) *UserRepository {
 	return &UserRepository{
-		collection: db.Database.Collection("users"),
+		collection: db.Collection("users"),
 	}
 }
 
+// EnsureIndexes cria o índice único de email, necessário para que inserts em lote
+// detectem duplicados sem uma consulta prévia por documento.
+func (r *UserRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection().Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "email", Value: 1}},
+		Options: options.Index().SetUnique(true).SetName("email_unique"),
+	})
+	return err
+}
+
 func (r *UserRepository) Create(ctx context.Context, user *models.User) error {
-	result, err := r.collection.InsertOne(ctx, user)
+	result, err := r.collection().InsertOne(ctx, user)
 	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrDuplicateKey
+		}
 		return err
 	}
 	user.ID = result.InsertedID.(primitive.ObjectID)
 	return nil
 }
 
+// CreateMany insere os usuários com um único InsertMany não ordenado.
+// O retorno tem o mesmo tamanho de users e contém o erro de cada item (nil em caso de sucesso).
+func (r *UserRepository) CreateMany(ctx context.Context, users []*models.User) []error {
+	errs := make([]error, len(users))
+	if len(users) == 0 {
+		return errs
+	}
+
+	docs := make([]interface{}, len(users))
+	for i, user := range users {
+		if user.ID.IsZero() {
+			user.ID = primitive.NewObjectID()
+		}
+		docs[i] = user
+	}
+
+	_, err := r.collection().InsertMany(ctx, docs, options.InsertMany().SetOrdered(false))
+	if err == nil {
+		return errs
+	}
+
+	var bulkErr mongo.BulkWriteException
+	if !errors.As(err, &bulkErr) || bulkErr.WriteConcernError != nil {
+		for i := range errs {
+			errs[i] = err
+		}
+		return errs
+	}
+
+	for _, writeErr := range bulkErr.WriteErrors {
+		if writeErr.Index < 0 || writeErr.Index >= len(errs) {
+			continue
+		}
+		if writeErr.HasErrorCode(11000) {
+			errs[writeErr.Index] = ErrDuplicateKey
+		} else {
+			errs[writeErr.Index] = writeErr
+		}
+	}
+	return errs
+}
+
 func (r *UserRepository) FindByEmail(ctx context.Context, email string) (*models.User, error) {
 	var user models.User
-	err := r.collection.FindOne(ctx, bson.M{"email": email}).Decode(&user)
+	err := r.collection().FindOne(ctx, bson.M{"email": email}).Decode(&user)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			return nil, nil
@@ -41,9 +100,28 @@ func (r *UserRepository) FindByEmail(ctx context.Context, email string) (*models
 	return &user, nil
 }
 
+// FindByEmails busca todos os usuários cujos emails estão na lista com uma única consulta $in.
+func (r *UserRepository) FindByEmails(ctx context.Context, emails []string) ([]*models.User, error) {
+	if len(emails) == 0 {
+		return nil, nil
+	}
+
+	cursor, err := r.collection().Find(ctx, bson.M{"email": bson.M{"$in": emails}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var users []*models.User
+	if err := cursor.All(ctx, &users); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
 func (r *UserRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.User, error) {
 	var user models.User
-	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&user)
+	err := r.collection().FindOne(ctx, bson.M{"_id": id}).Decode(&user)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			return nil, nil
@@ -53,3 +131,73 @@ func (r *UserRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*
 
 	return &user, nil
 }
+
+func (r *UserRepository) Update(ctx context.Context, user *models.User) (bool, error) {
+	update := bson.M{"$set": bson.M{
+		"name":       user.Name,
+		"email":      user.Email,
+		"password":   user.Password,
+		"role":       user.Role,
+		"locale":     user.Locale,
+		"updated_at": user.UpdatedAt,
+	}}
+	if user.DisabledAt != nil {
+		update["$set"].(bson.M)["disabled_at"] = user.DisabledAt
+	} else {
+		update["$unset"] = bson.M{"disabled_at": ""}
+	}
+
+	result, err := r.collection().UpdateByID(ctx, user.ID, update)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return false, ErrDuplicateKey
+		}
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+func (r *UserRepository) Delete(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	result, err := r.collection().DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return false, err
+	}
+	return result.DeletedCount > 0, nil
+}
+
+func (r *UserRepository) Scan(ctx context.Context, filter UserScanFilter, limit int64) ([]*models.User, error) {
+	query := bson.M{}
+	id := bson.M{}
+	if !filter.AfterID.IsZero() {
+		id["$gt"] = filter.AfterID
+	}
+	if len(filter.IDs) > 0 {
+		id["$in"] = filter.IDs
+	}
+	if len(id) > 0 {
+		query["_id"] = id
+	}
+	createdAt := bson.M{}
+	if !filter.CreatedFrom.IsZero() {
+		createdAt["$gte"] = filter.CreatedFrom
+	}
+	if !filter.CreatedTo.IsZero() {
+		createdAt["$lt"] = filter.CreatedTo
+	}
+	if len(createdAt) > 0 {
+		query["created_at"] = createdAt
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}).SetLimit(limit)
+	cursor, err := r.collection().Find(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	users := make([]*models.User, 0)
+	if err := cursor.All(ctx, &users); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
@@ -0,0 +1,228 @@
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// ErrWrongPassphrase indica que o keystore não pôde ser aberto com a passphrase.
+var ErrWrongPassphrase = errors.New("wrong keystore passphrase or corrupted keystore")
+
+// Parâmetros do scrypt recomendados para chaves derivadas de senhas.
+const (
+	scryptN      = 1 << 15
+	scryptR      = 8
+	scryptP      = 1
+	keystoreVers = 1
+)
+
+// keystoreFile é o formato do arquivo: os segredos, em JSON, cifrados com
+// AES-256-GCM usando uma chave derivada da passphrase com scrypt.
+type keystoreFile struct {
+	Version    int    `json:"version"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// Keystore é um arquivo local de segredos cifrado, gerenciado pelo comando
+// "app secrets". O arquivo só é decifrado de novo quando muda.
+type Keystore struct {
+	path       string
+	passphrase string
+
+	mu      sync.Mutex
+	modTime time.Time
+	salt    []byte
+	key     []byte
+	secrets map[string]string
+}
+
+func NewKeystore(path, passphrase string) *Keystore {
+	return &Keystore{path: path, passphrase: passphrase}
+}
+
+func (k *Keystore) Name() string { return "keystore" }
+
+func (k *Keystore) Secret(name string) (string, bool, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if err := k.load(); err != nil {
+		return "", false, err
+	}
+	value, ok := k.secrets[name]
+	return value, ok, nil
+}
+
+// Names devolve os nomes dos segredos guardados, em ordem alfabética.
+func (k *Keystore) Names() ([]string, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if err := k.load(); err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(k.secrets))
+	for name := range k.secrets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Set guarda um segredo, criando o keystore se ele não existir.
+func (k *Keystore) Set(name, value string) error {
+	return k.update(func(secrets map[string]string) { secrets[name] = value })
+}
+
+// Delete remove um segredo; remover um segredo ausente não é um erro.
+func (k *Keystore) Delete(name string) error {
+	return k.update(func(secrets map[string]string) { delete(secrets, name) })
+}
+
+func (k *Keystore) update(apply func(secrets map[string]string)) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if err := k.load(); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	secrets := make(map[string]string, len(k.secrets)+1)
+	for name, value := range k.secrets {
+		secrets[name] = value
+	}
+	apply(secrets)
+	return k.save(secrets)
+}
+
+// load decifra o arquivo se ele mudou desde a última leitura.
+func (k *Keystore) load() error {
+	info, err := os.Stat(k.path)
+	if err != nil {
+		return err
+	}
+	if k.secrets != nil && info.ModTime().Equal(k.modTime) {
+		return nil
+	}
+
+	data, err := os.ReadFile(k.path)
+	if err != nil {
+		return err
+	}
+	var file keystoreFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("reading keystore %s: %w", k.path, err)
+	}
+	if file.Version != keystoreVers {
+		return fmt.Errorf("keystore %s: unsupported version %d", k.path, file.Version)
+	}
+
+	key, err := k.deriveKey(file.Salt)
+	if err != nil {
+		return err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return err
+	}
+	plaintext, err := gcm.Open(nil, file.Nonce, file.Ciphertext, nil)
+	if err != nil {
+		return ErrWrongPassphrase
+	}
+
+	secrets := map[string]string{}
+	if err := json.Unmarshal(plaintext, &secrets); err != nil {
+		return fmt.Errorf("keystore %s: %w", k.path, err)
+	}
+	k.secrets, k.modTime = secrets, info.ModTime()
+	return nil
+}
+
+// save cifra os segredos com um nonce novo e troca o arquivo de forma atômica.
+func (k *Keystore) save(secrets map[string]string) error {
+	if k.salt == nil {
+		k.salt = make([]byte, 16)
+		if _, err := rand.Read(k.salt); err != nil {
+			return err
+		}
+	}
+	key, err := k.deriveKey(k.salt)
+	if err != nil {
+		return err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return err
+	}
+
+	plaintext, err := json.Marshal(secrets)
+	if err != nil {
+		return err
+	}
+	file := keystoreFile{Version: keystoreVers, Salt: k.salt, Nonce: make([]byte, gcm.NonceSize())}
+	if _, err := rand.Read(file.Nonce); err != nil {
+		return err
+	}
+	file.Ciphertext = gcm.Seal(nil, file.Nonce, plaintext, nil)
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(k.path), ".keystore-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), k.path); err != nil {
+		return err
+	}
+
+	// Força a próxima leitura a usar o arquivo gravado
+	k.secrets, k.modTime = nil, time.Time{}
+	return nil
+}
+
+// deriveKey deriva a chave da passphrase; o scrypt é lento de propósito, então a
+// chave do último salt fica em memória.
+func (k *Keystore) deriveKey(salt []byte) ([]byte, error) {
+	if k.key != nil && string(salt) == string(k.salt) {
+		return k.key, nil
+	}
+	if k.passphrase == "" {
+		return nil, errors.New("keystore passphrase is empty")
+	}
+	key, err := scrypt.Key([]byte(k.passphrase), salt, scryptN, scryptR, scryptP, 32)
+	if err != nil {
+		return nil, err
+	}
+	k.salt, k.key = salt, key
+	return key, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
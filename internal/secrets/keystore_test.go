@@ -0,0 +1,83 @@
+package secrets
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestKeystoreRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secrets.keystore")
+	keystore := NewKeystore(path, "correct horse")
+
+	if _, _, err := keystore.Secret("JWT_SECRET"); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("Secret() before the first Set error = %v, want ErrNotExist", err)
+	}
+	if err := keystore.Set("JWT_SECRET", "s3cret"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if err := keystore.Set("MONGO_PASSWORD", "mongo"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if err := keystore.Delete("MONGO_PASSWORD"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("keystore mode = %v, want 0600", info.Mode().Perm())
+	}
+	data, _ := os.ReadFile(path)
+	if strings.Contains(string(data), "s3cret") || strings.Contains(string(data), "JWT_SECRET") {
+		t.Fatalf("keystore file has the secrets in plain text: %s", data)
+	}
+
+	// Outro processo, com a mesma passphrase, lê o que foi gravado
+	reopened := NewKeystore(path, "correct horse")
+	if value, ok, err := reopened.Secret("JWT_SECRET"); err != nil || !ok || value != "s3cret" {
+		t.Errorf("Secret(JWT_SECRET) = %q, %v, %v; want s3cret", value, ok, err)
+	}
+	if names, err := reopened.Names(); err != nil || !slices.Equal(names, []string{"JWT_SECRET"}) {
+		t.Errorf("Names() = %v, %v; want [JWT_SECRET]", names, err)
+	}
+}
+
+func TestKeystoreWrongPassphrase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secrets.keystore")
+	if err := NewKeystore(path, "right").Set("JWT_SECRET", "s3cret"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	wrong := NewKeystore(path, "wrong")
+	if _, _, err := wrong.Secret("JWT_SECRET"); !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("Secret() error = %v, want ErrWrongPassphrase", err)
+	}
+	if err := wrong.Set("OTHER", "value"); !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("Set() error = %v, want ErrWrongPassphrase (the keystore must not be overwritten)", err)
+	}
+}
+
+func TestKeystoreSeesChangesFromOtherProcesses(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secrets.keystore")
+	reader := NewKeystore(path, "pass")
+	writer := NewKeystore(path, "pass")
+
+	if err := writer.Set("JWT_SECRET", "v1"); err != nil {
+		t.Fatal(err)
+	}
+	if value, _, _ := reader.Secret("JWT_SECRET"); value != "v1" {
+		t.Fatalf("Secret() = %q, want v1", value)
+	}
+	if err := writer.Set("JWT_SECRET", "v2"); err != nil {
+		t.Fatal(err)
+	}
+	if value, _, _ := reader.Secret("JWT_SECRET"); value != "v2" {
+		t.Errorf("Secret() after a rotation = %q, want v2", value)
+	}
+}
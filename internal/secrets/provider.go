@@ -0,0 +1,90 @@
+package secrets
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// SecretProvider devolve segredos pelo nome da variável de ambiente correspondente
+// (ex.: JWT_SECRET). Os valores são lidos a cada chamada, para que segredos
+// rotacionados na origem sejam vistos sem reiniciar o processo.
+type SecretProvider interface {
+	Name() string
+	// Secret devolve ok false quando o provider não tem o segredo.
+	Secret(name string) (value string, ok bool, err error)
+}
+
+// Chain consulta os providers em ordem e devolve o primeiro valor encontrado.
+type Chain []SecretProvider
+
+func (c Chain) Name() string {
+	names := make([]string, len(c))
+	for i, provider := range c {
+		names[i] = provider.Name()
+	}
+	return strings.Join(names, ",")
+}
+
+func (c Chain) Secret(name string) (string, bool, error) {
+	for _, provider := range c {
+		value, ok, err := provider.Secret(name)
+		if err != nil {
+			return "", false, fmt.Errorf("%s secrets: %w", provider.Name(), err)
+		}
+		if ok {
+			return value, true, nil
+		}
+	}
+	return "", false, nil
+}
+
+// EnvProvider lê os segredos das variáveis de ambiente do processo.
+type EnvProvider struct{}
+
+func NewEnvProvider() EnvProvider {
+	return EnvProvider{}
+}
+
+func (EnvProvider) Name() string { return "env" }
+
+func (EnvProvider) Secret(name string) (string, bool, error) {
+	value, ok := os.LookupEnv(name)
+	return value, ok && value != "", nil
+}
+
+// FileProvider lê cada segredo de um arquivo em dir com o nome do segredo em
+// minúsculas, como os secrets montados pelo Docker e pelo Kubernetes (ex.:
+// /run/secrets/jwt_secret).
+type FileProvider struct {
+	dir string
+}
+
+func NewFileProvider(dir string) *FileProvider {
+	return &FileProvider{dir: dir}
+}
+
+func (p *FileProvider) Name() string { return "file" }
+
+func (p *FileProvider) Secret(name string) (string, bool, error) {
+	value, err := ReadFile(filepath.Join(p.dir, strings.ToLower(name)))
+	if errors.Is(err, os.ErrNotExist) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return value, true, nil
+}
+
+// ReadFile lê um segredo de um arquivo, sem a quebra de linha final que editores e
+// "echo" costumam deixar.
+func ReadFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
@@ -0,0 +1,59 @@
+package secrets
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileProvider(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "jwt_secret"), []byte("s3cret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	provider := NewFileProvider(dir)
+
+	if value, ok, err := provider.Secret("JWT_SECRET"); err != nil || !ok || value != "s3cret" {
+		t.Errorf("Secret(JWT_SECRET) = %q, %v, %v; want s3cret without the newline", value, ok, err)
+	}
+	if _, ok, err := provider.Secret("MONGO_PASSWORD"); err != nil || ok {
+		t.Errorf("Secret(MONGO_PASSWORD) = %v, %v; want not found", ok, err)
+	}
+}
+
+// staticProvider é um provider de teste com valores fixos.
+type staticProvider struct {
+	name   string
+	values map[string]string
+	err    error
+}
+
+func (p staticProvider) Name() string { return p.name }
+
+func (p staticProvider) Secret(name string) (string, bool, error) {
+	value, ok := p.values[name]
+	return value, ok, p.err
+}
+
+func TestChain(t *testing.T) {
+	chain := Chain{
+		staticProvider{name: "first", values: map[string]string{"A": "from first"}},
+		staticProvider{name: "second", values: map[string]string{"A": "from second", "B": "from second"}},
+	}
+
+	if value, _, _ := chain.Secret("A"); value != "from first" {
+		t.Errorf("Secret(A) = %q, want the first provider to win", value)
+	}
+	if value, _, _ := chain.Secret("B"); value != "from second" {
+		t.Errorf("Secret(B) = %q, want the fallback to the second provider", value)
+	}
+	if _, ok, err := chain.Secret("C"); ok || err != nil {
+		t.Errorf("Secret(C) = %v, %v; want not found", ok, err)
+	}
+
+	broken := Chain{staticProvider{name: "vault", err: errors.New("unreachable")}}
+	if _, _, err := broken.Secret("A"); err == nil || err.Error() != "vault secrets: unreachable" {
+		t.Errorf("Secret() error = %v, want it to name the provider", err)
+	}
+}
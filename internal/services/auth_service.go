@@ -1,27 +1,36 @@
 package services
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/lucas/go-rest-api-mongo/internal/config"
-	"go.mongodb.org/mongo-driver/bson/primitive"
+	"github.com/lucas/go-rest-api-mongo/internal/models"
 	"golang.org/x/crypto/bcrypt"
 )
 
 type AuthService struct {
-	jwtSecret  string
+	keys       *keyRing
 	expiration time.Duration
 }
 
 func NewAuthService(cfg *config.Config) *AuthService {
 	return &AuthService{
-		jwtSecret:  cfg.JWT.SecretKey,
+		keys:       newKeyRing(cfg.JWT.SecretKey),
 		expiration: cfg.JWT.Expiration,
 	}
 }
+
+// RotateSecret passa a assinar os tokens com secret. Os tokens assinados com o
+// segredo anterior continuam aceitos até a validade configurada deles acabar.
+func (s *AuthService) RotateSecret(secret string) {
+	s.keys.rotate(secret, s.expiration)
+}
 func (s *AuthService) HashPassword(password string) (string, error) {
 	hashedBytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
@@ -34,17 +43,26 @@ func (s *AuthService) ComparePassword(hashedPassword, password string) error {
 	return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
 }
 
-func (s *AuthService) GenerateToken(userID primitive.ObjectID, email string) (string, error) {
+func (s *AuthService) GenerateToken(user *models.User) (string, error) {
+	return s.MintToken(user, s.expiration)
+}
+
+// MintToken gera um token com validade ttl, em vez da validade configurada.
+func (s *AuthService) MintToken(user *models.User, ttl time.Duration) (string, error) {
 	now := time.Now()
 	claims := jwt.MapClaims{
-		"user_id": userID.Hex(),
-		"email":   email,
-		"exp":     now.Add(s.expiration).Unix(),
+		"user_id": user.ID.Hex(),
+		"email":   user.Email,
+		"role":    user.Role,
+		"locale":  user.Locale,
+		"exp":     now.Add(ttl).Unix(),
 		"iat":     now.Unix(),
 	}
 
+	key := s.keys.signing()
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(s.jwtSecret))
+	token.Header["kid"] = key.id
+	return token.SignedString(key.secret)
 }
 
 func (s *AuthService) ValidateToken(tokenString string) (*jwt.Token, error) {
@@ -53,7 +71,7 @@ func (s *AuthService) ValidateToken(tokenString string) (*jwt.Token, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
-		return []byte(s.jwtSecret), nil
+		return s.keys.verification(token.Header["kid"])
 	})
 
 	if err != nil {
@@ -66,3 +84,99 @@ func (s *AuthService) ValidateToken(tokenString string) (*jwt.Token, error) {
 
 	return token, nil
 }
+
+// InspectToken decodifica as claims mesmo de um token inválido (expirado ou com outra
+// assinatura). O erro diz por que o token seria recusado; claims só é nil quando o
+// token nem pode ser decodificado.
+func (s *AuthService) InspectToken(tokenString string) (jwt.MapClaims, error) {
+	token, err := s.ValidateToken(tokenString)
+	if err == nil {
+		claims, _ := token.Claims.(jwt.MapClaims)
+		return claims, nil
+	}
+
+	claims := jwt.MapClaims{}
+	if _, _, parseErr := jwt.NewParser().ParseUnverified(tokenString, claims); parseErr != nil {
+		return nil, err
+	}
+	return claims, err
+}
+
+// signingKey é um segredo do keyRing. id vai no header kid dos tokens, para que a
+// verificação use a chave certa depois de uma rotação.
+type signingKey struct {
+	id        string
+	secret    []byte
+	expiresAt time.Time // só nas chaves aposentadas
+}
+
+// keyRing guarda a chave que assina os tokens e as aposentadas por rotações, que
+// continuam válidas para verificação até os tokens assinados com elas expirarem.
+type keyRing struct {
+	mu      sync.RWMutex
+	current signingKey
+	retired []signingKey
+}
+
+func newKeyRing(secret string) *keyRing {
+	return &keyRing{current: newSigningKey(secret)}
+}
+
+func newSigningKey(secret string) signingKey {
+	sum := sha256.Sum256([]byte(secret))
+	return signingKey{id: hex.EncodeToString(sum[:8]), secret: []byte(secret)}
+}
+
+func (r *keyRing) rotate(secret string, grace time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := newSigningKey(secret)
+	if key.id == r.current.id {
+		return
+	}
+	retired := r.current
+	retired.expiresAt = time.Now().Add(grace)
+	r.retired = append(r.live(), retired)
+	r.current = key
+}
+
+func (r *keyRing) signing() signingKey {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.current
+}
+
+// verification devolve a chave do kid ou, em tokens sem kid (emitidos antes do key
+// ring), todas as chaves válidas.
+func (r *keyRing) verification(kid interface{}) (interface{}, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	keys := append([]signingKey{r.current}, r.live()...)
+	if kid == nil {
+		set := jwt.VerificationKeySet{}
+		for _, key := range keys {
+			set.Keys = append(set.Keys, key.secret)
+		}
+		return set, nil
+	}
+	for _, key := range keys {
+		if key.id == kid {
+			return key.secret, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown signing key %v", kid)
+}
+
+// live devolve as chaves aposentadas que ainda não expiraram.
+func (r *keyRing) live() []signingKey {
+	now := time.Now()
+	var live []signingKey
+	for _, key := range r.retired {
+		if now.Before(key.expiresAt) {
+			live = append(live, key)
+		}
+	}
+	return live
+}
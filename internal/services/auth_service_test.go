@@ -0,0 +1,70 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lucas/go-rest-api-mongo/internal/config"
+	"github.com/lucas/go-rest-api-mongo/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestAuthServiceInspectToken(t *testing.T) {
+	service := NewAuthService(&config.Config{JWT: config.JWTConfig{SecretKey: "test-secret", Expiration: time.Hour}})
+	user := &models.User{ID: primitive.NewObjectID(), Email: "ana@example.com", Role: models.RoleAdmin}
+
+	valid, _ := service.MintToken(user, time.Minute)
+	claims, err := service.InspectToken(valid)
+	if err != nil || claims["email"] != user.Email {
+		t.Fatalf("InspectToken(valid) = %v, %v", claims, err)
+	}
+
+	// Expirado ou assinado com outro segredo: as claims vêm junto com o motivo
+	expired, _ := service.MintToken(user, -time.Minute)
+	other := NewAuthService(&config.Config{JWT: config.JWTConfig{SecretKey: "other-secret"}})
+	for _, tc := range []struct {
+		name      string
+		inspector *AuthService
+		token     string
+	}{
+		{"expired", service, expired},
+		{"wrong secret", other, valid},
+	} {
+		claims, err := tc.inspector.InspectToken(tc.token)
+		if err == nil || claims["user_id"] != user.ID.Hex() {
+			t.Errorf("InspectToken(%s) = %v, %v; want the claims and an error", tc.name, claims, err)
+		}
+	}
+
+	if claims, err := service.InspectToken("not-a-jwt"); claims != nil || err == nil {
+		t.Errorf("InspectToken(garbage) = %v, %v; want nil claims and an error", claims, err)
+	}
+}
+
+func TestAuthServiceRotateSecret(t *testing.T) {
+	service := NewAuthService(&config.Config{JWT: config.JWTConfig{SecretKey: "first-secret", Expiration: time.Hour}})
+	user := &models.User{ID: primitive.NewObjectID(), Email: "ana@example.com", Role: models.RoleUser}
+	before, _ := service.GenerateToken(user)
+
+	service.RotateSecret("second-secret")
+	after, _ := service.GenerateToken(user)
+
+	// Tokens anteriores à rotação continuam válidos durante a validade deles
+	for name, token := range map[string]string{"before": before, "after": after} {
+		if _, err := service.ValidateToken(token); err != nil {
+			t.Errorf("ValidateToken(%s the rotation) error = %v", name, err)
+		}
+	}
+	if _, err := NewAuthService(&config.Config{JWT: config.JWTConfig{SecretKey: "second-secret"}}).ValidateToken(after); err != nil {
+		t.Errorf("token minted after the rotation is not signed with the new secret: %v", err)
+	}
+
+	// Sem período de validade, a chave aposentada deixa de valer na hora
+	service.keys.rotate("third-secret", 0)
+	if _, err := service.ValidateToken(after); err == nil {
+		t.Error("ValidateToken() accepted a token of an expired key")
+	}
+	if _, err := service.ValidateToken(before); err != nil {
+		t.Errorf("ValidateToken() of a key still in its grace period error = %v", err)
+	}
+}
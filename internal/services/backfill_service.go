@@ -0,0 +1,212 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/lucas/go-rest-api-mongo/internal/events"
+	"github.com/lucas/go-rest-api-mongo/internal/messaging"
+	"github.com/lucas/go-rest-api-mongo/internal/models"
+	"github.com/lucas/go-rest-api-mongo/internal/repositories"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// BackfillOptions configura uma execução de BackfillService.Run.
+type BackfillOptions struct {
+	Name      string // ID do checkpoint; execuções com o mesmo nome continuam de onde pararam
+	Filter    repositories.UserScanFilter
+	BatchSize int64
+	Rate      float64 // usuários por segundo; 0 não limita
+	DryRun    bool    // só percorre os usuários, sem publicar nem gravar checkpoint
+	Restart   bool    // ignora o checkpoint existente e começa do início
+}
+
+type BackfillResult struct {
+	RunID     string
+	Resumed   bool
+	Scanned   int64 // usuários lidos nesta execução
+	Published int64 // mensagens publicadas nesta execução
+	Total     int64 // usuários processados desde o início da execução, somando retomadas
+}
+
+// BackfillService republica os eventos do ciclo de vida de usuários existentes, para
+// que consumidores novos recebam o estado atual de cada um. O registro, com a data de
+// cadastro original, já traz o perfil atual; um usuário desativado também recebe o
+// UserUpdated de disabled_at que a API publica ao desativá-lo. Usuários apagados não
+// estão mais na coleção e não geram eventos. As mensagens levam o header
+// events.HeaderReplay.
+type BackfillService struct {
+	users         repositories.UserStore
+	checkpoints   repositories.CheckpointStore
+	registrations *events.Publisher
+	lifecycle     *events.Publisher
+}
+
+func NewBackfillService(
+	users repositories.UserStore,
+	checkpoints repositories.CheckpointStore,
+	registrations *events.Publisher,
+	lifecycle *events.Publisher) *BackfillService {
+
+	return &BackfillService{
+		users:         users,
+		checkpoints:   checkpoints,
+		registrations: registrations,
+		lifecycle:     lifecycle,
+	}
+}
+
+// Run percorre os usuários em ordem de ID e grava o checkpoint depois de cada lote
+// publicado. Se a execução for interrompida, rodar de novo com o mesmo nome continua
+// do último lote confirmado; um checkpoint concluído só pega usuários criados depois.
+func (s *BackfillService) Run(ctx context.Context, opts BackfillOptions) (BackfillResult, error) {
+	opts.BatchSize = backfillBatchSize(opts.BatchSize, opts.Rate)
+
+	checkpoint, err := s.startCheckpoint(ctx, opts)
+	if err != nil {
+		return BackfillResult{}, err
+	}
+
+	result := BackfillResult{RunID: checkpoint.RunID, Resumed: checkpoint.Position != "", Total: checkpoint.Processed}
+	filter := opts.Filter
+	if checkpoint.Position != "" {
+		if filter.AfterID, err = primitive.ObjectIDFromHex(checkpoint.Position); err != nil {
+			return result, fmt.Errorf("invalid checkpoint position %q: %w", checkpoint.Position, err)
+		}
+	}
+
+	started := time.Now()
+	for {
+		users, err := s.users.Scan(ctx, filter, opts.BatchSize)
+		if err != nil {
+			return result, err
+		}
+		if len(users) == 0 {
+			return result, nil
+		}
+
+		messages := make([]messaging.Message, 0, 2*len(users))
+		for _, user := range users {
+			userMessages, err := s.messages(user)
+			if err != nil {
+				return result, fmt.Errorf("encoding events for user %s: %w", user.ID.Hex(), err)
+			}
+			messages = append(messages, userMessages...)
+		}
+		messages = events.MarkReplay(messages, checkpoint.RunID)
+
+		last := users[len(users)-1].ID
+		if opts.DryRun {
+			log.Printf("[dry-run] would publish %d messages for %d users (up to %s)", len(messages), len(users), last.Hex())
+		} else {
+			if err := s.registrations.PublishBatch(ctx, messages); err != nil {
+				return result, fmt.Errorf("publishing batch after %s: %w", filter.AfterID.Hex(), err)
+			}
+			checkpoint.Position = last.Hex()
+			checkpoint.Processed += int64(len(users))
+			checkpoint.UpdatedAt = time.Now()
+			if err := s.checkpoints.Save(ctx, checkpoint); err != nil {
+				return result, fmt.Errorf("saving checkpoint: %w", err)
+			}
+			result.Published += int64(len(messages))
+		}
+
+		result.Scanned += int64(len(users))
+		result.Total += int64(len(users))
+		filter.AfterID = last
+
+		if err := sleepContext(ctx, rateDelay(opts.Rate, result.Scanned, time.Since(started))); err != nil {
+			return result, err
+		}
+	}
+}
+
+// messages monta as mensagens dos eventos do usuário, na ordem em que a API os publica.
+func (s *BackfillService) messages(user *models.User) ([]messaging.Message, error) {
+	messages, err := s.registrations.Messages(events.NewUserRegistered(user))
+	if err != nil || !user.Disabled() {
+		return messages, err
+	}
+
+	disabled, err := s.lifecycle.Messages(&events.UserUpdated{
+		UserID:    user.ID.Hex(),
+		Email:     user.Email,
+		Name:      user.Name,
+		Changed:   []string{"disabled_at"},
+		UpdatedAt: user.DisabledAt.UTC(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return append(messages, disabled...), nil
+}
+
+// startCheckpoint carrega o checkpoint da execução ou cria um novo. Retomar com
+// filtros diferentes publicaria um conjunto de usuários diferente, então é um erro.
+func (s *BackfillService) startCheckpoint(ctx context.Context, opts BackfillOptions) (*models.Checkpoint, error) {
+	filter := describeScanFilter(opts.Filter)
+
+	if !opts.Restart {
+		checkpoint, err := s.checkpoints.Get(ctx, opts.Name)
+		if err != nil {
+			return nil, err
+		}
+		if checkpoint != nil {
+			if checkpoint.Filter != filter {
+				return nil, fmt.Errorf("%w (%q, now %q): use restart to start over", ErrCheckpointMismatch, checkpoint.Filter, filter)
+			}
+			return checkpoint, nil
+		}
+	}
+
+	return &models.Checkpoint{
+		ID:     opts.Name,
+		RunID:  fmt.Sprintf("%s-%s", opts.Name, time.Now().UTC().Format("20060102T150405Z")),
+		Filter: filter,
+	}, nil
+}
+
+// backfillBatchSize limita o lote à taxa: um lote inteiro é publicado de uma vez, então
+// um lote maior que rate sairia em rajada e só depois esperaria.
+func backfillBatchSize(size int64, rate float64) int64 {
+	if size <= 0 {
+		size = 100
+	}
+	if rate > 0 && float64(size) > rate {
+		size = max(1, int64(rate))
+	}
+	return size
+}
+
+// rateDelay é quanto esperar para que done itens em elapsed não passem de rate por segundo.
+func rateDelay(rate float64, done int64, elapsed time.Duration) time.Duration {
+	if rate <= 0 {
+		return 0
+	}
+	target := time.Duration(float64(done) / rate * float64(time.Second))
+	if target <= elapsed {
+		return 0
+	}
+	return target - elapsed
+}
+
+func describeScanFilter(filter repositories.UserScanFilter) string {
+	var parts []string
+	if !filter.CreatedFrom.IsZero() {
+		parts = append(parts, "from="+filter.CreatedFrom.UTC().Format(time.RFC3339))
+	}
+	if !filter.CreatedTo.IsZero() {
+		parts = append(parts, "to="+filter.CreatedTo.UTC().Format(time.RFC3339))
+	}
+	if len(filter.IDs) > 0 {
+		ids := make([]string, len(filter.IDs))
+		for i, id := range filter.IDs {
+			ids[i] = id.Hex()
+		}
+		parts = append(parts, "ids="+strings.Join(ids, ","))
+	}
+	return strings.Join(parts, " ")
+}
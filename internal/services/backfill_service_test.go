@@ -0,0 +1,182 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/lucas/go-rest-api-mongo/internal/dto"
+	"github.com/lucas/go-rest-api-mongo/internal/events"
+	"github.com/lucas/go-rest-api-mongo/internal/models"
+	"github.com/lucas/go-rest-api-mongo/internal/repositories"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func newTestBackfill(t *testing.T, users int) (*BackfillService, *flakyBus, *repositories.MemoryCheckpointRepository, []*models.User) {
+	service, bus, checkpoints, _, created := newTestBackfillWithRepo(t, users)
+	return service, bus, checkpoints, created
+}
+
+func newTestBackfillWithRepo(t *testing.T, users int) (*BackfillService, *flakyBus, *repositories.MemoryCheckpointRepository, *repositories.MemoryUserRepository, []*models.User) {
+	t.Helper()
+	userService, repo := newTestUserService()
+
+	reqs := make([]*dto.RegisterRequest, users)
+	for i := range reqs {
+		reqs[i] = &dto.RegisterRequest{Name: "User", Email: string(rune('a'+i)) + "@example.com", Password: "secret123"}
+	}
+	created := make([]*models.User, 0, users)
+	for _, result := range userService.RegisterBatch(context.Background(), reqs) {
+		if result.Err != nil {
+			t.Fatalf("RegisterBatch() error = %v", result.Err)
+		}
+		created = append(created, result.User)
+	}
+
+	bus := newFlakyBus(0, nil)
+	checkpoints := repositories.NewMemoryCheckpointRepository()
+	encoder, _ := events.NewEncoder(events.ModeStructured, "/test", "http://localhost/schemas/events", nil)
+	registrations := events.NewPublisher(bus, encoder, testTopic)
+	lifecycle := events.NewPublisher(bus, encoder, testEventsTopic)
+	return NewBackfillService(repo, checkpoints, registrations, lifecycle), bus, checkpoints, repo, created
+}
+
+func TestBackfillPublishesMarkedEvents(t *testing.T) {
+	service, bus, checkpoints, users := newTestBackfill(t, 5)
+	ctx := context.Background()
+
+	result, err := service.Run(ctx, BackfillOptions{Name: "test", BatchSize: 2})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if result.Scanned != 5 || result.Published != 5 || result.Resumed {
+		t.Errorf("result = %+v, want 5 users published in a fresh run", result)
+	}
+
+	messages := bus.Messages(testTopic)
+	if len(messages) != 5 {
+		t.Fatalf("published %d messages, want 5", len(messages))
+	}
+	for _, msg := range messages {
+		if !events.IsReplay(msg) || msg.Headers[events.HeaderReplay] != result.RunID {
+			t.Errorf("message headers = %v, want the replay marker %s", msg.Headers, result.RunID)
+		}
+	}
+
+	checkpoint, _ := checkpoints.Get(ctx, "test")
+	if checkpoint == nil || checkpoint.Position != users[4].ID.Hex() || checkpoint.Processed != 5 {
+		t.Errorf("checkpoint = %+v, want the last user and 5 processed", checkpoint)
+	}
+}
+
+func TestBackfillPublishesDisabledState(t *testing.T) {
+	service, bus, _, repo, users := newTestBackfillWithRepo(t, 3)
+	ctx := context.Background()
+
+	disabledAt := time.Now().UTC().Truncate(time.Millisecond)
+	users[1].DisabledAt = &disabledAt
+	if _, err := repo.Update(ctx, users[1]); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	result, err := service.Run(ctx, BackfillOptions{Name: "state"})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if result.Scanned != 3 || result.Published != 4 {
+		t.Errorf("result = %+v, want 3 registrations and 1 update", result)
+	}
+
+	// Só o usuário desativado recebe o UserUpdated, depois do seu registro
+	updates := bus.Messages(testEventsTopic)
+	if len(updates) != 1 || updates[0].Key != users[1].ID.Hex() || !events.IsReplay(updates[0]) {
+		t.Fatalf("user-events messages = %+v, want the disabled user's update marked as replay", updates)
+	}
+	ce, err := events.Decode(updates[0])
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	var event events.UserUpdated
+	if err := ce.DataAs(&event); err != nil || ce.Type != events.TypeUserUpdated || len(event.Changed) != 1 || event.Changed[0] != "disabled_at" || !event.UpdatedAt.Equal(disabledAt) {
+		t.Errorf("update = %s %+v, %v, want disabled_at changed at %s", ce.Type, event, err, disabledAt)
+	}
+}
+
+func TestBackfillResumesFromCheckpoint(t *testing.T) {
+	service, flaky, _, _ := newTestBackfill(t, 5)
+	ctx := context.Background()
+
+	// O segundo lote falha: o checkpoint fica no primeiro
+	flaky.failAfter(1, errors.New("broker down"))
+	first, err := service.Run(ctx, BackfillOptions{Name: "resume", BatchSize: 2})
+	if err == nil || first.Published != 2 {
+		t.Fatalf("Run() = %+v, %v; want an error after the first batch", first, err)
+	}
+
+	flaky.heal()
+	second, err := service.Run(ctx, BackfillOptions{Name: "resume", BatchSize: 2})
+	if err != nil {
+		t.Fatalf("Run() resume error = %v", err)
+	}
+	if !second.Resumed || second.Scanned != 3 || second.Total != 5 || second.RunID != first.RunID {
+		t.Errorf("resumed result = %+v, want the remaining 3 users in run %s", second, first.RunID)
+	}
+	if got := len(flaky.Messages(testTopic)); got != 5 {
+		t.Errorf("published %d messages in total, want 5 without duplicates", got)
+	}
+
+	// Filtros diferentes não podem reaproveitar o checkpoint
+	_, err = service.Run(ctx, BackfillOptions{Name: "resume", Filter: repositories.UserScanFilter{CreatedFrom: time.Now()}})
+	if !errors.Is(err, ErrCheckpointMismatch) {
+		t.Errorf("Run() with other filters error = %v, want ErrCheckpointMismatch", err)
+	}
+}
+
+func TestBackfillDryRunAndFilters(t *testing.T) {
+	service, bus, checkpoints, users := newTestBackfill(t, 3)
+	ctx := context.Background()
+
+	filter := repositories.UserScanFilter{IDs: []primitive.ObjectID{users[0].ID, users[2].ID}}
+	result, err := service.Run(ctx, BackfillOptions{Name: "dry", Filter: filter, DryRun: true})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if result.Scanned != 2 || result.Published != 0 {
+		t.Errorf("result = %+v, want 2 users scanned and nothing published", result)
+	}
+	if got := len(bus.Messages(testTopic)); got != 0 {
+		t.Errorf("dry run published %d messages", got)
+	}
+	if checkpoint, _ := checkpoints.Get(ctx, "dry"); checkpoint != nil {
+		t.Errorf("dry run saved checkpoint %+v", checkpoint)
+	}
+}
+
+func TestRateDelay(t *testing.T) {
+	if d := rateDelay(10, 5, 100*time.Millisecond); d != 400*time.Millisecond {
+		t.Errorf("rateDelay(10/s, 5 done, 100ms) = %s, want 400ms", d)
+	}
+	if d := rateDelay(0, 1000, 0); d != 0 {
+		t.Errorf("rateDelay without limit = %s, want 0", d)
+	}
+}
+
+func TestBackfillBatchSize(t *testing.T) {
+	tests := []struct {
+		size int64
+		rate float64
+		want int64
+	}{
+		{0, 0, 100},
+		{500, 0, 500},
+		{100, 10, 10}, // no máximo rate usuários de uma vez
+		{100, 0.5, 1}, // abaixo de 1/s, um usuário por lote
+		{20, 50, 20},  // o lote já cabe na taxa
+	}
+	for _, tt := range tests {
+		if got := backfillBatchSize(tt.size, tt.rate); got != tt.want {
+			t.Errorf("backfillBatchSize(%d, %g) = %d, want %d", tt.size, tt.rate, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,246 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/lucas/go-rest-api-mongo/internal/events"
+	"github.com/lucas/go-rest-api-mongo/internal/messaging"
+	"github.com/lucas/go-rest-api-mongo/internal/models"
+	"github.com/lucas/go-rest-api-mongo/internal/repositories"
+)
+
+// ChangeStreamCheckpoint é o ID do checkpoint com o resume token do change stream.
+const ChangeStreamCheckpoint = "cdc-users"
+
+// userProfileFields são os campos do usuário que geram UserUpdated, na ordem de Changed.
+var userProfileFields = []string{"name", "email", "role", "locale", "disabled_at"}
+
+// ChangeStreamPublisher transforma as mudanças na coleção de usuários, inclusive as
+// feitas fora da API, em eventos de domínio. Só a réplica com o lease publica, e o
+// resume token é gravado depois de cada mudança publicada: um restart continua da
+// última mudança confirmada. Se o processo cair entre a publicação e o checkpoint,
+// a mudança é publicada de novo com o mesmo id de CloudEvent, que é derivado do
+// token, para que consumidores descartem a duplicata.
+type ChangeStreamPublisher struct {
+	changes     repositories.UserChangeStream
+	checkpoints repositories.CheckpointStore
+	emitter     *EventEmitter
+	elector     *LeaderElector
+	retryPolicy RetryPolicy
+	wg          sync.WaitGroup
+	handled     atomic.Int64
+	lastChange  atomic.Int64 // UnixNano da última mudança publicada
+}
+
+// ChangeStreamStats são as métricas do publisher nesta réplica.
+type ChangeStreamStats struct {
+	Leader       bool       `json:"leader"`
+	Changes      int64      `json:"changes"`
+	LastChangeAt *time.Time `json:"last_change_at,omitempty"`
+}
+
+func NewChangeStreamPublisher(
+	changes repositories.UserChangeStream,
+	checkpoints repositories.CheckpointStore,
+	emitter *EventEmitter,
+	elector *LeaderElector,
+	retryPolicy RetryPolicy) *ChangeStreamPublisher {
+
+	return &ChangeStreamPublisher{
+		changes:     changes,
+		checkpoints: checkpoints,
+		emitter:     emitter,
+		elector:     elector,
+		retryPolicy: retryPolicy,
+	}
+}
+
+func (p *ChangeStreamPublisher) Start(ctx context.Context) {
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		p.elector.Run(ctx, p.watch)
+	}()
+}
+
+// Wait bloqueia até o watcher terminar, depois que o contexto de Start é cancelado.
+func (p *ChangeStreamPublisher) Wait() {
+	p.wg.Wait()
+}
+
+func (p *ChangeStreamPublisher) Stats() ChangeStreamStats {
+	stats := ChangeStreamStats{Leader: p.elector.IsLeader(), Changes: p.handled.Load()}
+	if nanos := p.lastChange.Load(); nanos > 0 {
+		at := time.Unix(0, nanos)
+		stats.LastChangeAt = &at
+	}
+	return stats
+}
+
+// watch acompanha o change stream enquanto esta réplica for líder, reabrindo-o a
+// partir do último checkpoint depois de um erro.
+func (p *ChangeStreamPublisher) watch(ctx context.Context) error {
+	for attempt := 1; ; attempt++ {
+		checkpoint, err := p.checkpoints.Get(ctx, ChangeStreamCheckpoint)
+		if err == nil {
+			if checkpoint == nil {
+				checkpoint = &models.Checkpoint{ID: ChangeStreamCheckpoint}
+			}
+			log.Printf("Watching user changes (resume token %q)", checkpoint.Position)
+			err = p.changes.WatchChanges(ctx, checkpoint.Position, func(ctx context.Context, change repositories.UserChange) error {
+				attempt = 1
+				return p.handle(ctx, checkpoint, change)
+			})
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		delay := p.retryPolicy.Backoff(attempt)
+		log.Printf("Change stream stopped, reopening in %s: %v", delay, err)
+		if sleepContext(ctx, delay) != nil {
+			return nil
+		}
+	}
+}
+
+// handle publica os eventos da mudança e avança o checkpoint. Um evento que não pode
+// ser codificado e, depois de esgotar os retries, as mensagens não publicadas viram
+// dead letters e o stream segue, para que uma mudança com problema não trave as
+// seguintes.
+func (p *ChangeStreamPublisher) handle(ctx context.Context, checkpoint *models.Checkpoint, change repositories.UserChange) error {
+	var messages []messaging.Message
+	var unencoded []*models.DeadLetter
+	for i, event := range changeEvents(change) {
+		id := events.NameID(fmt.Sprintf("%s/%d", change.Token, i))
+		eventMessages, err := p.emitter.Messages(events.WithID(event, id))
+		if err != nil {
+			log.Printf("Error encoding %s event from change %q: %v", event.EventType(), change.Token, err)
+			unencoded = append(unencoded, newUnencodedDeadLetter(event, err))
+			continue
+		}
+		messages = append(messages, eventMessages...)
+	}
+	p.emitter.saveDeadLetters(ctx, unencoded)
+
+	if err := p.publish(ctx, messages); err != nil {
+		return err
+	}
+
+	checkpoint.Position = change.Token
+	checkpoint.Processed++
+	checkpoint.UpdatedAt = time.Now()
+	if err := p.checkpoints.Save(ctx, checkpoint); err != nil {
+		return err
+	}
+
+	p.handled.Add(1)
+	p.lastChange.Store(checkpoint.UpdatedAt.UnixNano())
+	return nil
+}
+
+func (p *ChangeStreamPublisher) publish(ctx context.Context, messages []messaging.Message) error {
+	for attempt := 1; len(messages) > 0; attempt++ {
+		err := p.emitter.PublishBatch(ctx, messages)
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if !IsRetryable(err) || attempt >= p.retryPolicy.MaxAttempts {
+			errs := publishErrors(err, len(messages))
+			var letters []*models.DeadLetter
+			for i, message := range messages {
+				if errs[i] != nil {
+					letters = append(letters, newEventDeadLetter(message, errs[i], attempt))
+				}
+			}
+			p.emitter.saveDeadLetters(ctx, letters)
+			return nil
+		}
+
+		delay := p.retryPolicy.Backoff(attempt)
+		log.Printf("Retrying %d change events in %s (attempt %d): %v", len(messages), delay, attempt, err)
+		if err := sleepContext(ctx, delay); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// changeEvents converte uma mudança nos eventos de domínio equivalentes. Updates que
+// só tocam campos internos (ex.: updated_at) não geram eventos.
+func changeEvents(change repositories.UserChange) []events.Event {
+	switch change.Operation {
+	case repositories.ChangeInsert:
+		if change.User == nil {
+			return nil
+		}
+		return []events.Event{events.NewUserRegistered(change.User)}
+
+	case repositories.ChangeUpdate, repositories.ChangeReplace:
+		user := change.User
+		if user == nil {
+			// O documento já foi apagado; o delete gera o seu próprio evento
+			return nil
+		}
+
+		fields := change.UpdatedFields
+		if change.Operation == repositories.ChangeReplace {
+			fields = nil
+			if change.Before != nil {
+				fields = repositories.ChangedUserFields(change.Before, user)
+			}
+		}
+
+		// updated_at só é confiável quando foi gravado junto com a mudança
+		at := change.Time
+		if !user.UpdatedAt.IsZero() && (change.Operation == repositories.ChangeReplace || slices.Contains(fields, "updated_at")) {
+			at = user.UpdatedAt.UTC()
+		}
+
+		var result []events.Event
+		changed := make([]string, 0, len(userProfileFields))
+		for _, field := range userProfileFields {
+			if slices.Contains(fields, field) {
+				changed = append(changed, field)
+			}
+		}
+		// Um replace sem pre-image não diz o que mudou, mas ainda é uma mudança
+		if len(changed) > 0 || (change.Operation == repositories.ChangeReplace && change.Before == nil) {
+			result = append(result, &events.UserUpdated{
+				UserID:    user.ID.Hex(),
+				Email:     user.Email,
+				Name:      user.Name,
+				Changed:   changed,
+				UpdatedAt: at,
+			})
+		}
+		if slices.Contains(fields, "password") {
+			result = append(result, &events.UserPasswordChanged{
+				UserID:    user.ID.Hex(),
+				ChangedAt: at,
+			})
+		}
+		return result
+
+	case repositories.ChangeDelete:
+		event := &events.UserDeleted{UserID: change.UserID.Hex(), DeletedAt: change.Time}
+		// Sem pre-images (MongoDB < 6.0) o email do usuário apagado não é conhecido
+		if change.Before != nil {
+			event.Email = change.Before.Email
+		}
+		return []events.Event{event}
+
+	default:
+		return nil
+	}
+}
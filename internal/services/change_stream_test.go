@@ -0,0 +1,177 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/lucas/go-rest-api-mongo/internal/events"
+	"github.com/lucas/go-rest-api-mongo/internal/messaging"
+	"github.com/lucas/go-rest-api-mongo/internal/models"
+	"github.com/lucas/go-rest-api-mongo/internal/repositories"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func startChangeStream(t *testing.T, repo *repositories.MemoryUserRepository, bus messaging.EventBus, checkpoints repositories.CheckpointStore) func() {
+	t.Helper()
+	return startChangeStreamWith(t, repo, newTestEmitter(bus, repositories.NewMemoryDeadLetterRepository()), checkpoints)
+}
+
+func startChangeStreamWith(t *testing.T, repo *repositories.MemoryUserRepository, emitter *EventEmitter, checkpoints repositories.CheckpointStore) func() {
+	t.Helper()
+	elector := NewLeaderElector(repositories.NewMemoryLeaseRepository(), ChangeStreamCheckpoint, time.Second)
+	publisher := NewChangeStreamPublisher(repo, checkpoints, emitter, elector, testRetryPolicy)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	publisher.Start(ctx)
+	waitFor(t, elector.IsLeader)
+	// O stream começa nas mudanças feitas depois de aberto
+	time.Sleep(20 * time.Millisecond)
+	return func() {
+		cancel()
+		publisher.Wait()
+	}
+}
+
+func eventTypes(t *testing.T, messages []messaging.Message) ([]string, []string) {
+	t.Helper()
+	types := make([]string, len(messages))
+	ids := make([]string, len(messages))
+	for i, msg := range messages {
+		ce, err := events.Decode(msg)
+		if err != nil {
+			t.Fatalf("Decode() error = %v", err)
+		}
+		types[i], ids[i] = ce.Type, ce.ID
+	}
+	return types, ids
+}
+
+func TestChangeStreamPublishesChangesMadeOutsideTheAPI(t *testing.T) {
+	repo := repositories.NewMemoryUserRepository()
+	bus := messaging.NewMemoryBus(0)
+	checkpoints := repositories.NewMemoryCheckpointRepository()
+	stop := startChangeStream(t, repo, bus, checkpoints)
+	ctx := context.Background()
+
+	user := &models.User{ID: primitive.NewObjectID(), Name: "Ana", Email: "ana@example.com", Password: "hash-1", CreatedAt: time.Now()}
+	repo.Create(ctx, user)
+	user.Name, user.UpdatedAt = "Ana Maria", time.Now()
+	repo.Update(ctx, user)
+	user.Password = "hash-2"
+	repo.Update(ctx, user)
+	user.UpdatedAt = time.Now().Add(time.Second)
+	repo.Update(ctx, user) // só updated_at: sem evento
+	repo.Delete(ctx, user.ID)
+
+	want := []string{events.TypeUserRegistered, events.TypeUserUpdated, events.TypeUserPasswordChanged, events.TypeUserDeleted}
+	waitFor(t, func() bool { return len(bus.Messages(testEventsTopic)) >= len(want) })
+	stop()
+
+	messages := bus.Messages(testEventsTopic)
+	types, _ := eventTypes(t, messages)
+	if len(types) != len(want) {
+		t.Fatalf("event types = %v, want %v", types, want)
+	}
+	for i := range want {
+		if types[i] != want[i] || messages[i].Key != user.ID.Hex() {
+			t.Errorf("event %d = %s keyed %q, want %s keyed by the user id", i, types[i], messages[i].Key, want[i])
+		}
+	}
+
+	deleted, _ := events.Decode(messages[3])
+	var event events.UserDeleted
+	deleted.DataAs(&event)
+	if event.Email != "ana@example.com" {
+		t.Errorf("deleted event email = %q, want it from the pre-image", event.Email)
+	}
+
+	checkpoint, _ := checkpoints.Get(ctx, ChangeStreamCheckpoint)
+	if checkpoint == nil || checkpoint.Processed != 5 {
+		t.Errorf("checkpoint = %+v, want 5 changes processed", checkpoint)
+	}
+}
+
+func TestChangeStreamResumesFromCheckpoint(t *testing.T) {
+	repo := repositories.NewMemoryUserRepository()
+	bus := messaging.NewMemoryBus(0)
+	checkpoints := repositories.NewMemoryCheckpointRepository()
+	ctx := context.Background()
+
+	stop := startChangeStream(t, repo, bus, checkpoints)
+	repo.Create(ctx, &models.User{Name: "Ana", Email: "ana@example.com"})
+	waitFor(t, func() bool { return len(bus.Messages(testEventsTopic)) == 1 })
+	stop()
+
+	// Mudança feita com o publisher parado: deve sair no restart, sem repetir a anterior
+	repo.Create(ctx, &models.User{Name: "Bia", Email: "bia@example.com"})
+	stop = startChangeStream(t, repo, bus, checkpoints)
+	waitFor(t, func() bool { return len(bus.Messages(testEventsTopic)) >= 2 })
+	time.Sleep(50 * time.Millisecond)
+	stop()
+
+	messages := bus.Messages(testEventsTopic)
+	if len(messages) != 2 {
+		t.Fatalf("published %d events, want 2 without duplicates", len(messages))
+	}
+
+	// Republicar a partir de um checkpoint antigo gera os mesmos ids de CloudEvent
+	_, ids := eventTypes(t, messages)
+	replayBus := messaging.NewMemoryBus(0)
+	checkpoints.Save(ctx, &models.Checkpoint{ID: ChangeStreamCheckpoint, Position: "0"})
+	stop = startChangeStream(t, repo, replayBus, checkpoints)
+	waitFor(t, func() bool { return len(replayBus.Messages(testEventsTopic)) >= 2 })
+	stop()
+	_, replayedIDs := eventTypes(t, replayBus.Messages(testEventsTopic))
+	if replayedIDs[0] != ids[0] || replayedIDs[1] != ids[1] {
+		t.Errorf("replayed ids = %v, want the original ids %v", replayedIDs, ids)
+	}
+}
+
+func TestChangeStreamDeadLettersEventsThatFailToEncode(t *testing.T) {
+	repo := repositories.NewMemoryUserRepository()
+	bus := messaging.NewMemoryBus(0)
+	checkpoints := repositories.NewMemoryCheckpointRepository()
+	deadLetters := repositories.NewMemoryDeadLetterRepository()
+	emitter := newFailingEmitter(bus, deadLetters, events.TypeUserUpdated, errors.New("no schema"))
+	stop := startChangeStreamWith(t, repo, emitter, checkpoints)
+	ctx := context.Background()
+
+	user := &models.User{ID: primitive.NewObjectID(), Name: "Ana", Email: "ana@example.com", CreatedAt: time.Now()}
+	repo.Create(ctx, user)
+	user.Name = "Ana Maria"
+	repo.Update(ctx, user)
+	repo.Create(ctx, &models.User{Name: "Bia", Email: "bia@example.com"})
+
+	// A mudança que não pôde ser codificada não trava as seguintes
+	waitFor(t, func() bool { return len(bus.Messages(testEventsTopic)) >= 2 })
+	stop()
+
+	types, _ := eventTypes(t, bus.Messages(testEventsTopic))
+	if len(types) != 2 || types[0] != events.TypeUserRegistered || types[1] != events.TypeUserRegistered {
+		t.Errorf("event types = %v, want both registrations", types)
+	}
+	letters, _ := deadLetters.List(ctx, "", 10, 0)
+	if len(letters) != 1 || letters[0].Kind != models.DeadLetterKindUnencoded || letters[0].EventType != events.TypeUserUpdated {
+		t.Errorf("dead letters = %+v, want the update that failed to encode", letters)
+	}
+	checkpoint, _ := checkpoints.Get(ctx, ChangeStreamCheckpoint)
+	if checkpoint == nil || checkpoint.Processed != 3 {
+		t.Errorf("checkpoint = %+v, want 3 changes processed", checkpoint)
+	}
+}
+
+func TestEmitterWithoutStateChanges(t *testing.T) {
+	bus := messaging.NewMemoryBus(0)
+	emitter := newTestEmitter(bus, repositories.NewMemoryDeadLetterRepository()).WithoutStateChanges()
+	ctx := context.Background()
+
+	emitter.Emit(ctx, &events.UserDeleted{UserID: primitive.NewObjectID().Hex(), Email: "a@example.com", DeletedAt: time.Now()})
+	emitter.Emit(ctx, &events.UserLoggedIn{UserID: primitive.NewObjectID().Hex(), Email: "a@example.com", LoggedInAt: time.Now()})
+
+	types, _ := eventTypes(t, bus.Messages(testEventsTopic))
+	if len(types) != 1 || types[0] != events.TypeUserLoggedIn {
+		t.Errorf("published %v, want only the login event", types)
+	}
+}
@@ -0,0 +1,214 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lucas/go-rest-api-mongo/internal/events"
+	"github.com/lucas/go-rest-api-mongo/internal/messaging"
+	"github.com/lucas/go-rest-api-mongo/internal/models"
+	"github.com/lucas/go-rest-api-mongo/internal/repositories"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type DeadLetterService struct {
+	repo        repositories.DeadLetterStore
+	userService *UserService
+	emitter     *EventEmitter
+	jobs        repositories.JobStore
+}
+
+func NewDeadLetterService(
+	repo repositories.DeadLetterStore,
+	userService *UserService,
+	emitter *EventEmitter,
+	jobs repositories.JobStore) *DeadLetterService {
+
+	return &DeadLetterService{
+		repo:        repo,
+		userService: userService,
+		emitter:     emitter,
+		jobs:        jobs,
+	}
+}
+
+func (s *DeadLetterService) List(ctx context.Context, kind string, limit, offset int64) ([]*models.DeadLetter, error) {
+	return s.repo.List(ctx, kind, limit, offset)
+}
+
+func (s *DeadLetterService) Get(ctx context.Context, id primitive.ObjectID) (*models.DeadLetter, error) {
+	letter, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if letter == nil {
+		return nil, ErrDeadLetterNotFound
+	}
+	return letter, nil
+}
+
+// Replay reexecuta o job e remove a dead letter em caso de sucesso. Se o usuário for
+// criado mas o evento falhar, a dead letter passa a ser do tipo evento, para que o
+// próximo replay não tente criar o usuário de novo. O registro gera uma mensagem por
+// tópico: as que falharem viram dead letters de evento. Um evento que não pôde ser
+// codificado é codificado de novo e publicado da mesma forma. Um job de outro tipo
+// volta para a fila, com as tentativas zeradas.
+func (s *DeadLetterService) Replay(ctx context.Context, id primitive.ObjectID) error {
+	letter, err := s.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if letter.Kind == models.DeadLetterKindRegistration {
+		if letter.User == nil {
+			return errors.New("dead letter has no user to register")
+		}
+
+		if err := s.userService.CreateBatch(ctx, []*models.User{letter.User})[0]; err != nil {
+			return s.recordAttempt(ctx, letter, err)
+		}
+
+		return s.replayRegistrationEvents(ctx, letter)
+	}
+
+	if letter.Kind == models.DeadLetterKindJob {
+		return s.replayJob(ctx, letter)
+	}
+
+	if letter.Kind == models.DeadLetterKindUnencoded {
+		return s.replayUnencoded(ctx, letter)
+	}
+
+	// Dead letters antigas não têm Topic: PublishMessage usa o tópico de registro
+	message := messaging.Message{Topic: letter.Topic, Key: letter.Key, Headers: letter.Headers, Payload: []byte(letter.Payload)}
+	if err := s.emitter.PublishMessage(ctx, message); err != nil {
+		return s.recordAttempt(ctx, letter, err)
+	}
+
+	_, err = s.repo.Delete(ctx, letter.ID)
+	return err
+}
+
+// replayRegistrationEvents publica os eventos de um usuário recém-criado pelo replay.
+// A dead letter original é removida e cada mensagem que falhar vira uma nova.
+func (s *DeadLetterService) replayRegistrationEvents(ctx context.Context, letter *models.DeadLetter) error {
+	messages, err := s.emitter.Messages(events.NewUserRegistered(letter.User))
+	if err != nil {
+		return err
+	}
+	return s.publishReplayed(ctx, letter, messages)
+}
+
+// replayUnencoded decodifica o evento guardado em JSON e o publica. Se ele ainda não
+// puder ser codificado, a dead letter fica com a tentativa registrada.
+func (s *DeadLetterService) replayUnencoded(ctx context.Context, letter *models.DeadLetter) error {
+	event, ok := events.New(letter.EventType)
+	if !ok {
+		return fmt.Errorf("dead letter has an unknown event type %q", letter.EventType)
+	}
+	if err := json.Unmarshal([]byte(letter.Payload), event); err != nil {
+		return fmt.Errorf("dead letter has an invalid %s payload: %w", letter.EventType, err)
+	}
+
+	messages, err := s.emitter.Messages(event)
+	if err != nil {
+		return s.recordAttempt(ctx, letter, err)
+	}
+	return s.publishReplayed(ctx, letter, messages)
+}
+
+// publishReplayed publica as mensagens de um replay e remove a dead letter original;
+// cada mensagem que falhar vira uma nova dead letter de evento.
+func (s *DeadLetterService) publishReplayed(ctx context.Context, letter *models.DeadLetter, messages []messaging.Message) error {
+	var failed []*models.DeadLetter
+	var publishErr error
+	if err := s.emitter.PublishBatch(ctx, messages); err != nil {
+		publishErr = err
+		for i, err := range publishErrors(err, len(messages)) {
+			if err != nil {
+				failed = append(failed, newEventDeadLetter(messages[i], err, letter.Attempts+1))
+			}
+		}
+	}
+
+	if len(failed) > 0 {
+		if err := s.repo.CreateMany(ctx, failed); err != nil {
+			return errors.Join(publishErr, err)
+		}
+	}
+	if _, err := s.repo.Delete(ctx, letter.ID); err != nil {
+		return err
+	}
+	return publishErr
+}
+
+// replayJob coloca o payload de volta na fila do tipo do job, na lane normal. O job é
+// processado depois, pelos workers: uma nova falha gera uma nova dead letter.
+func (s *DeadLetterService) replayJob(ctx context.Context, letter *models.DeadLetter) error {
+	var payload bson.Raw
+	if err := bson.UnmarshalExtJSON([]byte(letter.Payload), true, &payload); err != nil {
+		return fmt.Errorf("dead letter has an invalid %s payload: %w", letter.JobType, err)
+	}
+
+	job := &models.Job{Type: letter.JobType, Lane: models.JobLaneNormal, Payload: payload, EnqueuedAt: time.Now()}
+	if err := s.jobs.Enqueue(ctx, job); err != nil {
+		if errors.Is(err, repositories.ErrQueueFull) {
+			err = ErrQueueFull
+		}
+		return s.recordAttempt(ctx, letter, err)
+	}
+
+	_, err := s.repo.Delete(ctx, letter.ID)
+	return err
+}
+
+func (s *DeadLetterService) Discard(ctx context.Context, id primitive.ObjectID) error {
+	deleted, err := s.repo.Delete(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrDeadLetterNotFound
+	}
+	return nil
+}
+
+// RetryEvents reenvia as dead letters de evento mais recentes, até limit; as que
+// falharem de novo ficam com a tentativa registrada e voltam na próxima chamada.
+func (s *DeadLetterService) RetryEvents(ctx context.Context, limit int64) (replayed, failed int, err error) {
+	letters, err := s.repo.List(ctx, models.DeadLetterKindEvent, limit, 0)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	for _, letter := range letters {
+		if err := s.Replay(ctx, letter.ID); err != nil {
+			if ctx.Err() != nil {
+				return replayed, failed, ctx.Err()
+			}
+			failed++
+			continue
+		}
+		replayed++
+	}
+	return replayed, failed, nil
+}
+
+// Purge remove as dead letters criadas antes de before, de todos os tipos.
+func (s *DeadLetterService) Purge(ctx context.Context, before time.Time) (int64, error) {
+	return s.repo.DeleteBefore(ctx, before)
+}
+
+func (s *DeadLetterService) recordAttempt(ctx context.Context, letter *models.DeadLetter, cause error) error {
+	letter.Attempts++
+	letter.Error = cause.Error()
+	letter.LastAttemptAt = time.Now()
+	if err := s.repo.Update(ctx, letter); err != nil {
+		return errors.Join(cause, err)
+	}
+	return cause
+}
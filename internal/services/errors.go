@@ -6,4 +6,15 @@ var (
 	ErrEmailExists        = errors.New("email already exists")
 	ErrInvalidCredentials = errors.New("invalid credentials")
 	ErrUserNotFound       = errors.New("user not found")
+	ErrInvalidPassword    = errors.New("current password is incorrect")
+	ErrUserDisabled       = errors.New("user is disabled")
+	ErrInvalidRole        = errors.New("invalid role")
+	ErrDeadLetterNotFound = errors.New("dead letter not found")
+	ErrCheckpointMismatch = errors.New("checkpoint was created with different filters")
+	ErrQueueFull          = errors.New("job queue is full")
+	ErrPoolSizeOutOfRange = errors.New("worker pool size is outside the configured bounds")
+	ErrJobTypeNotFound    = errors.New("job type not found")
+	ErrUnknownLane        = errors.New("unknown priority lane")
+	ErrScheduleNotFound   = errors.New("scheduled job not found")
+	ErrScheduleRunning    = errors.New("scheduled job is already running")
 )
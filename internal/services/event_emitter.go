@@ -0,0 +1,155 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+
+	"github.com/lucas/go-rest-api-mongo/internal/events"
+	"github.com/lucas/go-rest-api-mongo/internal/messaging"
+	"github.com/lucas/go-rest-api-mongo/internal/models"
+	"github.com/lucas/go-rest-api-mongo/internal/repositories"
+)
+
+// EventEmitter publica os eventos do ciclo de vida do usuário. Registros vão para o
+// tópico de registro e para o de eventos; os demais só para o de eventos. A mensagem
+// usa o ID do usuário como key, o que mantém a ordem dos eventos de cada usuário.
+//
+// Um EventEmitter nil não publica nada, o que é útil em testes.
+type EventEmitter struct {
+	registrations   *events.Publisher
+	lifecycle       *events.Publisher
+	deadLetters     repositories.DeadLetterStore
+	skipStateEvents bool
+}
+
+func NewEventEmitter(
+	registrations *events.Publisher,
+	lifecycle *events.Publisher,
+	deadLetters repositories.DeadLetterStore) *EventEmitter {
+
+	return &EventEmitter{
+		registrations: registrations,
+		lifecycle:     lifecycle,
+		deadLetters:   deadLetters,
+	}
+}
+
+// WithoutStateChanges devolve um emitter que descarta os eventos de mudança de
+// estado (registro, atualização, troca de senha e exclusão). É usado pela API quando
+// o ChangeStreamPublisher publica esses eventos a partir do banco, para que não
+// saiam em dobro; eventos de login continuam sendo publicados.
+func (e *EventEmitter) WithoutStateChanges() *EventEmitter {
+	clone := *e
+	clone.skipStateEvents = true
+	return &clone
+}
+
+// Messages monta as mensagens do evento, uma por tópico de destino.
+func (e *EventEmitter) Messages(event events.Event) ([]messaging.Message, error) {
+	if e == nil || (e.skipStateEvents && isStateChange(event)) {
+		return nil, nil
+	}
+	return e.publisherFor(event).Messages(event)
+}
+
+// Emit publica o evento em uma única tentativa. A operação que gerou o evento já foi
+// concluída, então uma falha não é devolvida a quem chamou: as mensagens que não
+// foram publicadas viram dead letters para replay, e um evento que não pôde ser
+// codificado vira uma dead letter com o evento em JSON.
+func (e *EventEmitter) Emit(ctx context.Context, event events.Event) {
+	if e == nil {
+		return
+	}
+
+	// O evento não pode se perder se o cliente desconectar
+	ctx = context.WithoutCancel(ctx)
+
+	messages, err := e.Messages(event)
+	if err != nil {
+		log.Printf("Error encoding %s event: %v", event.EventType(), err)
+		e.saveDeadLetters(ctx, []*models.DeadLetter{newUnencodedDeadLetter(event, err)})
+		return
+	}
+	if len(messages) == 0 {
+		return
+	}
+
+	err = e.PublishBatch(ctx, messages)
+	if err == nil {
+		return
+	}
+
+	errs := publishErrors(err, len(messages))
+	letters := make([]*models.DeadLetter, 0, len(messages))
+	for i, message := range messages {
+		if errs[i] != nil {
+			letters = append(letters, newEventDeadLetter(message, errs[i], 1))
+		}
+	}
+	e.saveDeadLetters(ctx, letters)
+}
+
+func (e *EventEmitter) PublishBatch(ctx context.Context, messages []messaging.Message) error {
+	return e.lifecycle.PublishBatch(ctx, messages)
+}
+
+// PublishMessage republica uma mensagem já montada; sem tópico, ela vai para o
+// tópico de registro, que era o único antes das mensagens guardarem o tópico.
+func (e *EventEmitter) PublishMessage(ctx context.Context, message messaging.Message) error {
+	return e.registrations.PublishMessage(ctx, message)
+}
+
+func (e *EventEmitter) saveDeadLetters(ctx context.Context, letters []*models.DeadLetter) {
+	if len(letters) == 0 {
+		return
+	}
+	if err := e.deadLetters.CreateMany(ctx, letters); err != nil {
+		log.Printf("Error saving %d dead letters: %v", len(letters), err)
+		return
+	}
+	log.Printf("Moved %d failed events to the dead letter store", len(letters))
+}
+
+// newUnencodedDeadLetter guarda o evento em JSON, que não depende do serializer que
+// falhou; sem nem isso, a dead letter registra só o tipo e o erro.
+func newUnencodedDeadLetter(event events.Event, err error) *models.DeadLetter {
+	letter := newDeadLetter(models.DeadLetterKindUnencoded, err, 1)
+	letter.EventType = event.EventType()
+	if payload, jsonErr := json.Marshal(event); jsonErr == nil {
+		letter.Payload = string(payload)
+	}
+	return letter
+}
+
+func isStateChange(event events.Event) bool {
+	switch event.EventType() {
+	case events.TypeUserRegistered, events.TypeUserUpdated, events.TypeUserPasswordChanged, events.TypeUserDeleted:
+		return true
+	default:
+		return false
+	}
+}
+
+func (e *EventEmitter) publisherFor(event events.Event) *events.Publisher {
+	if event.EventType() == events.TypeUserRegistered {
+		return e.registrations
+	}
+	return e.lifecycle
+}
+
+// publishErrors distribui o erro de um PublishBatch entre as n mensagens: um
+// PublishErrors traz o erro de cada uma, qualquer outro erro vale para todas.
+func publishErrors(err error, n int) []error {
+	errs := make([]error, n)
+	var publishErrs messaging.PublishErrors
+	if errors.As(err, &publishErrs) && len(publishErrs) == n {
+		copy(errs, publishErrs)
+		return errs
+	}
+	for i := range errs {
+		errs[i] = err
+	}
+	return errs
+}
@@ -0,0 +1,369 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/lucas/go-rest-api-mongo/internal/models"
+	"github.com/lucas/go-rest-api-mongo/internal/repositories"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Job é um job de um tipo, com o payload já decodificado.
+type Job[T any] struct {
+	ID         primitive.ObjectID
+	Lane       string
+	Attempts   int // entregas a um worker, contando esta
+	EnqueuedAt time.Time
+	Payload    T
+
+	raw bson.Raw // o payload como foi enfileirado, para a dead letter
+}
+
+// Queue é a fila de um tipo de job sobre um JobStore, dividida em lanes de
+// prioridade. O payload é gravado como um documento BSON, então T precisa ser uma
+// struct, um ponteiro para struct ou um mapa.
+type Queue[T any] struct {
+	store   repositories.JobStore
+	jobType string
+
+	mu    sync.Mutex
+	lanes []*laneState // em ordem de peso, da maior prioridade para a menor
+}
+
+// Lane é uma lane de prioridade. Os workers alternam as lanes por weighted round
+// robin: cada lane recebe Weight lotes a cada rodada, enquanto tiver jobs. Com
+// Capacity maior que zero, Enqueue recusa jobs na lane cheia.
+type Lane struct {
+	Name     string
+	Weight   int
+	Capacity int
+}
+
+// laneState é uma lane com o crédito do weighted round robin e os contadores de LaneStats.
+type laneState struct {
+	Lane
+	credit   int
+	accepted atomic.Int64
+	rejected atomic.Int64
+	dequeued atomic.Int64
+}
+
+// LaneStats são as métricas de uma lane da fila.
+type LaneStats struct {
+	Lane     string `json:"lane"`
+	Weight   int    `json:"weight"`
+	Capacity int    `json:"capacity,omitempty"`
+	Depth    int64  `json:"depth"`
+	Accepted int64  `json:"accepted"`
+	Rejected int64  `json:"rejected"` // recusados com a lane ou a fila cheia
+	Dequeued int64  `json:"dequeued"`
+}
+
+// EnqueueOptions escolhem a lane do job e a chave (tenant, chave de API) usada para
+// alternar os jobs de clientes diferentes dentro da lane. Lane vazia é a normal.
+type EnqueueOptions struct {
+	Lane    string
+	FairKey string
+}
+
+// NewQueue cria a fila do tipo; sem lanes, ela tem só a lane normal.
+func NewQueue[T any](store repositories.JobStore, jobType string, lanes ...Lane) *Queue[T] {
+	q := &Queue[T]{store: store, jobType: jobType}
+	if len(lanes) == 0 {
+		lanes = []Lane{{Name: models.JobLaneNormal, Weight: 1}}
+	}
+	q.SetLanes(lanes)
+	return q
+}
+
+func (q *Queue[T]) Type() string {
+	return q.jobType
+}
+
+// SetLanes troca os pesos e as capacidades das lanes. Lanes que já existiam mantêm os
+// contadores; jobs de uma lane removida ficam na fila até ela voltar.
+func (q *Queue[T]) SetLanes(lanes []Lane) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	states := make([]*laneState, 0, len(lanes))
+	for _, lane := range lanes {
+		// O nome não muda depois de criado: dequeue o lê sem o lock
+		state := q.laneLocked(lane.Name)
+		if state == nil {
+			state = &laneState{Lane: Lane{Name: lane.Name}}
+		}
+		state.Weight, state.Capacity = lane.Weight, lane.Capacity
+		states = append(states, state)
+	}
+	slices.SortStableFunc(states, func(a, b *laneState) int { return b.Weight - a.Weight })
+	q.lanes = states
+}
+
+func (q *Queue[T]) laneLocked(name string) *laneState {
+	for _, state := range q.lanes {
+		if state.Name == name {
+			return state
+		}
+	}
+	return nil
+}
+
+// Enqueue coloca o payload na lane de opts. Retorna ErrUnknownLane para uma lane que
+// a fila não tem e ErrQueueFull quando a lane ou a fila não aceita mais jobs. A
+// capacidade da lane é conferida antes de gravar, então réplicas enfileirando ao
+// mesmo tempo podem passar dela por alguns jobs.
+func (q *Queue[T]) Enqueue(ctx context.Context, payload T, opts EnqueueOptions) error {
+	if opts.Lane == "" {
+		opts.Lane = models.JobLaneNormal
+	}
+	q.mu.Lock()
+	lane := q.laneLocked(opts.Lane)
+	var capacity int
+	if lane != nil {
+		capacity = lane.Capacity
+	}
+	q.mu.Unlock()
+	if lane == nil {
+		return fmt.Errorf("%w: %q", ErrUnknownLane, opts.Lane)
+	}
+
+	raw, err := bson.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("encoding %s job: %w", q.jobType, err)
+	}
+
+	if capacity > 0 {
+		depth, err := q.store.Depth(ctx, q.jobType, opts.Lane)
+		if err != nil {
+			return err
+		}
+		if depth >= int64(capacity) {
+			lane.rejected.Add(1)
+			return ErrQueueFull
+		}
+	}
+
+	err = q.store.Enqueue(ctx, &models.Job{Type: q.jobType, Lane: opts.Lane, FairKey: opts.FairKey, Payload: raw, EnqueuedAt: time.Now()})
+	if errors.Is(err, repositories.ErrQueueFull) {
+		lane.rejected.Add(1)
+		return ErrQueueFull
+	}
+	if err == nil {
+		lane.accepted.Add(1)
+	}
+	return err
+}
+
+// Depth conta os jobs na fila, em todas as lanes, inclusive os reservados.
+func (q *Queue[T]) Depth(ctx context.Context) (int64, error) {
+	return q.store.Depth(ctx, q.jobType, "")
+}
+
+// LaneStats devolve as métricas de cada lane, da maior prioridade para a menor.
+func (q *Queue[T]) LaneStats(ctx context.Context) ([]LaneStats, error) {
+	q.mu.Lock()
+	stats := make([]LaneStats, len(q.lanes))
+	for i, lane := range q.lanes {
+		stats[i] = LaneStats{
+			Lane:     lane.Name,
+			Weight:   lane.Weight,
+			Capacity: lane.Capacity,
+			Accepted: lane.accepted.Load(),
+			Rejected: lane.rejected.Load(),
+			Dequeued: lane.dequeued.Load(),
+		}
+	}
+	q.mu.Unlock()
+
+	var errs []error
+	for i := range stats {
+		depth, err := q.store.Depth(ctx, q.jobType, stats[i].Lane)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		stats[i].Depth = depth
+	}
+	return stats, errors.Join(errs...)
+}
+
+// schedule devolve a ordem em que as lanes são consultadas para o próximo lote: a
+// escolhida pelo smooth weighted round robin e depois as outras, da maior prioridade
+// para a menor, para que um lote nunca espere com jobs em outra lane.
+func (q *Queue[T]) schedule() []*laneState {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var next *laneState
+	total := 0
+	for _, lane := range q.lanes {
+		lane.credit += lane.Weight
+		total += lane.Weight
+		if next == nil || lane.credit > next.credit {
+			next = lane
+		}
+	}
+	if next == nil {
+		return nil
+	}
+	next.credit -= total
+
+	order := make([]*laneState, 0, len(q.lanes))
+	order = append(order, next)
+	for _, lane := range q.lanes {
+		if lane != next {
+			order = append(order, lane)
+		}
+	}
+	return order
+}
+
+// dequeue reserva até limit jobs de uma lane, na ordem de schedule. Os que não puderam
+// ser decodificados voltam em invalid: eles nunca vão ser processados, então viram
+// dead letters.
+func (q *Queue[T]) dequeue(ctx context.Context, owner string, limit int, visibility time.Duration) (jobs []Job[T], invalid []*models.Job, err error) {
+	for _, lane := range q.schedule() {
+		reserved, err := q.store.Dequeue(ctx, q.jobType, lane.Name, owner, limit, visibility)
+		lane.dequeued.Add(int64(len(reserved)))
+		for _, stored := range reserved {
+			job := Job[T]{ID: stored.ID, Lane: stored.Lane, Attempts: stored.Attempts, EnqueuedAt: stored.EnqueuedAt, raw: stored.Payload}
+			if decodeErr := bson.Unmarshal(stored.Payload, &job.Payload); decodeErr != nil {
+				invalid = append(invalid, stored)
+				continue
+			}
+			jobs = append(jobs, job)
+		}
+		if err != nil || len(reserved) > 0 {
+			return jobs, invalid, err
+		}
+	}
+	return nil, nil, nil
+}
+
+func (q *Queue[T]) ack(ctx context.Context, ids []primitive.ObjectID) error {
+	return q.store.Ack(ctx, ids)
+}
+
+// JobHandler registra um tipo de job no JobRunner (ver RegisterJob).
+type JobHandler[T any] struct {
+	Queue *Queue[T]
+	// Handle processa um lote e devolve um erro por job, na ordem de jobs; nil indica
+	// que todos deram certo. Jobs com erro transitório (IsRetryable) são repetidos
+	// segundo RetryPolicy; os outros viram dead letters.
+	Handle func(ctx context.Context, jobs []Job[T]) []error
+	// DeadLetter monta a dead letter de um job que falhou; nil grava uma dead letter do
+	// tipo job, que o replay coloca de volta na fila.
+	DeadLetter func(job Job[T], err error, attempts int) *models.DeadLetter
+
+	BatchSize int
+	// Timeout limita o processamento de um lote, com os retries. É também por quanto
+	// tempo o lote fica reservado: depois disso outro worker pode receber os mesmos jobs.
+	Timeout      time.Duration
+	PollInterval time.Duration // espera entre consultas à fila vazia
+	Concurrency  int           // workers iniciais; ver WorkerPool.Resize
+	RetryPolicy  RetryPolicy
+}
+
+// RegisterJob cria o WorkerPool do tipo de job e o adiciona ao runner.
+func RegisterJob[T any](runner *JobRunner, handler JobHandler[T], deadLetters repositories.DeadLetterStore) *WorkerPool[T] {
+	pool := NewWorkerPool(handler, deadLetters)
+	runner.Add(pool)
+	return pool
+}
+
+// JobPool é um WorkerPool visto sem o tipo do payload, como o JobRunner e o endpoint
+// de admin o usam.
+type JobPool interface {
+	Type() string
+	Start(ctx context.Context)
+	Wait()
+	Healthy() error
+	Stats(ctx context.Context) (WorkerPoolStats, error)
+	Size() int
+	Resize(size int) error
+	SetScaling(scaling WorkerPoolScaling)
+	SetAutoscale(enabled bool)
+}
+
+// JobRunner agrupa os worker pools de todos os tipos de job do processo.
+type JobRunner struct {
+	pools []JobPool // na ordem em que foram registrados
+}
+
+func NewJobRunner() *JobRunner {
+	return &JobRunner{}
+}
+
+// Add registra o pool de um tipo; cada tipo só pode ter um pool.
+func (r *JobRunner) Add(pool JobPool) {
+	if _, ok := r.Pool(pool.Type()); ok {
+		panic(fmt.Sprintf("services: job type %q registered twice", pool.Type()))
+	}
+	r.pools = append(r.pools, pool)
+}
+
+// Pool devolve o pool do tipo de job.
+func (r *JobRunner) Pool(jobType string) (JobPool, bool) {
+	for _, pool := range r.pools {
+		if pool.Type() == jobType {
+			return pool, true
+		}
+	}
+	return nil, false
+}
+
+func (r *JobRunner) Start(ctx context.Context) {
+	for _, pool := range r.pools {
+		pool.Start(ctx)
+	}
+}
+
+// Wait bloqueia até os workers de todos os pools terminarem.
+func (r *JobRunner) Wait() {
+	for _, pool := range r.pools {
+		pool.Wait()
+	}
+}
+
+// Healthy junta os erros de saúde de todos os pools.
+func (r *JobRunner) Healthy() error {
+	var errs []error
+	for _, pool := range r.pools {
+		if err := pool.Healthy(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", pool.Type(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Stats devolve as métricas de cada pool pelo tipo de job.
+func (r *JobRunner) Stats(ctx context.Context) (map[string]WorkerPoolStats, error) {
+	stats := make(map[string]WorkerPoolStats, len(r.pools))
+	var errs []error
+	for _, pool := range r.pools {
+		poolStats, err := pool.Stats(ctx)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", pool.Type(), err))
+		}
+		stats[pool.Type()] = poolStats
+	}
+	return stats, errors.Join(errs...)
+}
+
+// newJobDeadLetter é a dead letter padrão de um job: o payload vai como Extended JSON,
+// para ser lido na API de admin e enfileirado de novo pelo replay.
+func newJobDeadLetter(jobType string, payload bson.Raw, err error, attempts int) *models.DeadLetter {
+	letter := newDeadLetter(models.DeadLetterKindJob, err, attempts)
+	letter.JobType = jobType
+	if data, marshalErr := bson.MarshalExtJSON(payload, true, false); marshalErr == nil {
+		letter.Payload = string(data)
+	}
+	return letter
+}
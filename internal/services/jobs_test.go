@@ -0,0 +1,191 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lucas/go-rest-api-mongo/internal/models"
+	"github.com/lucas/go-rest-api-mongo/internal/repositories"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+type testEmail struct {
+	To string `bson:"to"`
+}
+
+func TestJobRunnerRetriesAndDeadLettersJobs(t *testing.T) {
+	store := repositories.NewMemoryJobRepository(0)
+	deadLetters := repositories.NewMemoryDeadLetterRepository()
+	queue := NewQueue[testEmail](store, "email")
+	ctx := context.Background()
+
+	var mu sync.Mutex
+	calls := make(map[string]int)
+	runner := NewJobRunner()
+	RegisterJob(runner, JobHandler[testEmail]{
+		Queue: queue,
+		Handle: func(ctx context.Context, jobs []Job[testEmail]) []error {
+			mu.Lock()
+			defer mu.Unlock()
+			errs := make([]error, len(jobs))
+			for i, job := range jobs {
+				calls[job.Payload.To]++
+				switch {
+				case job.Payload.To == "bad@example.com":
+					errs[i] = errors.New("mailbox does not exist")
+				case job.Payload.To == "flaky@example.com" && calls[job.Payload.To] == 1:
+					errs[i] = context.DeadlineExceeded
+				}
+			}
+			return errs
+		},
+		BatchSize:    10,
+		Timeout:      time.Second,
+		PollInterval: 10 * time.Millisecond,
+		Concurrency:  1,
+		RetryPolicy:  testRetryPolicy,
+	}, deadLetters)
+
+	for _, to := range []string{"ok@example.com", "flaky@example.com", "bad@example.com"} {
+		if err := queue.Enqueue(ctx, testEmail{To: to}, EnqueueOptions{}); err != nil {
+			t.Fatalf("Enqueue() error = %v", err)
+		}
+	}
+	// Um payload que não decodifica em testEmail nunca chega ao Handle
+	invalid, _ := bson.Marshal(bson.M{"to": 42})
+	if err := store.Enqueue(ctx, &models.Job{Type: "email", Lane: models.JobLaneNormal, Payload: invalid}); err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	runner.Start(runCtx)
+	waitFor(t, func() bool {
+		depth, _ := queue.Depth(ctx)
+		return depth == 0
+	})
+	cancel()
+	runner.Wait()
+
+	stats, err := runner.Stats(ctx)
+	if err != nil {
+		t.Fatalf("Stats() error = %v", err)
+	}
+	if email := stats["email"]; email.Jobs != 4 || email.Succeeded != 2 || email.DeadLettered != 2 {
+		t.Errorf("stats = %+v, want 4 jobs, 2 succeeded and 2 dead lettered", email)
+	}
+	if calls["flaky@example.com"] != 2 || calls["bad@example.com"] != 1 {
+		t.Errorf("calls = %v, want the transient error retried and the permanent one not", calls)
+	}
+
+	letters, _ := deadLetters.List(ctx, models.DeadLetterKindJob, 10, 0)
+	var bad *models.DeadLetter
+	for _, letter := range letters {
+		if letter.JobType != "email" {
+			t.Errorf("dead letter job type = %q, want email", letter.JobType)
+		}
+		if strings.Contains(letter.Payload, "bad@example.com") {
+			bad = letter
+		}
+	}
+	if len(letters) != 2 || bad == nil || bad.Error != "mailbox does not exist" {
+		t.Fatalf("dead letters = %+v, want the failed and the invalid jobs", letters)
+	}
+
+	// O replay coloca o job de volta na fila do tipo
+	service := NewDeadLetterService(deadLetters, nil, nil, store)
+	if err := service.Replay(ctx, bad.ID); err != nil {
+		t.Fatalf("Replay() error = %v", err)
+	}
+	jobs, _, err := queue.dequeue(ctx, "test", 10, time.Minute)
+	if err != nil || len(jobs) != 1 || jobs[0].Payload.To != "bad@example.com" {
+		t.Errorf("queue after replay = %+v (%v), want the bad@example.com job", jobs, err)
+	}
+	if stored, _ := deadLetters.FindByID(ctx, bad.ID); stored != nil {
+		t.Error("Replay() must remove the dead letter once the job is queued")
+	}
+}
+
+func TestJobRunnerPools(t *testing.T) {
+	store := repositories.NewMemoryJobRepository(0)
+	runner := NewJobRunner()
+	handle := func(context.Context, []Job[testEmail]) []error { return nil }
+	RegisterJob(runner, JobHandler[testEmail]{Queue: NewQueue[testEmail](store, "email"), Handle: handle, Concurrency: 2}, nil)
+	RegisterJob(runner, JobHandler[testEmail]{Queue: NewQueue[testEmail](store, "webhook"), Handle: handle, Concurrency: 1}, nil)
+
+	if pool, ok := runner.Pool("webhook"); !ok || pool.Size() != 1 {
+		t.Errorf("Pool(webhook) = %v, %t, want the webhook pool with 1 worker", pool, ok)
+	}
+	if _, ok := runner.Pool("export"); ok {
+		t.Error("Pool(export) must not find an unregistered type")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("registering a job type twice must panic")
+		}
+	}()
+	RegisterJob(runner, JobHandler[testEmail]{Queue: NewQueue[testEmail](store, "email"), Handle: handle}, nil)
+}
+
+func TestQueueLanes(t *testing.T) {
+	store := repositories.NewMemoryJobRepository(0)
+	queue := NewQueue[testEmail](store, "email",
+		Lane{Name: models.JobLaneLow, Weight: 1},
+		Lane{Name: models.JobLaneHigh, Weight: 3, Capacity: 20},
+	)
+	ctx := context.Background()
+
+	for i := 0; i < 20; i++ {
+		for _, lane := range []string{models.JobLaneHigh, models.JobLaneLow} {
+			if err := queue.Enqueue(ctx, testEmail{To: lane}, EnqueueOptions{Lane: lane}); err != nil {
+				t.Fatalf("Enqueue(%s) error = %v", lane, err)
+			}
+		}
+	}
+
+	// Com as duas lanes cheias, os lotes seguem os pesos: 3 da high para 1 da low
+	batches := make(map[string]int)
+	for i := 0; i < 8; i++ {
+		jobs, _, err := queue.dequeue(ctx, "test", 1, time.Minute)
+		if err != nil || len(jobs) != 1 {
+			t.Fatalf("dequeue() = %d jobs, %v", len(jobs), err)
+		}
+		batches[jobs[0].Lane]++
+	}
+	if batches[models.JobLaneHigh] != 6 || batches[models.JobLaneLow] != 2 {
+		t.Errorf("batches per lane = %v, want 6 high and 2 low", batches)
+	}
+
+	// A lane cheia recusa; a lane desconhecida é um erro do cliente
+	if err := queue.Enqueue(ctx, testEmail{}, EnqueueOptions{Lane: models.JobLaneHigh}); !errors.Is(err, ErrQueueFull) {
+		t.Errorf("Enqueue() on a full lane error = %v, want ErrQueueFull", err)
+	}
+	if err := queue.Enqueue(ctx, testEmail{}, EnqueueOptions{Lane: "urgent"}); !errors.Is(err, ErrUnknownLane) {
+		t.Errorf("Enqueue() on an unknown lane error = %v, want ErrUnknownLane", err)
+	}
+
+	// Uma lane vazia não segura o lote: os workers passam para a próxima
+	drained := 0
+	for i := 0; i < 4; i++ {
+		jobs, _, _ := queue.dequeue(ctx, "test", 10, time.Minute)
+		drained += len(jobs)
+	}
+	if drained != 32 {
+		t.Errorf("drained %d jobs in 4 batches of 10, want the 32 left in both lanes", drained)
+	}
+
+	stats, err := queue.LaneStats(ctx)
+	if err != nil {
+		t.Fatalf("LaneStats() error = %v", err)
+	}
+	if len(stats) != 2 || stats[0].Lane != models.JobLaneHigh {
+		t.Fatalf("LaneStats() = %+v, want high before low", stats)
+	}
+	if high := stats[0]; high.Accepted != 20 || high.Rejected != 1 || high.Capacity != 20 || high.Dequeued != 20 {
+		t.Errorf("high lane = %+v, want 20 accepted, 1 rejected and 20 dequeued", high)
+	}
+}
@@ -0,0 +1,134 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/lucas/go-rest-api-mongo/internal/repositories"
+)
+
+// LeaderElector garante, por meio de um lease, que um processo rode em uma única
+// réplica. O lease é renovado a cada ttl/3; se a renovação falhar até o lease
+// expirar, o processo é cancelado antes que outra réplica possa assumir.
+type LeaderElector struct {
+	leases repositories.LeaseStore
+	name   string
+	owner  string
+	ttl    time.Duration
+	leader atomic.Bool
+}
+
+func NewLeaderElector(leases repositories.LeaseStore, name string, ttl time.Duration) *LeaderElector {
+	return &LeaderElector{
+		leases: leases,
+		name:   name,
+		owner:  instanceID(),
+		ttl:    ttl,
+	}
+}
+
+func (le *LeaderElector) Owner() string  { return le.owner }
+func (le *LeaderElector) IsLeader() bool { return le.leader.Load() }
+
+// Run tenta adquirir o lease até conseguir e então executa run com um contexto que
+// é cancelado se a liderança for perdida. Quando run retorna, o lease é liberado e
+// a disputa recomeça. Run só retorna quando ctx é cancelado.
+func (le *LeaderElector) Run(ctx context.Context, run func(ctx context.Context) error) {
+	interval := le.ttl / 3
+	for {
+		acquired, err := le.leases.TryAcquire(ctx, le.name, le.owner, le.ttl)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("Error acquiring lease %s: %v", le.name, err)
+		}
+
+		if acquired {
+			log.Printf("Acquired lease %s as %s", le.name, le.owner)
+			if err := le.lead(ctx, run); err != nil {
+				log.Printf("Leader of %s stopped: %v", le.name, err)
+			}
+		}
+
+		if sleepContext(ctx, interval) != nil {
+			return
+		}
+	}
+}
+
+// TryLead tenta adquirir o lease uma única vez. Se conseguir, executa run como Run
+// faria, com o lease renovado até run retornar e liberado em seguida, e devolve o erro
+// de run; se outro owner segura o lease, devolve false sem executar run.
+func (le *LeaderElector) TryLead(ctx context.Context, run func(ctx context.Context) error) (bool, error) {
+	acquired, err := le.leases.TryAcquire(ctx, le.name, le.owner, le.ttl)
+	if err != nil || !acquired {
+		return false, err
+	}
+	return true, le.lead(ctx, run)
+}
+
+func (le *LeaderElector) lead(ctx context.Context, run func(ctx context.Context) error) error {
+	leaderCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	le.leader.Store(true)
+	defer le.leader.Store(false)
+
+	renewed := make(chan struct{})
+	go func() {
+		defer close(renewed)
+		le.renew(leaderCtx, cancel)
+	}()
+
+	err := run(leaderCtx)
+	cancel()
+	<-renewed
+
+	// Libera o lease para que outra réplica assuma sem esperar o TTL
+	releaseCtx, releaseCancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer releaseCancel()
+	if releaseErr := le.leases.Release(releaseCtx, le.name, le.owner); releaseErr != nil {
+		log.Printf("Error releasing lease %s: %v", le.name, releaseErr)
+	}
+	return err
+}
+
+// renew renova o lease até ctx ser cancelado, chamando lost quando ele é perdido.
+// Com erros de renovação, desiste um terço do TTL antes da expiração, para não haver
+// dois líderes ao mesmo tempo.
+func (le *LeaderElector) renew(ctx context.Context, lost context.CancelFunc) {
+	margin := le.ttl / 3
+	deadline := time.Now().Add(le.ttl - margin)
+	for sleepContext(ctx, margin/2) == nil {
+		acquired, err := le.leases.TryAcquire(ctx, le.name, le.owner, le.ttl)
+		switch {
+		case acquired:
+			deadline = time.Now().Add(le.ttl - margin)
+		case err == nil:
+			log.Printf("Lost lease %s to another owner", le.name)
+			lost()
+			return
+		case time.Now().After(deadline):
+			log.Printf("Lease %s expired after renewal errors: %v", le.name, err)
+			lost()
+			return
+		default:
+			log.Printf("Error renewing lease %s (will retry): %v", le.name, err)
+		}
+	}
+}
+
+// instanceID identifica esta réplica nos leases.
+func instanceID() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	var suffix [4]byte
+	rand.Read(suffix[:])
+	return fmt.Sprintf("%s-%d-%s", host, os.Getpid(), hex.EncodeToString(suffix[:]))
+}
@@ -0,0 +1,59 @@
+package services
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lucas/go-rest-api-mongo/internal/repositories"
+)
+
+func TestLeaderElectorRunsOnOneReplica(t *testing.T) {
+	leases := repositories.NewMemoryLeaseRepository()
+	first := NewLeaderElector(leases, "job", 60*time.Millisecond)
+	second := NewLeaderElector(leases, "job", 60*time.Millisecond)
+
+	var running atomic.Int32
+	var maxRunning atomic.Int32
+	run := func(ctx context.Context) error {
+		if n := running.Add(1); n > maxRunning.Load() {
+			maxRunning.Store(n)
+		}
+		defer running.Add(-1)
+		<-ctx.Done()
+		return nil
+	}
+
+	ctx1, cancel1 := context.WithCancel(context.Background())
+	ctx2, cancel2 := context.WithCancel(context.Background())
+	defer cancel2()
+	done1 := make(chan struct{})
+	go func() { first.Run(ctx1, run); close(done1) }()
+	waitFor(t, first.IsLeader)
+	go second.Run(ctx2, run)
+
+	time.Sleep(150 * time.Millisecond)
+	if second.IsLeader() || maxRunning.Load() != 1 {
+		t.Fatalf("second leader = %t, max running = %d; want only the first replica", second.IsLeader(), maxRunning.Load())
+	}
+
+	// O líder para e libera o lease: a outra réplica assume
+	cancel1()
+	<-done1
+	waitFor(t, second.IsLeader)
+	if maxRunning.Load() != 1 {
+		t.Errorf("max running = %d, want 1", maxRunning.Load())
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
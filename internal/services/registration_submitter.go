@@ -0,0 +1,65 @@
+package services
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/lucas/go-rest-api-mongo/internal/dto"
+	"github.com/lucas/go-rest-api-mongo/internal/models"
+)
+
+// RegistrationSubmitter é o lado da API da fila de registros: prepara o usuário e o
+// coloca na fila de jobs de registro que os workers consomem, possivelmente em outro processo.
+type RegistrationSubmitter struct {
+	userService *UserService
+	queue       *Queue[*models.User]
+	accepted    atomic.Int64
+	rejected    atomic.Int64
+}
+
+func NewRegistrationSubmitter(userService *UserService, queue *Queue[*models.User]) *RegistrationSubmitter {
+	return &RegistrationSubmitter{
+		userService: userService,
+		queue:       queue,
+	}
+}
+
+// SubmitterStats são as métricas do lado da API da fila de registros.
+type SubmitterStats struct {
+	Accepted   int64       `json:"accepted"`
+	Rejected   int64       `json:"rejected"`
+	QueueDepth int64       `json:"queue_depth"`
+	Lanes      []LaneStats `json:"lanes"`
+}
+
+// Submit enfileira o registro na lane de opts, alternando com os registros de outros
+// clientes pela FairKey. A senha é convertida em hash antes, já que a fila é durável;
+// retorna ErrUnknownLane para uma prioridade que a fila não tem e ErrQueueFull quando
+// a lane não aceita mais jobs.
+func (s *RegistrationSubmitter) Submit(ctx context.Context, req *dto.RegisterRequest, opts EnqueueOptions) error {
+	result := s.userService.PrepareBatch([]*dto.RegisterRequest{req})[0]
+	if result.Err != nil {
+		s.rejected.Add(1)
+		return result.Err
+	}
+
+	if err := s.queue.Enqueue(ctx, result.User, opts); err != nil {
+		s.rejected.Add(1)
+		return err
+	}
+	s.accepted.Add(1)
+	return nil
+}
+
+func (s *RegistrationSubmitter) Stats(ctx context.Context) (SubmitterStats, error) {
+	lanes, err := s.queue.LaneStats(ctx)
+	stats := SubmitterStats{
+		Accepted: s.accepted.Load(),
+		Rejected: s.rejected.Load(),
+		Lanes:    lanes,
+	}
+	for _, lane := range lanes {
+		stats.QueueDepth += lane.Depth
+	}
+	return stats, err
+}
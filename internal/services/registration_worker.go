@@ -0,0 +1,174 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync/atomic"
+
+	"github.com/lucas/go-rest-api-mongo/internal/events"
+	"github.com/lucas/go-rest-api-mongo/internal/messaging"
+	"github.com/lucas/go-rest-api-mongo/internal/models"
+	"github.com/lucas/go-rest-api-mongo/internal/repositories"
+)
+
+// RegistrationJobType é o tipo dos jobs de registro enfileirados pelo /register-fast.
+const RegistrationJobType = "registration"
+
+// RegistrationWorker processa os jobs de registro: grava os usuários preparados por
+// RegistrationSubmitter e publica os eventos de registro.
+type RegistrationWorker struct {
+	userService *UserService
+	emitter     *EventEmitter
+	deadLetters repositories.DeadLetterStore
+	retryPolicy atomic.Pointer[RetryPolicy] // dos eventos; a dos usuários é do pool
+	stats       registrationWorkerStats
+}
+
+type registrationWorkerStats struct {
+	registered atomic.Int64
+	duplicates atomic.Int64
+	published  atomic.Int64
+}
+
+// RegistrationWorkerStats são as métricas próprias dos jobs de registro; as da fila
+// estão nas do pool.
+type RegistrationWorkerStats struct {
+	Registered      int64 `json:"registered"`
+	Duplicates      int64 `json:"duplicates"`
+	EventsPublished int64 `json:"events_published"`
+}
+
+func NewRegistrationWorker(userService *UserService, emitter *EventEmitter, deadLetters repositories.DeadLetterStore, retryPolicy RetryPolicy) *RegistrationWorker {
+	w := &RegistrationWorker{
+		userService: userService,
+		emitter:     emitter,
+		deadLetters: deadLetters,
+	}
+	w.Configure(retryPolicy)
+	return w
+}
+
+// Configure troca a política de retry da publicação dos eventos.
+func (w *RegistrationWorker) Configure(retryPolicy RetryPolicy) {
+	w.retryPolicy.Store(&retryPolicy)
+}
+
+// DeadLetter grava o usuário de um job que falhou como dead letter de registro, que o
+// replay cria e publica de novo.
+func (w *RegistrationWorker) DeadLetter(job Job[*models.User], err error, attempts int) *models.DeadLetter {
+	return newRegistrationDeadLetter(job.Payload, err, attempts)
+}
+
+func (w *RegistrationWorker) Stats() RegistrationWorkerStats {
+	return RegistrationWorkerStats{
+		Registered:      w.stats.registered.Load(),
+		Duplicates:      w.stats.duplicates.Load(),
+		EventsPublished: w.stats.published.Load(),
+	}
+}
+
+// Handle grava os usuários do lote e publica os eventos dos que foram criados. Um
+// email já cadastrado por outro usuário não é erro do job, só é registrado no log. Um
+// job repetido encontra o próprio usuário já gravado e apenas publica os eventos de
+// novo. Os eventos que esgotam as tentativas viram dead letters de evento, para não
+// repetir o registro inteiro.
+func (w *RegistrationWorker) Handle(ctx context.Context, jobs []Job[*models.User]) []error {
+	users := make([]*models.User, len(jobs))
+	for i, job := range jobs {
+		users[i] = job.Payload
+	}
+
+	errs := w.userService.CreateBatch(ctx, users)
+
+	var created []*models.User
+	for i, user := range users {
+		switch err := errs[i]; {
+		case err == nil:
+			created = append(created, user)
+		case errors.Is(err, ErrEmailExists):
+			w.stats.duplicates.Add(1)
+			log.Printf("Error registering user %s: %v", user.Email, err)
+			errs[i] = nil
+		}
+	}
+	w.stats.registered.Add(int64(len(created)))
+
+	messages := make([]messaging.Message, 0, 2*len(created))
+	for _, user := range created {
+		userMessages, err := w.emitter.Messages(events.NewUserRegistered(user))
+		if err != nil {
+			log.Printf("Error marshaling event for user %s: %v", user.Email, err)
+			continue
+		}
+		messages = append(messages, userMessages...)
+	}
+
+	published, deadLetters := w.publishWithRetry(ctx, messages)
+	w.stats.published.Add(int64(published))
+	if len(deadLetters) > 0 {
+		// O timeout do lote pode já ter passado, mas a dead letter não pode se perder
+		if err := w.deadLetters.CreateMany(context.WithoutCancel(ctx), deadLetters); err != nil {
+			log.Printf("Error saving %d dead letters: %v", len(deadLetters), err)
+		} else {
+			log.Printf("Moved %d failed events to the dead letter store", len(deadLetters))
+		}
+	}
+
+	log.Printf("Registered %d of %d users and published %d of %d events", len(created), len(users), published, len(messages))
+	return errs
+}
+
+// publishWithRetry publica os eventos em uma única escrita por tentativa, repetindo
+// apenas as mensagens que falharam com erro transitório.
+func (w *RegistrationWorker) publishWithRetry(ctx context.Context, messages []messaging.Message) (int, []*models.DeadLetter) {
+	var deadLetters []*models.DeadLetter
+	published := 0
+	retryPolicy := *w.retryPolicy.Load()
+
+	pending := messages
+	for attempt := 1; len(pending) > 0; attempt++ {
+		err := w.emitter.PublishBatch(ctx, pending)
+		if err == nil {
+			published += len(pending)
+			break
+		}
+
+		errs := publishErrors(err, len(pending))
+
+		var retry []messaging.Message
+		for i, message := range pending {
+			switch {
+			case errs[i] == nil:
+				published++
+			case IsRetryable(errs[i]) && attempt < retryPolicy.MaxAttempts:
+				retry = append(retry, message)
+			default:
+				deadLetters = append(deadLetters, newEventDeadLetter(message, errs[i], attempt))
+			}
+		}
+
+		if len(retry) == 0 {
+			log.Printf("Error publishing %d events: %v", len(deadLetters), err)
+			break
+		}
+
+		delay := retryPolicy.Backoff(attempt)
+		log.Printf("Retrying %d events in %s (attempt %d): %v", len(retry), delay, attempt, err)
+		if sleepErr := sleepContext(ctx, delay); sleepErr != nil {
+			for _, message := range retry {
+				deadLetters = append(deadLetters, newEventDeadLetter(message, sleepErr, attempt))
+			}
+			break
+		}
+		pending = retry
+	}
+
+	return published, deadLetters
+}
+
+func newRegistrationDeadLetter(user *models.User, err error, attempts int) *models.DeadLetter {
+	letter := newDeadLetter(models.DeadLetterKindRegistration, err, attempts)
+	letter.User = user
+	return letter
+}
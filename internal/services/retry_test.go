@@ -0,0 +1,49 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{ErrEmailExists, false},
+		{fmt.Errorf("wrapped: %w", ErrEmailExists), false},
+		{errors.New("boom"), false},
+		{context.DeadlineExceeded, true},
+		{kafka.LeaderNotAvailable, true},
+		{kafka.MessageSizeTooLarge, false},
+		{kafka.WriteErrors{nil, kafka.NotEnoughReplicas}, true},
+	}
+
+	for _, tt := range tests {
+		if got := IsRetryable(tt.err); got != tt.want {
+			t.Errorf("IsRetryable(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestRetryPolicyBackoffIsBounded(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 10, BaseDelay: 10 * time.Millisecond, MaxDelay: 80 * time.Millisecond}
+
+	for attempt := 1; attempt <= 10; attempt++ {
+		limit := policy.BaseDelay << (attempt - 1)
+		if limit > policy.MaxDelay {
+			limit = policy.MaxDelay
+		}
+		for i := 0; i < 20; i++ {
+			if d := policy.Backoff(attempt); d < 0 || d > limit {
+				t.Fatalf("Backoff(%d) = %v, want within [0, %v]", attempt, d, limit)
+			}
+		}
+	}
+}
@@ -0,0 +1,334 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/lucas/go-rest-api-mongo/internal/models"
+	"github.com/lucas/go-rest-api-mongo/internal/repositories"
+	"github.com/lucas/go-rest-api-mongo/pkg/cron"
+)
+
+// ScheduledTask é um job periódico de manutenção. Run devolve um resumo do que foi
+// feito, guardado no histórico da execução.
+type ScheduledTask struct {
+	Name     string
+	Schedule string // expressão cron (pkg/cron)
+	Run      func(ctx context.Context) (string, error)
+}
+
+// SchedulerOptions configura um Scheduler.
+type SchedulerOptions struct {
+	Location         *time.Location // fuso das expressões cron; nil é UTC
+	Jitter           time.Duration  // atraso aleatório de até Jitter depois de cada horário
+	LeaseTTL         time.Duration
+	Timeout          time.Duration // limite de cada execução; 0 sem limite
+	HistoryRetention time.Duration // por quanto tempo as execuções ficam no histórico
+}
+
+// ScheduleStatus é um job agendado visto pelo endpoint de admin. Running diz se alguma
+// réplica segura o lease do job.
+type ScheduleStatus struct {
+	Name      string              `json:"name"`
+	Schedule  string              `json:"schedule"`
+	Paused    bool                `json:"paused"`
+	Running   bool                `json:"running"`
+	NextRunAt *time.Time          `json:"next_run_at,omitempty"`
+	LastRun   *models.ScheduleRun `json:"last_run,omitempty"`
+}
+
+// Scheduler executa jobs periódicos em uma única réplica por horário. Cada réplica
+// acorda no horário do cron mais um jitter aleatório e tenta pegar o lease do job: a
+// que consegue confere, com o lease na mão, se o horário já foi executado, e o marca
+// antes de executar. O lease é renovado enquanto o job roda, então um job longo não é
+// executado em paralelo por outra réplica nem por um disparo manual.
+type Scheduler struct {
+	store   repositories.ScheduleStore
+	leases  repositories.LeaseStore
+	options SchedulerOptions
+
+	tasks map[string]*scheduledTask
+	order []string
+
+	ctx context.Context // o de Start, usado pelos disparos manuais
+	wg  sync.WaitGroup
+}
+
+type scheduledTask struct {
+	ScheduledTask
+	schedule *cron.Schedule
+	elector  *LeaderElector
+	running  atomic.Bool // nesta réplica
+}
+
+func NewScheduler(store repositories.ScheduleStore, leases repositories.LeaseStore, options SchedulerOptions) *Scheduler {
+	if options.Location == nil {
+		options.Location = time.UTC
+	}
+	return &Scheduler{
+		store:   store,
+		leases:  leases,
+		options: options,
+		tasks:   make(map[string]*scheduledTask),
+		ctx:     context.Background(),
+	}
+}
+
+// Add registra um job antes de Start. Registrar o mesmo nome duas vezes é um erro de
+// programação e causa panic.
+func (s *Scheduler) Add(task ScheduledTask) error {
+	if _, exists := s.tasks[task.Name]; exists {
+		panic(fmt.Sprintf("services: scheduled job %q registered twice", task.Name))
+	}
+	schedule, err := cron.Parse(task.Schedule)
+	if err != nil {
+		return fmt.Errorf("scheduled job %s: %w", task.Name, err)
+	}
+
+	s.tasks[task.Name] = &scheduledTask{
+		ScheduledTask: task,
+		schedule:      schedule,
+		elector:       NewLeaderElector(s.leases, scheduleLeaseName(task.Name), s.options.LeaseTTL),
+	}
+	s.order = append(s.order, task.Name)
+	return nil
+}
+
+// Start inicia um loop por job, que para quando ctx é cancelado.
+func (s *Scheduler) Start(ctx context.Context) {
+	s.ctx = ctx
+	for _, name := range s.order {
+		task := s.tasks[name]
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.loop(ctx, task)
+		}()
+	}
+}
+
+// Wait espera os loops e as execuções em andamento terminarem.
+func (s *Scheduler) Wait() {
+	s.wg.Wait()
+}
+
+func (s *Scheduler) loop(ctx context.Context, task *scheduledTask) {
+	for {
+		tick := task.schedule.Next(time.Now().In(s.options.Location))
+		if tick.IsZero() {
+			log.Printf("Scheduled job %s never runs: %q matches no date", task.Name, task.Schedule)
+			return
+		}
+		if sleepContext(ctx, time.Until(tick)+s.jitter()) != nil {
+			return
+		}
+
+		err := s.execute(ctx, task, models.ScheduleTriggerCron, tick, nil)
+		if err != nil && !errors.Is(err, ErrScheduleRunning) && ctx.Err() == nil {
+			log.Printf("Scheduled job %s failed: %v", task.Name, err)
+		}
+	}
+}
+
+func (s *Scheduler) jitter() time.Duration {
+	if s.options.Jitter <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(s.options.Jitter)))
+}
+
+// execute roda o job se conseguir o lease. Nos disparos do cron, o horário tick só é
+// executado se o job não está pausado e nenhuma réplica já o executou. started recebe
+// a execução assim que ela é gravada no histórico. Sem o lease, devolve
+// ErrScheduleRunning.
+func (s *Scheduler) execute(ctx context.Context, task *scheduledTask, trigger string, tick time.Time, started chan<- *models.ScheduleRun) error {
+	if !task.running.CompareAndSwap(false, true) {
+		return ErrScheduleRunning
+	}
+	defer task.running.Store(false)
+
+	acquired, err := task.elector.TryLead(ctx, func(ctx context.Context) error {
+		if trigger == models.ScheduleTriggerCron {
+			state, err := s.store.GetState(ctx, task.Name)
+			if err != nil {
+				return err
+			}
+			if state != nil && (state.Paused || !state.LastScheduledAt.Before(tick)) {
+				return nil
+			}
+			if err := s.store.SetLastScheduled(ctx, task.Name, tick); err != nil {
+				return err
+			}
+		}
+
+		return s.run(ctx, task, trigger, tick, started)
+	})
+	if err == nil && !acquired {
+		return ErrScheduleRunning
+	}
+	return err
+}
+
+// run executa o job e grava o início e o fim da execução no histórico.
+func (s *Scheduler) run(ctx context.Context, task *scheduledTask, trigger string, tick time.Time, started chan<- *models.ScheduleRun) error {
+	run := &models.ScheduleRun{
+		Job:       task.Name,
+		Trigger:   trigger,
+		Owner:     task.elector.Owner(),
+		StartedAt: time.Now(),
+		Status:    models.ScheduleRunRunning,
+		ExpiresAt: time.Now().Add(s.options.HistoryRetention),
+	}
+	if !tick.IsZero() {
+		run.ScheduledAt = &tick
+	}
+	if err := s.store.SaveRun(ctx, run); err != nil {
+		return fmt.Errorf("recording run: %w", err)
+	}
+	if started != nil {
+		snapshot := *run
+		started <- &snapshot
+	}
+
+	runCtx, cancel := ctx, context.CancelFunc(func() {})
+	if s.options.Timeout > 0 {
+		runCtx, cancel = context.WithTimeout(ctx, s.options.Timeout)
+	}
+	result, runErr := task.Run(runCtx)
+	cancel()
+
+	finished := time.Now()
+	run.FinishedAt = &finished
+	run.ExpiresAt = finished.Add(s.options.HistoryRetention)
+	run.Result = result
+	run.Status = models.ScheduleRunSucceeded
+	if runErr != nil {
+		run.Status = models.ScheduleRunFailed
+		run.Error = runErr.Error()
+	}
+	log.Printf("Scheduled job %s %s in %s (%s) %s", task.Name, run.Status, finished.Sub(run.StartedAt).Round(time.Millisecond), trigger, result)
+
+	// Grava o fim mesmo se ctx foi cancelado no meio da execução
+	saveCtx, saveCancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer saveCancel()
+	if err := s.store.SaveRun(saveCtx, run); err != nil {
+		return errors.Join(runErr, fmt.Errorf("recording run: %w", err))
+	}
+	return runErr
+}
+
+// Trigger dispara o job fora do horário, mesmo pausado, e devolve a execução assim que
+// ela começa; o job continua rodando em background. Se ele já está rodando em alguma
+// réplica, devolve ErrScheduleRunning.
+func (s *Scheduler) Trigger(ctx context.Context, name string) (*models.ScheduleRun, error) {
+	task, ok := s.tasks[name]
+	if !ok {
+		return nil, ErrScheduleNotFound
+	}
+
+	started := make(chan *models.ScheduleRun, 1)
+	done := make(chan error, 1)
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		err := s.execute(s.ctx, task, models.ScheduleTriggerManual, time.Time{}, started)
+		if err != nil && !errors.Is(err, ErrScheduleRunning) {
+			log.Printf("Scheduled job %s failed: %v", task.Name, err)
+		}
+		done <- err
+	}()
+
+	select {
+	case run := <-started:
+		return run, nil
+	case err := <-done:
+		// Um job rápido pode terminar antes de o select ver o início
+		select {
+		case run := <-started:
+			return run, nil
+		default:
+			return nil, err
+		}
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// SetPaused pausa ou retoma os disparos do cron de um job em todas as réplicas. Uma
+// execução em andamento não é interrompida.
+func (s *Scheduler) SetPaused(ctx context.Context, name string, paused bool) (*ScheduleStatus, error) {
+	if _, ok := s.tasks[name]; !ok {
+		return nil, ErrScheduleNotFound
+	}
+	if _, err := s.store.SetPaused(ctx, name, paused); err != nil {
+		return nil, err
+	}
+	return s.Status(ctx, name)
+}
+
+// List devolve o estado de todos os jobs, na ordem em que foram registrados.
+func (s *Scheduler) List(ctx context.Context) ([]ScheduleStatus, error) {
+	statuses := make([]ScheduleStatus, 0, len(s.order))
+	for _, name := range s.order {
+		status, err := s.Status(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, *status)
+	}
+	return statuses, nil
+}
+
+func (s *Scheduler) Status(ctx context.Context, name string) (*ScheduleStatus, error) {
+	task, ok := s.tasks[name]
+	if !ok {
+		return nil, ErrScheduleNotFound
+	}
+
+	state, err := s.store.GetState(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	lease, err := s.leases.Get(ctx, scheduleLeaseName(name))
+	if err != nil {
+		return nil, err
+	}
+	runs, err := s.store.ListRuns(ctx, name, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &ScheduleStatus{
+		Name:     name,
+		Schedule: task.Schedule,
+		Paused:   state != nil && state.Paused,
+		Running:  task.running.Load() || (lease != nil && lease.ExpiresAt.After(time.Now())),
+	}
+	if !status.Paused {
+		if next := task.schedule.Next(time.Now().In(s.options.Location)); !next.IsZero() {
+			status.NextRunAt = &next
+		}
+	}
+	if len(runs) > 0 {
+		status.LastRun = runs[0]
+	}
+	return status, nil
+}
+
+// Runs devolve o histórico de um job, mais recentes primeiro.
+func (s *Scheduler) Runs(ctx context.Context, name string, limit int64) ([]*models.ScheduleRun, error) {
+	if _, ok := s.tasks[name]; !ok {
+		return nil, ErrScheduleNotFound
+	}
+	return s.store.ListRuns(ctx, name, limit)
+}
+
+func scheduleLeaseName(name string) string {
+	return "schedule:" + name
+}
@@ -0,0 +1,133 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lucas/go-rest-api-mongo/internal/models"
+	"github.com/lucas/go-rest-api-mongo/internal/repositories"
+)
+
+// newTestReplicas monta dois schedulers com os mesmos stores, como duas réplicas.
+func newTestReplicas(t *testing.T, task ScheduledTask) (first, second *Scheduler) {
+	t.Helper()
+	store := repositories.NewMemoryScheduleRepository()
+	leases := repositories.NewMemoryLeaseRepository()
+	options := SchedulerOptions{LeaseTTL: time.Second, HistoryRetention: time.Hour}
+
+	first = NewScheduler(store, leases, options)
+	second = NewScheduler(store, leases, options)
+	for _, s := range []*Scheduler{first, second} {
+		if err := s.Add(task); err != nil {
+			t.Fatalf("Add() error = %v", err)
+		}
+	}
+	return first, second
+}
+
+func TestSchedulerRunsEachTickOnce(t *testing.T) {
+	var calls atomic.Int32
+	first, second := newTestReplicas(t, ScheduledTask{
+		Name:     "cleanup",
+		Schedule: "*/15 * * * *",
+		Run: func(ctx context.Context) (string, error) {
+			if calls.Add(1) == 2 {
+				return "", errors.New("database unavailable")
+			}
+			return "3 removed", nil
+		},
+	})
+	ctx := context.Background()
+	tick := time.Date(2025, time.January, 15, 10, 15, 0, 0, time.UTC)
+
+	// A réplica que chega depois (pelo jitter) vê que o horário já foi executado
+	for _, s := range []*Scheduler{first, second} {
+		if err := s.execute(ctx, s.tasks["cleanup"], models.ScheduleTriggerCron, tick, nil); err != nil {
+			t.Fatalf("execute() error = %v", err)
+		}
+	}
+	if calls.Load() != 1 {
+		t.Fatalf("calls = %d, want the tick run once across replicas", calls.Load())
+	}
+
+	// Uma falha fica no histórico
+	next := tick.Add(15 * time.Minute)
+	if err := second.execute(ctx, second.tasks["cleanup"], models.ScheduleTriggerCron, next, nil); err == nil {
+		t.Fatal("execute() error = nil, want the job error")
+	}
+	runs, err := first.Runs(ctx, "cleanup", 10)
+	if err != nil {
+		t.Fatalf("Runs() error = %v", err)
+	}
+	if len(runs) != 2 || runs[0].Status != models.ScheduleRunFailed || runs[0].Error != "database unavailable" || !runs[0].ScheduledAt.Equal(next) {
+		t.Fatalf("runs = %+v, want the failed run first", runs)
+	}
+	if runs[1].Status != models.ScheduleRunSucceeded || runs[1].Result != "3 removed" || runs[1].FinishedAt == nil {
+		t.Errorf("first run = %+v, want it succeeded with its result", runs[1])
+	}
+
+	// Pausado em uma réplica, o job não roda em nenhuma
+	if _, err := first.SetPaused(ctx, "cleanup", true); err != nil {
+		t.Fatalf("SetPaused() error = %v", err)
+	}
+	second.execute(ctx, second.tasks["cleanup"], models.ScheduleTriggerCron, next.Add(15*time.Minute), nil)
+	if calls.Load() != 2 {
+		t.Errorf("calls = %d, want a paused job skipped", calls.Load())
+	}
+	statuses, err := second.List(ctx)
+	if err != nil || len(statuses) != 1 || !statuses[0].Paused || statuses[0].NextRunAt != nil || statuses[0].LastRun == nil {
+		t.Errorf("List() = %+v, %v, want the paused job with its last run", statuses, err)
+	}
+}
+
+func TestSchedulerTrigger(t *testing.T) {
+	release := make(chan struct{})
+	first, second := newTestReplicas(t, ScheduledTask{
+		Name:     "report",
+		Schedule: "@daily",
+		Run: func(ctx context.Context) (string, error) {
+			<-release
+			return "sent", nil
+		},
+	})
+	ctx := context.Background()
+
+	// O disparo manual vale mesmo pausado e responde assim que a execução começa
+	first.SetPaused(ctx, "report", true)
+	run, err := first.Trigger(ctx, "report")
+	if err != nil || run.Status != models.ScheduleRunRunning || run.Trigger != models.ScheduleTriggerManual {
+		t.Fatalf("Trigger() = %+v, %v, want a running manual run", run, err)
+	}
+
+	// Enquanto uma réplica segura o lease, nenhuma outra executa o job
+	if _, err := second.Trigger(ctx, "report"); !errors.Is(err, ErrScheduleRunning) {
+		t.Errorf("Trigger() on another replica error = %v, want ErrScheduleRunning", err)
+	}
+	if status, _ := second.Status(ctx, "report"); status == nil || !status.Running {
+		t.Errorf("Status() = %+v, want the job running on the first replica", status)
+	}
+
+	close(release)
+	first.Wait()
+	runs, _ := second.Runs(ctx, "report", 10)
+	if len(runs) != 1 || runs[0].ID != run.ID || runs[0].Status != models.ScheduleRunSucceeded {
+		t.Errorf("runs = %+v, want the triggered run succeeded", runs)
+	}
+	if status, _ := second.Status(ctx, "report"); status == nil || status.Running {
+		t.Errorf("Status() = %+v, want the lease released", status)
+	}
+
+	if _, err := first.Trigger(ctx, "export"); !errors.Is(err, ErrScheduleNotFound) {
+		t.Errorf("Trigger() of an unknown job error = %v, want ErrScheduleNotFound", err)
+	}
+}
+
+func TestSchedulerRejectsInvalidCron(t *testing.T) {
+	s := NewScheduler(repositories.NewMemoryScheduleRepository(), repositories.NewMemoryLeaseRepository(), SchedulerOptions{})
+	if err := s.Add(ScheduledTask{Name: "cleanup", Schedule: "every day"}); err == nil {
+		t.Error("Add() with an invalid cron expression error = nil")
+	}
+}
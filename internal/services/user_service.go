@@ -2,27 +2,47 @@ package services
 
 import (
 	"context"
+	"errors"
+	"slices"
+	"sync"
 	"time"
 
 	"github.com/lucas/go-rest-api-mongo/internal/dto"
+	"github.com/lucas/go-rest-api-mongo/internal/events"
 	"github.com/lucas/go-rest-api-mongo/internal/models"
 	"github.com/lucas/go-rest-api-mongo/internal/repositories"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type UserService struct {
-	repo        *repositories.UserRepository
+	repo        repositories.UserStore
 	authService *AuthService
+	emitter     *EventEmitter
 }
 
-func NewUserService(repo *repositories.UserRepository, authService *AuthService) *UserService {
+// NewUserService cria o serviço de usuários. Cada mudança de estado é publicada pelo
+// emitter; com um emitter nil nenhum evento é publicado.
+func NewUserService(repo repositories.UserStore, authService *AuthService, emitter *EventEmitter) *UserService {
 	return &UserService{
 		repo:        repo,
 		authService: authService,
+		emitter:     emitter,
 	}
 }
 
 func (s *UserService) Register(ctx context.Context, req *dto.RegisterRequest) (*models.User, error) {
+	return s.create(ctx, req, models.RoleUser)
+}
+
+// CreateWithRole registra um usuário já com a role informada, para uso administrativo.
+func (s *UserService) CreateWithRole(ctx context.Context, req *dto.RegisterRequest, role string) (*models.User, error) {
+	if !slices.Contains(models.Roles, role) {
+		return nil, ErrInvalidRole
+	}
+	return s.create(ctx, req, role)
+}
+
+func (s *UserService) create(ctx context.Context, req *dto.RegisterRequest, role string) (*models.User, error) {
 	existingUser, err := s.repo.FindByEmail(ctx, req.Email)
 	if err != nil {
 		return nil, err
@@ -40,35 +60,197 @@ func (s *UserService) Register(ctx context.Context, req *dto.RegisterRequest) (*
 		Email:     req.Email,
 		Password:  hashedPassword,
 		Name:      req.Name,
+		Role:      role,
+		Locale:    req.Locale,
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}
 
 	if err := s.repo.Create(ctx, user); err != nil {
+		if errors.Is(err, repositories.ErrDuplicateKey) {
+			return nil, ErrEmailExists
+		}
 		return nil, err
 	}
 
+	s.emitter.Emit(ctx, events.NewUserRegistered(user))
 	return user, nil
 }
 
+// RegisterResult é o resultado de um item de RegisterBatch.
+type RegisterResult struct {
+	User *models.User
+	Err  error
+}
+
+// RegisterBatch registra vários usuários de uma vez. O resultado tem a mesma ordem de reqs.
+func (s *UserService) RegisterBatch(ctx context.Context, reqs []*dto.RegisterRequest) []RegisterResult {
+	results := s.PrepareBatch(reqs)
+
+	users := make([]*models.User, 0, len(results))
+	indexes := make([]int, 0, len(results))
+	for i, result := range results {
+		if result.Err == nil {
+			users = append(users, result.User)
+			indexes = append(indexes, i)
+		}
+	}
+
+	createErrs := s.CreateBatch(ctx, users)
+	for j, i := range indexes {
+		if createErrs[j] != nil {
+			results[i] = RegisterResult{Err: createErrs[j]}
+		}
+	}
+
+	return results
+}
+
+// PrepareBatch monta os usuários de um lote sem acessar o banco: emails repetidos dentro
+// do lote são rejeitados e as senhas são hasheadas em paralelo. Os usuários retornados
+// podem ser passados (e repassados, em caso de erro transitório) para CreateBatch.
+func (s *UserService) PrepareBatch(reqs []*dto.RegisterRequest) []RegisterResult {
+	results := make([]RegisterResult, len(reqs))
+
+	// Deduplica emails dentro do lote: apenas a primeira ocorrência é mantida
+	seen := make(map[string]bool, len(reqs))
+	hashes := make([]string, len(reqs))
+	var wg sync.WaitGroup
+	for i, req := range reqs {
+		if seen[req.Email] {
+			results[i].Err = ErrEmailExists
+			continue
+		}
+		seen[req.Email] = true
+
+		// bcrypt é caro em CPU, então os hashes são calculados em paralelo
+		wg.Add(1)
+		go func(i int, password string) {
+			defer wg.Done()
+			hashes[i], results[i].Err = s.authService.HashPassword(password)
+		}(i, req.Password)
+	}
+	wg.Wait()
+
+	now := time.Now()
+	for i, req := range reqs {
+		if results[i].Err != nil {
+			continue
+		}
+		results[i].User = &models.User{
+			ID:        primitive.NewObjectID(),
+			Email:     req.Email,
+			Password:  hashes[i],
+			Name:      req.Name,
+			Role:      models.RoleUser,
+			Locale:    req.Locale,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+	}
+
+	return results
+}
+
+// CreateBatch persiste usuários preparados por PrepareBatch com uma única consulta $in
+// e um único InsertMany não ordenado, retornando o erro de cada item.
+// Um usuário que já existe com o mesmo ID conta como sucesso, o que torna seguro
+// repetir a chamada depois de um erro transitório com resultado incerto.
+func (s *UserService) CreateBatch(ctx context.Context, users []*models.User) []error {
+	errs := make([]error, len(users))
+	if len(users) == 0 {
+		return errs
+	}
+
+	emails := make([]string, len(users))
+	for i, user := range users {
+		emails[i] = user.Email
+	}
+
+	existing, err := s.repo.FindByEmails(ctx, emails)
+	if err != nil {
+		for i := range errs {
+			errs[i] = err
+		}
+		return errs
+	}
+
+	existingIDs := make(map[string]primitive.ObjectID, len(existing))
+	for _, user := range existing {
+		existingIDs[user.Email] = user.ID
+	}
+
+	toCreate := make([]*models.User, 0, len(users))
+	indexes := make([]int, 0, len(users))
+	for i, user := range users {
+		if id, ok := existingIDs[user.Email]; ok {
+			if id != user.ID {
+				errs[i] = ErrEmailExists
+			}
+			continue
+		}
+		toCreate = append(toCreate, user)
+		indexes = append(indexes, i)
+	}
+
+	createErrs := s.repo.CreateMany(ctx, toCreate)
+	for j, i := range indexes {
+		if errors.Is(createErrs[j], repositories.ErrDuplicateKey) {
+			errs[i] = ErrEmailExists
+		} else {
+			errs[i] = createErrs[j]
+		}
+	}
+
+	return errs
+}
+
 func (s *UserService) Login(ctx context.Context, req *dto.LoginRequest) (*dto.LoginResponse, error) {
 	user, err := s.repo.FindByEmail(ctx, req.Email)
 	if err != nil {
 		return nil, err
 	}
 	if user == nil {
+		s.emitter.Emit(ctx, &events.UserLoginFailed{
+			Email:    req.Email,
+			Reason:   events.LoginFailedUnknownEmail,
+			FailedAt: time.Now(),
+		})
 		return nil, ErrInvalidCredentials
 	}
 
 	if err := s.authService.ComparePassword(user.Password, req.Password); err != nil {
+		s.emitter.Emit(ctx, &events.UserLoginFailed{
+			UserID:   user.ID.Hex(),
+			Email:    user.Email,
+			Reason:   events.LoginFailedInvalidPassword,
+			FailedAt: time.Now(),
+		})
 		return nil, ErrInvalidCredentials
 	}
 
-	token, err := s.authService.GenerateToken(user.ID, user.Email)
+	// A senha é conferida antes para não revelar quais contas estão desativadas
+	if user.Disabled() {
+		s.emitter.Emit(ctx, &events.UserLoginFailed{
+			UserID:   user.ID.Hex(),
+			Email:    user.Email,
+			Reason:   events.LoginFailedUserDisabled,
+			FailedAt: time.Now(),
+		})
+		return nil, ErrUserDisabled
+	}
+
+	token, err := s.authService.GenerateToken(user)
 	if err != nil {
 		return nil, err
 	}
 
+	s.emitter.Emit(ctx, &events.UserLoggedIn{
+		UserID:     user.ID.Hex(),
+		Email:      user.Email,
+		LoggedInAt: time.Now(),
+	})
+
 	return &dto.LoginResponse{
 		Token: token,
 		User: dto.UserResponse{
@@ -81,5 +263,211 @@ func (s *UserService) Login(ctx context.Context, req *dto.LoginRequest) (*dto.Lo
 }
 
 func (s *UserService) GetByID(ctx context.Context, userID primitive.ObjectID) (*models.User, error) {
-	return s.repo.FindByID(ctx, userID)
+	user, err := s.repo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	return user, nil
+}
+
+// Find busca o usuário por ID ou, quando ref não é um ObjectID, por email.
+func (s *UserService) Find(ctx context.Context, ref string) (*models.User, error) {
+	if id, err := primitive.ObjectIDFromHex(ref); err == nil {
+		return s.GetByID(ctx, id)
+	}
+
+	user, err := s.repo.FindByEmail(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	return user, nil
+}
+
+// UpdateProfile altera o nome e/ou o email do usuário. Sem nenhuma mudança efetiva,
+// o usuário é devolvido sem escrita no banco e sem evento.
+func (s *UserService) UpdateProfile(ctx context.Context, userID primitive.ObjectID, req *dto.UpdateProfileRequest) (*models.User, error) {
+	user, err := s.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var changed []string
+	if req.Name != nil && *req.Name != user.Name {
+		user.Name = *req.Name
+		changed = append(changed, "name")
+	}
+	if req.Email != nil && *req.Email != user.Email {
+		user.Email = *req.Email
+		changed = append(changed, "email")
+	}
+	if len(changed) == 0 {
+		return user, nil
+	}
+
+	user.UpdatedAt = time.Now()
+	if err := s.update(ctx, user); err != nil {
+		return nil, err
+	}
+
+	s.emitter.Emit(ctx, &events.UserUpdated{
+		UserID:    user.ID.Hex(),
+		Email:     user.Email,
+		Name:      user.Name,
+		Changed:   changed,
+		UpdatedAt: user.UpdatedAt,
+	})
+	return user, nil
+}
+
+// ChangePassword troca a senha depois de conferir a senha atual.
+func (s *UserService) ChangePassword(ctx context.Context, userID primitive.ObjectID, req *dto.ChangePasswordRequest) error {
+	user, err := s.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.authService.ComparePassword(user.Password, req.CurrentPassword); err != nil {
+		return ErrInvalidPassword
+	}
+
+	hashedPassword, err := s.authService.HashPassword(req.NewPassword)
+	if err != nil {
+		return err
+	}
+
+	user.Password = hashedPassword
+	user.UpdatedAt = time.Now()
+	if err := s.update(ctx, user); err != nil {
+		return err
+	}
+
+	s.emitter.Emit(ctx, &events.UserPasswordChanged{
+		UserID:    user.ID.Hex(),
+		ChangedAt: user.UpdatedAt,
+	})
+	return nil
+}
+
+// ResetPassword troca a senha sem conferir a atual, para uso administrativo.
+func (s *UserService) ResetPassword(ctx context.Context, userID primitive.ObjectID, newPassword string) error {
+	user, err := s.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	hashedPassword, err := s.authService.HashPassword(newPassword)
+	if err != nil {
+		return err
+	}
+
+	user.Password = hashedPassword
+	user.UpdatedAt = time.Now()
+	if err := s.update(ctx, user); err != nil {
+		return err
+	}
+
+	s.emitter.Emit(ctx, &events.UserPasswordChanged{
+		UserID:    user.ID.Hex(),
+		ChangedAt: user.UpdatedAt,
+	})
+	return nil
+}
+
+// SetRole altera a role do usuário. A nova role vale na hora, também para tokens já
+// emitidos: o AuthMiddleware lê a role do usuário gravado.
+func (s *UserService) SetRole(ctx context.Context, userID primitive.ObjectID, role string) (*models.User, error) {
+	if !slices.Contains(models.Roles, role) {
+		return nil, ErrInvalidRole
+	}
+	return s.updateAdmin(ctx, userID, "role", func(user *models.User) bool {
+		if user.Role == role {
+			return false
+		}
+		user.Role = role
+		return true
+	})
+}
+
+// SetDisabled desativa ou reativa o usuário. Usuários desativados não conseguem fazer
+// login, e o AuthMiddleware recusa os tokens já emitidos enquanto durar a desativação.
+func (s *UserService) SetDisabled(ctx context.Context, userID primitive.ObjectID, disabled bool) (*models.User, error) {
+	return s.updateAdmin(ctx, userID, "disabled_at", func(user *models.User) bool {
+		if user.Disabled() == disabled {
+			return false
+		}
+		user.DisabledAt = nil
+		if disabled {
+			now := time.Now()
+			user.DisabledAt = &now
+		}
+		return true
+	})
+}
+
+// updateAdmin aplica apply ao usuário e publica UserUpdated com field quando apply
+// indica uma mudança efetiva.
+func (s *UserService) updateAdmin(ctx context.Context, userID primitive.ObjectID, field string, apply func(user *models.User) bool) (*models.User, error) {
+	user, err := s.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !apply(user) {
+		return user, nil
+	}
+
+	user.UpdatedAt = time.Now()
+	if err := s.update(ctx, user); err != nil {
+		return nil, err
+	}
+
+	s.emitter.Emit(ctx, &events.UserUpdated{
+		UserID:    user.ID.Hex(),
+		Email:     user.Email,
+		Name:      user.Name,
+		Changed:   []string{field},
+		UpdatedAt: user.UpdatedAt,
+	})
+	return user, nil
+}
+
+func (s *UserService) Delete(ctx context.Context, userID primitive.ObjectID) error {
+	user, err := s.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	deleted, err := s.repo.Delete(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrUserNotFound
+	}
+
+	s.emitter.Emit(ctx, &events.UserDeleted{
+		UserID:    user.ID.Hex(),
+		Email:     user.Email,
+		DeletedAt: time.Now(),
+	})
+	return nil
+}
+
+func (s *UserService) update(ctx context.Context, user *models.User) error {
+	updated, err := s.repo.Update(ctx, user)
+	if err != nil {
+		if errors.Is(err, repositories.ErrDuplicateKey) {
+			return ErrEmailExists
+		}
+		return err
+	}
+	if !updated {
+		return ErrUserNotFound
+	}
+	return nil
 }
@@ -0,0 +1,262 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/lucas/go-rest-api-mongo/internal/config"
+	"github.com/lucas/go-rest-api-mongo/internal/dto"
+	"github.com/lucas/go-rest-api-mongo/internal/events"
+	"github.com/lucas/go-rest-api-mongo/internal/messaging"
+	"github.com/lucas/go-rest-api-mongo/internal/models"
+	"github.com/lucas/go-rest-api-mongo/internal/repositories"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func newTestUserService() (*UserService, *repositories.MemoryUserRepository) {
+	cfg := &config.Config{JWT: config.JWTConfig{SecretKey: "test-secret", Expiration: time.Hour}}
+	repo := repositories.NewMemoryUserRepository()
+	return NewUserService(repo, NewAuthService(cfg), nil), repo
+}
+
+func TestUserServiceRegister(t *testing.T) {
+	service, repo := newTestUserService()
+	ctx := context.Background()
+
+	user, err := service.Register(ctx, &dto.RegisterRequest{Name: "Ana", Email: "ana@example.com", Password: "secret123"})
+	if err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	if user.ID.IsZero() || user.Role != models.RoleUser {
+		t.Errorf("Register() user = %+v, want an ID and the user role", user)
+	}
+
+	stored, _ := repo.FindByEmail(ctx, "ana@example.com")
+	if stored == nil || stored.Password == "secret123" {
+		t.Fatal("Register() must store the user with a hashed password")
+	}
+
+	_, err = service.Register(ctx, &dto.RegisterRequest{Name: "Ana", Email: "ana@example.com", Password: "secret123"})
+	if !errors.Is(err, ErrEmailExists) {
+		t.Fatalf("Register() duplicate error = %v, want ErrEmailExists", err)
+	}
+}
+
+func TestUserServiceRegisterBatch(t *testing.T) {
+	service, _ := newTestUserService()
+	ctx := context.Background()
+
+	if _, err := service.Register(ctx, &dto.RegisterRequest{Name: "Old", Email: "old@example.com", Password: "secret123"}); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	results := service.RegisterBatch(ctx, []*dto.RegisterRequest{
+		{Name: "A", Email: "a@example.com", Password: "secret123"},
+		{Name: "A again", Email: "a@example.com", Password: "secret123"},
+		{Name: "Old", Email: "old@example.com", Password: "secret123"},
+		{Name: "B", Email: "b@example.com", Password: "secret123"},
+	})
+
+	if results[0].Err != nil || results[0].User == nil || results[3].Err != nil {
+		t.Fatalf("RegisterBatch() new users failed: %+v", results)
+	}
+	if !errors.Is(results[1].Err, ErrEmailExists) {
+		t.Errorf("duplicate in batch error = %v, want ErrEmailExists", results[1].Err)
+	}
+	if !errors.Is(results[2].Err, ErrEmailExists) {
+		t.Errorf("existing email error = %v, want ErrEmailExists", results[2].Err)
+	}
+}
+
+func TestUserServiceCreateBatchIsRetrySafe(t *testing.T) {
+	service, _ := newTestUserService()
+	ctx := context.Background()
+
+	prepared := service.PrepareBatch([]*dto.RegisterRequest{{Name: "A", Email: "a@example.com", Password: "secret123"}})
+	users := []*models.User{prepared[0].User}
+
+	if errs := service.CreateBatch(ctx, users); errs[0] != nil {
+		t.Fatalf("CreateBatch() error = %v", errs[0])
+	}
+	// Repetir o mesmo usuário (mesmo ID) depois de um erro incerto não é conflito
+	if errs := service.CreateBatch(ctx, users); errs[0] != nil {
+		t.Fatalf("CreateBatch() retry error = %v, want nil", errs[0])
+	}
+}
+
+func TestUserServiceLogin(t *testing.T) {
+	service, _ := newTestUserService()
+	ctx := context.Background()
+
+	if _, err := service.Register(ctx, &dto.RegisterRequest{Name: "Ana", Email: "ana@example.com", Password: "secret123"}); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	resp, err := service.Login(ctx, &dto.LoginRequest{Email: "ana@example.com", Password: "secret123"})
+	if err != nil || resp.Token == "" {
+		t.Fatalf("Login() = %+v, %v; want a token", resp, err)
+	}
+
+	for _, req := range []*dto.LoginRequest{
+		{Email: "ana@example.com", Password: "wrong"},
+		{Email: "nobody@example.com", Password: "secret123"},
+	} {
+		if _, err := service.Login(ctx, req); !errors.Is(err, ErrInvalidCredentials) {
+			t.Errorf("Login(%s) error = %v, want ErrInvalidCredentials", req.Email, err)
+		}
+	}
+}
+
+func TestUserServiceGetByIDNotFound(t *testing.T) {
+	service, _ := newTestUserService()
+
+	_, err := service.GetByID(context.Background(), primitive.NewObjectID())
+	if !errors.Is(err, ErrUserNotFound) {
+		t.Fatalf("GetByID() error = %v, want ErrUserNotFound", err)
+	}
+}
+
+func TestUserServicePublishesLifecycleEvents(t *testing.T) {
+	service, _ := newTestUserService()
+	bus := messaging.NewMemoryBus(0)
+	deadLetters := repositories.NewMemoryDeadLetterRepository()
+	service.emitter = newTestEmitter(bus, deadLetters)
+	ctx := context.Background()
+
+	user, err := service.Register(ctx, &dto.RegisterRequest{Name: "Ana", Email: "ana@example.com", Password: "secret123"})
+	if err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	service.Login(ctx, &dto.LoginRequest{Email: "nobody@example.com", Password: "secret123"})
+	service.Login(ctx, &dto.LoginRequest{Email: "ana@example.com", Password: "wrong"})
+	if _, err := service.Login(ctx, &dto.LoginRequest{Email: "ana@example.com", Password: "secret123"}); err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+
+	name := "Ana Maria"
+	if _, err := service.UpdateProfile(ctx, user.ID, &dto.UpdateProfileRequest{Name: &name}); err != nil {
+		t.Fatalf("UpdateProfile() error = %v", err)
+	}
+	err = service.ChangePassword(ctx, user.ID, &dto.ChangePasswordRequest{CurrentPassword: "wrong", NewPassword: "secret456"})
+	if !errors.Is(err, ErrInvalidPassword) {
+		t.Fatalf("ChangePassword() error = %v, want ErrInvalidPassword", err)
+	}
+	if err := service.ChangePassword(ctx, user.ID, &dto.ChangePasswordRequest{CurrentPassword: "secret123", NewPassword: "secret456"}); err != nil {
+		t.Fatalf("ChangePassword() error = %v", err)
+	}
+	if err := service.Delete(ctx, user.ID); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if err := service.Delete(ctx, user.ID); !errors.Is(err, ErrUserNotFound) {
+		t.Fatalf("Delete() twice error = %v, want ErrUserNotFound", err)
+	}
+
+	want := []struct{ eventType, key string }{
+		{events.TypeUserRegistered, user.ID.Hex()},
+		{events.TypeUserLoginFailed, "nobody@example.com"},
+		{events.TypeUserLoginFailed, user.ID.Hex()},
+		{events.TypeUserLoggedIn, user.ID.Hex()},
+		{events.TypeUserUpdated, user.ID.Hex()},
+		{events.TypeUserPasswordChanged, user.ID.Hex()},
+		{events.TypeUserDeleted, user.ID.Hex()},
+	}
+	messages := bus.Messages(testEventsTopic)
+	if len(messages) != len(want) {
+		t.Fatalf("published %d events, want %d", len(messages), len(want))
+	}
+	for i, message := range messages {
+		ce, err := events.Decode(message)
+		if err != nil {
+			t.Fatalf("Decode() error = %v", err)
+		}
+		if ce.Type != want[i].eventType || message.Key != want[i].key {
+			t.Errorf("event %d = %s keyed %q, want %s keyed %q", i, ce.Type, message.Key, want[i].eventType, want[i].key)
+		}
+	}
+	if got := len(bus.Messages(testTopic)); got != 1 {
+		t.Errorf("published %d events to %s, want only the registration", got, testTopic)
+	}
+}
+
+func TestUserServiceUpdateProfileRejectsTakenEmail(t *testing.T) {
+	service, _ := newTestUserService()
+	ctx := context.Background()
+
+	ana, _ := service.Register(ctx, &dto.RegisterRequest{Name: "Ana", Email: "ana@example.com", Password: "secret123"})
+	service.Register(ctx, &dto.RegisterRequest{Name: "Bia", Email: "bia@example.com", Password: "secret123"})
+
+	email := "bia@example.com"
+	if _, err := service.UpdateProfile(ctx, ana.ID, &dto.UpdateProfileRequest{Email: &email}); !errors.Is(err, ErrEmailExists) {
+		t.Fatalf("UpdateProfile() error = %v, want ErrEmailExists", err)
+	}
+}
+
+func TestUserServiceAdminOperations(t *testing.T) {
+	service, _ := newTestUserService()
+	bus := messaging.NewMemoryBus(0)
+	service.emitter = newTestEmitter(bus, repositories.NewMemoryDeadLetterRepository())
+	ctx := context.Background()
+
+	if _, err := service.CreateWithRole(ctx, &dto.RegisterRequest{Name: "Root", Email: "root@example.com", Password: "secret123"}, "root"); !errors.Is(err, ErrInvalidRole) {
+		t.Fatalf("CreateWithRole() error = %v, want ErrInvalidRole", err)
+	}
+	user, err := service.CreateWithRole(ctx, &dto.RegisterRequest{Name: "Ana", Email: "ana@example.com", Password: "secret123"}, models.RoleAdmin)
+	if err != nil || user.Role != models.RoleAdmin {
+		t.Fatalf("CreateWithRole() = %+v, %v; want an admin", user, err)
+	}
+	if found, err := service.Find(ctx, "ana@example.com"); err != nil || found.ID != user.ID {
+		t.Fatalf("Find(email) = %+v, %v", found, err)
+	}
+	if found, err := service.Find(ctx, user.ID.Hex()); err != nil || found.ID != user.ID {
+		t.Fatalf("Find(id) = %+v, %v", found, err)
+	}
+
+	if _, err := service.SetRole(ctx, user.ID, models.RoleUser); err != nil {
+		t.Fatalf("SetRole() error = %v", err)
+	}
+	if _, err := service.SetDisabled(ctx, user.ID, true); err != nil {
+		t.Fatalf("SetDisabled() error = %v", err)
+	}
+	// Desativar de novo não é uma mudança e não gera evento
+	if _, err := service.SetDisabled(ctx, user.ID, true); err != nil {
+		t.Fatalf("SetDisabled() twice error = %v", err)
+	}
+	if _, err := service.Login(ctx, &dto.LoginRequest{Email: "ana@example.com", Password: "secret123"}); !errors.Is(err, ErrUserDisabled) {
+		t.Fatalf("Login() disabled error = %v, want ErrUserDisabled", err)
+	}
+	if _, err := service.Login(ctx, &dto.LoginRequest{Email: "ana@example.com", Password: "wrong"}); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("Login() disabled with wrong password error = %v, want ErrInvalidCredentials", err)
+	}
+
+	if err := service.ResetPassword(ctx, user.ID, "secret456"); err != nil {
+		t.Fatalf("ResetPassword() error = %v", err)
+	}
+	if _, err := service.SetDisabled(ctx, user.ID, false); err != nil {
+		t.Fatalf("SetDisabled(false) error = %v", err)
+	}
+	if _, err := service.Login(ctx, &dto.LoginRequest{Email: "ana@example.com", Password: "secret456"}); err != nil {
+		t.Fatalf("Login() after reset error = %v", err)
+	}
+
+	want := []string{
+		events.TypeUserRegistered,
+		events.TypeUserUpdated, // role
+		events.TypeUserUpdated, // disabled_at
+		events.TypeUserLoginFailed,
+		events.TypeUserLoginFailed,
+		events.TypeUserPasswordChanged,
+		events.TypeUserUpdated, // disabled_at
+		events.TypeUserLoggedIn,
+	}
+	messages := bus.Messages(testEventsTopic)
+	if len(messages) != len(want) {
+		t.Fatalf("published %d events, want %d", len(messages), len(want))
+	}
+	for i, message := range messages {
+		if ce, _ := events.Decode(message); ce.Type != want[i] {
+			t.Errorf("event %d = %s, want %s", i, ce.Type, want[i])
+		}
+	}
+}
@@ -2,115 +2,344 @@ package services
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"log"
+	"sync"
+	"sync/atomic"
 	"time"
 
-	"github.com/lucas/go-rest-api-mongo/internal/dto"
 	"github.com/lucas/go-rest-api-mongo/internal/messaging"
+	"github.com/lucas/go-rest-api-mongo/internal/models"
+	"github.com/lucas/go-rest-api-mongo/internal/repositories"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
-type WorkerPool struct {
-	userService   *UserService
-	kafkaProducer *messaging.KafkaProducer
-	jobQueue      chan *dto.RegisterRequest
-	workerCount   int
-	batchSize     int
-	batchTimeout  time.Duration
+// WorkerPool consome a fila de um tipo de job: cada worker reserva um lote, o passa ao
+// Handle do JobHandler, repete os jobs com erro transitório e confirma o lote. Workers
+// de vários processos podem consumir a mesma fila durável. O número de workers muda
+// com o pool rodando, com Resize ou pelo autoscaler (ver worker_pool_scaling.go).
+type WorkerPool[T any] struct {
+	handler     JobHandler[T]
+	deadLetters repositories.DeadLetterStore
+	owner       string
+	settings    atomic.Pointer[workerPoolSettings]
+	wg          sync.WaitGroup
+	stats       workerPoolStats
+
+	mu          sync.Mutex
+	ctx         context.Context // o de Start; nil antes dele
+	workerCount int             // tamanho pedido, mesmo antes de Start
+	workers     []chan struct{} // canal de parada de cada worker em execução
+	scaling     WorkerPoolScaling
 }
 
-func NewWorkerPool(
-	userService *UserService,
-	kafkaProducer *messaging.KafkaProducer,
-	workerCount, batchSize int,
-	batchTimeout time.Duration) *WorkerPool {
+// workerPoolSettings são as opções que podem mudar com o pool rodando (ver Configure).
+type workerPoolSettings struct {
+	batchSize    int
+	pollInterval time.Duration
+	retryPolicy  RetryPolicy
+}
 
-	return &WorkerPool{
-		userService:   userService,
-		kafkaProducer: kafkaProducer,
-		jobQueue:      make(chan *dto.RegisterRequest, 100), // Buffer size can be adjusted
-		workerCount:   workerCount,
-		batchSize:     batchSize,
-		batchTimeout:  batchTimeout,
+// NewWorkerPool cria o pool do tipo de job de handler.Queue, com handler.Concurrency
+// workers; os limites do tamanho são ajustados com SetScaling.
+func NewWorkerPool[T any](handler JobHandler[T], deadLetters repositories.DeadLetterStore) *WorkerPool[T] {
+	wp := &WorkerPool[T]{
+		handler:     handler,
+		deadLetters: deadLetters,
+		owner:       instanceID(),
+		workerCount: handler.Concurrency,
+		scaling:     WorkerPoolScaling{Min: handler.Concurrency, Max: handler.Concurrency, Interval: defaultAutoscaleInterval},
 	}
+	wp.Configure(handler.BatchSize, handler.PollInterval, handler.RetryPolicy)
+	return wp
+}
+
+// Type devolve o tipo de job consumido pelo pool.
+func (wp *WorkerPool[T]) Type() string {
+	return wp.handler.Queue.Type()
+}
+
+// Configure troca o tamanho do lote, a espera entre consultas à fila vazia e a
+// política de retry. Cada worker passa a usá-los a partir do próximo lote.
+func (wp *WorkerPool[T]) Configure(batchSize int, pollInterval time.Duration, retryPolicy RetryPolicy) {
+	wp.settings.Store(&workerPoolSettings{
+		batchSize:    batchSize,
+		pollInterval: pollInterval,
+		retryPolicy:  retryPolicy,
+	})
+}
+
+// workerPoolStats são os contadores de WorkerPoolStats, atualizados pelos workers.
+type workerPoolStats struct {
+	batches      atomic.Int64
+	jobs         atomic.Int64
+	redelivered  atomic.Int64
+	succeeded    atomic.Int64
+	deadLettered atomic.Int64
+	busy         atomic.Int64 // tempo total processando lotes, em nanossegundos
+
+	mu            sync.Mutex
+	lastPollAt    time.Time // última consulta à fila que funcionou
+	lastPollError error
+}
+
+// WorkerPoolStats são as métricas do pool de um tipo de job, expostas pela role worker.
+type WorkerPoolStats struct {
+	Type          string      `json:"type"`
+	Workers       int         `json:"workers"`
+	MinWorkers    int         `json:"min_workers"`
+	MaxWorkers    int         `json:"max_workers"`
+	Autoscale     bool        `json:"autoscale"`
+	AvgBatchMs    float64     `json:"avg_batch_ms"` // tempo médio de processamento de um lote
+	Batches       int64       `json:"batches"`
+	Jobs          int64       `json:"jobs"`
+	Redelivered   int64       `json:"redelivered"` // jobs entregues mais de uma vez
+	Succeeded     int64       `json:"succeeded"`
+	DeadLettered  int64       `json:"dead_lettered"`
+	QueueDepth    int64       `json:"queue_depth"`
+	Lanes         []LaneStats `json:"lanes"`
+	LastPollAt    time.Time   `json:"last_poll_at"`
+	LastPollError string      `json:"last_poll_error,omitempty"`
+}
+
+func (wp *WorkerPool[T]) Start(ctx context.Context) {
+	wp.recordPoll(nil)
+
+	wp.mu.Lock()
+	wp.ctx = ctx
+	wp.resizeLocked(wp.workerCount)
+	wp.mu.Unlock()
+
+	wp.wg.Add(1)
+	go func() {
+		defer wp.wg.Done()
+		wp.autoscale(ctx)
+	}()
+}
+
+// Wait bloqueia até todos os workers terminarem, o que acontece depois que o
+// contexto passado a Start é cancelado e o lote em andamento é processado.
+func (wp *WorkerPool[T]) Wait() {
+	wp.wg.Wait()
 }
 
-func (wp *WorkerPool) Start(ctx context.Context) {
-	for i := 0; i < wp.workerCount; i++ {
-		go wp.worker(ctx)
+func (wp *WorkerPool[T]) Stats(ctx context.Context) (WorkerPoolStats, error) {
+	wp.stats.mu.Lock()
+	lastPollAt, lastPollError := wp.stats.lastPollAt, wp.stats.lastPollError
+	wp.stats.mu.Unlock()
+
+	wp.mu.Lock()
+	workers, scaling := wp.workerCount, wp.scaling
+	wp.mu.Unlock()
+
+	lanes, err := wp.handler.Queue.LaneStats(ctx)
+	var depth int64
+	for _, lane := range lanes {
+		depth += lane.Depth
+	}
+	stats := WorkerPoolStats{
+		Type:         wp.Type(),
+		Workers:      workers,
+		MinWorkers:   scaling.Min,
+		MaxWorkers:   scaling.Max,
+		Autoscale:    scaling.Autoscale,
+		Batches:      wp.stats.batches.Load(),
+		Jobs:         wp.stats.jobs.Load(),
+		Redelivered:  wp.stats.redelivered.Load(),
+		Succeeded:    wp.stats.succeeded.Load(),
+		DeadLettered: wp.stats.deadLettered.Load(),
+		QueueDepth:   depth,
+		Lanes:        lanes,
+		LastPollAt:   lastPollAt,
+	}
+	if stats.Batches > 0 {
+		stats.AvgBatchMs = float64(wp.stats.busy.Load()) / float64(stats.Batches) / float64(time.Millisecond)
+	}
+	if lastPollError != nil {
+		stats.LastPollError = lastPollError.Error()
 	}
+	return stats, err
 }
 
-func (wp *WorkerPool) Submit(req *dto.RegisterRequest) error {
-	select {
-	case wp.jobQueue <- req:
-		return nil
-	default:
-		return fmt.Errorf("worker pool queue is full")
+// Healthy retorna um erro quando os workers não conseguem consultar a fila há mais
+// tempo que a reserva de um lote.
+func (wp *WorkerPool[T]) Healthy() error {
+	wp.stats.mu.Lock()
+	defer wp.stats.mu.Unlock()
+
+	if since := time.Since(wp.stats.lastPollAt); since > wp.handler.Timeout+wp.settings.Load().pollInterval {
+		return fmt.Errorf("%s queue not polled for %s: %v", wp.Type(), since.Round(time.Second), wp.stats.lastPollError)
 	}
+	return nil
 }
 
-func (wp *WorkerPool) worker(ctx context.Context) {
-	batch := make([]*dto.RegisterRequest, 0, wp.batchSize)
-	ticker := time.NewTicker(wp.batchTimeout)
-	defer ticker.Stop()
+func (wp *WorkerPool[T]) recordPoll(err error) {
+	wp.stats.mu.Lock()
+	defer wp.stats.mu.Unlock()
+
+	wp.stats.lastPollError = err
+	if err == nil {
+		wp.stats.lastPollAt = time.Now()
+	}
+}
 
+// worker processa lotes até ctx ser cancelado ou stop ser fechado; nos dois casos o
+// lote em andamento termina antes.
+func (wp *WorkerPool[T]) worker(ctx context.Context, stop <-chan struct{}) {
 	for {
 		select {
-		case req := <-wp.jobQueue:
-			batch = append(batch, req)
-			if len(batch) >= wp.batchSize {
-				wp.processBatch(ctx, batch)
-				batch = batch[:0] // Reset batch
-			}
+		case <-stop:
+			return
+		default:
+		}
 
-		case <-ticker.C:
-			if len(batch) > 0 {
-				wp.processBatch(ctx, batch)
-				batch = batch[:0]
-			}
+		settings := wp.settings.Load()
+		jobs, invalid, err := wp.handler.Queue.dequeue(ctx, wp.owner, settings.batchSize, wp.handler.Timeout)
+		if ctx.Err() != nil && len(jobs) == 0 && len(invalid) == 0 {
+			return
+		}
+		wp.recordPoll(err)
+		if err != nil {
+			log.Printf("Error reading the %s queue: %v", wp.Type(), err)
+		}
 
-		case <-ctx.Done():
-			// Processa batch restante antes de sair
-			if len(batch) > 0 {
-				wp.processBatch(context.Background(), batch)
+		if len(jobs) == 0 && len(invalid) == 0 {
+			timer := time.NewTimer(settings.pollInterval)
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-stop:
+				timer.Stop()
+				return
 			}
-			return
+			continue
 		}
+
+		// O lote reservado é processado e confirmado mesmo durante o shutdown
+		wp.processJobs(context.WithoutCancel(ctx), jobs, invalid, settings.retryPolicy)
 	}
 }
 
-func (wp *WorkerPool) processBatch(ctx context.Context, batch []*dto.RegisterRequest) {
-	log.Printf("Processing batch of %d registrations", len(batch))
+// processJobs processa um lote reservado dentro do Timeout do handler e confirma
+// todos os jobs: os que falharam já viraram dead letters e não devem voltar para a fila.
+func (wp *WorkerPool[T]) processJobs(ctx context.Context, jobs []Job[T], invalid []*models.Job, retryPolicy RetryPolicy) {
+	start := time.Now()
+	defer func() { wp.stats.busy.Add(int64(time.Since(start))) }()
 
-	for _, req := range batch {
-		// Registra o usuário no banco
-		user, err := wp.userService.Register(ctx, req)
-		if err != nil {
-			log.Printf("Error registering user %s: %v", req.Email, err)
-			continue
+	ids := make([]primitive.ObjectID, 0, len(jobs)+len(invalid))
+	for _, job := range jobs {
+		if job.Attempts > 1 {
+			wp.stats.redelivered.Add(1)
 		}
+		ids = append(ids, job.ID)
+	}
+	wp.stats.jobs.Add(int64(len(jobs) + len(invalid)))
+	wp.stats.batches.Add(1)
+
+	var deadLetters []*models.DeadLetter
+	for _, job := range invalid {
+		log.Printf("Discarding %s job %s with an invalid payload", job.Type, job.ID.Hex())
+		deadLetters = append(deadLetters, newJobDeadLetter(job.Type, job.Payload, fmt.Errorf("invalid %s payload", job.Type), job.Attempts))
+		ids = append(ids, job.ID)
+	}
 
-		// Publica evento no Kafka
-		event := map[string]interface{}{
-			"event_type": "user_registered",
-			"user_id":    user.ID.Hex(),
-			"email":      user.Email,
-			"name":       user.Name,
-			"timestamp":  time.Now().Unix(),
+	if len(jobs) > 0 {
+		handleCtx, cancel := context.WithTimeout(ctx, wp.handler.Timeout)
+		succeeded, failed := wp.runWithRetry(handleCtx, jobs, retryPolicy)
+		cancel()
+		wp.stats.succeeded.Add(int64(succeeded))
+		deadLetters = append(deadLetters, failed...)
+		log.Printf("Processed %d of %d %s jobs", succeeded, len(jobs), wp.Type())
+	}
+
+	if len(deadLetters) > 0 {
+		wp.stats.deadLettered.Add(int64(len(deadLetters)))
+		// O timeout do lote pode já ter passado, mas a dead letter não pode se perder
+		if err := wp.deadLetters.CreateMany(ctx, deadLetters); err != nil {
+			log.Printf("Error saving %d dead letters: %v", len(deadLetters), err)
+		} else {
+			log.Printf("Moved %d failed %s jobs to the dead letter store", len(deadLetters), wp.Type())
 		}
+	}
 
-		eventData, err := json.Marshal(event)
-		if err != nil {
-			log.Printf("Error marshaling event for user %s: %v", user.Email, err)
-			continue
+	if err := wp.handler.Queue.ack(ctx, ids); err != nil {
+		// Sem o ack os jobs voltam depois da reserva; o Handle precisa ser idempotente
+		log.Printf("Error acknowledging %d %s jobs: %v", len(ids), wp.Type(), err)
+	}
+}
+
+// runWithRetry chama o Handle repetindo apenas os jobs com erro transitório. Jobs que
+// esgotam as tentativas ou falham de forma permanente viram dead letters.
+func (wp *WorkerPool[T]) runWithRetry(ctx context.Context, jobs []Job[T], retryPolicy RetryPolicy) (int, []*models.DeadLetter) {
+	var deadLetters []*models.DeadLetter
+	succeeded := 0
+
+	pending := jobs
+	for attempt := 1; len(pending) > 0; attempt++ {
+		errs := wp.handler.Handle(ctx, pending)
+
+		var retry []Job[T]
+		var lastErr error
+		for i, job := range pending {
+			var err error
+			if errs != nil {
+				err = errs[i]
+			}
+			switch {
+			case err == nil:
+				succeeded++
+			case IsRetryable(err) && attempt < retryPolicy.MaxAttempts:
+				retry = append(retry, job)
+				lastErr = err
+			default:
+				log.Printf("Giving up %s job %s after %d attempts: %v", wp.Type(), job.ID.Hex(), attempt, err)
+				deadLetters = append(deadLetters, wp.deadLetter(job, err, attempt))
+			}
 		}
 
-		if err := wp.kafkaProducer.PublishUserRegistration(ctx, eventData); err != nil {
-			log.Printf("Error publishing event for user %s: %v", user.Email, err)
-		} else {
-			log.Printf("Successfully registered and published event for user: %s", user.Email)
+		if len(retry) == 0 {
+			break
 		}
+
+		delay := retryPolicy.Backoff(attempt)
+		log.Printf("Retrying %d %s jobs in %s (attempt %d): %v", len(retry), wp.Type(), delay, attempt, lastErr)
+		if err := sleepContext(ctx, delay); err != nil {
+			for _, job := range retry {
+				deadLetters = append(deadLetters, wp.deadLetter(job, err, attempt))
+			}
+			break
+		}
+		pending = retry
+	}
+
+	return succeeded, deadLetters
+}
+
+func (wp *WorkerPool[T]) deadLetter(job Job[T], err error, attempts int) *models.DeadLetter {
+	if wp.handler.DeadLetter != nil {
+		return wp.handler.DeadLetter(job, err, attempts)
+	}
+	return newJobDeadLetter(wp.Type(), job.raw, err, attempts)
+}
+
+func newEventDeadLetter(message messaging.Message, err error, attempts int) *models.DeadLetter {
+	letter := newDeadLetter(models.DeadLetterKindEvent, err, attempts)
+	letter.Topic = message.Topic
+	letter.Key = message.Key
+	letter.Headers = message.Headers
+	letter.Payload = string(message.Payload)
+	return letter
+}
+
+func newDeadLetter(kind string, err error, attempts int) *models.DeadLetter {
+	now := time.Now()
+	return &models.DeadLetter{
+		Kind:          kind,
+		Error:         err.Error(),
+		Attempts:      attempts,
+		CreatedAt:     now,
+		LastAttemptAt: now,
 	}
 }
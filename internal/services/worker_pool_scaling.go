@@ -0,0 +1,161 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"time"
+)
+
+// defaultAutoscaleInterval é o intervalo do autoscaler até SetScaling ser chamado.
+const defaultAutoscaleInterval = 10 * time.Second
+
+// WorkerPoolScaling são os limites do número de workers e o autoscaler. Com Autoscale,
+// a cada Interval o pool cresce até dar conta da fila em um intervalo, estimando a
+// vazão de cada worker pelo tempo médio dos lotes, e encolhe um worker por vez quando
+// sobra capacidade.
+type WorkerPoolScaling struct {
+	Min       int
+	Max       int
+	Autoscale bool
+	Interval  time.Duration
+}
+
+// SetScaling troca os limites e liga ou desliga o autoscaler; o tamanho atual é
+// ajustado para ficar dentro dos novos limites.
+func (wp *WorkerPool[T]) SetScaling(scaling WorkerPoolScaling) {
+	if scaling.Interval <= 0 {
+		scaling.Interval = defaultAutoscaleInterval
+	}
+
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+
+	wp.scaling = scaling
+	if size := min(max(wp.workerCount, scaling.Min), scaling.Max); size != wp.workerCount {
+		log.Printf("Resizing %s worker pool from %d to %d workers to fit %d-%d", wp.Type(), wp.workerCount, size, scaling.Min, scaling.Max)
+		wp.resizeLocked(size)
+	}
+}
+
+// SetAutoscale liga ou desliga o autoscaler, mantendo os limites.
+func (wp *WorkerPool[T]) SetAutoscale(enabled bool) {
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+	wp.scaling.Autoscale = enabled
+}
+
+// Size devolve o número de workers pedido, que os workers em excesso atingem ao
+// terminar o lote em andamento.
+func (wp *WorkerPool[T]) Size() int {
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+	return wp.workerCount
+}
+
+// Resize muda o número de workers dentro dos limites de SetScaling. Workers novos
+// começam na hora; os removidos param depois do lote em andamento. Com o autoscaler
+// ligado, ele continua a partir do novo tamanho.
+func (wp *WorkerPool[T]) Resize(size int) error {
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+
+	if size < wp.scaling.Min || size > wp.scaling.Max {
+		return fmt.Errorf("%w: %d is not between %d and %d", ErrPoolSizeOutOfRange, size, wp.scaling.Min, wp.scaling.Max)
+	}
+	if size != wp.workerCount {
+		log.Printf("Resizing %s worker pool from %d to %d workers", wp.Type(), wp.workerCount, size)
+		wp.resizeLocked(size)
+	}
+	return nil
+}
+
+// resizeLocked inicia ou para workers até chegar a size. Antes de Start só guarda o
+// tamanho; depois do cancelamento do contexto de Start, não inicia mais ninguém.
+func (wp *WorkerPool[T]) resizeLocked(size int) {
+	wp.workerCount = size
+	if wp.ctx == nil || wp.ctx.Err() != nil {
+		return
+	}
+
+	ctx := wp.ctx
+	for len(wp.workers) < size {
+		stop := make(chan struct{})
+		wp.workers = append(wp.workers, stop)
+		wp.wg.Add(1)
+		go func() {
+			defer wp.wg.Done()
+			wp.worker(ctx, stop)
+		}()
+	}
+	for len(wp.workers) > size {
+		last := len(wp.workers) - 1
+		close(wp.workers[last])
+		wp.workers = wp.workers[:last]
+	}
+}
+
+// autoscale ajusta o tamanho do pool a cada intervalo, enquanto o autoscaler estiver
+// ligado.
+func (wp *WorkerPool[T]) autoscale(ctx context.Context) {
+	var lastBatches, lastBusy int64
+	for {
+		wp.mu.Lock()
+		scaling := wp.scaling
+		wp.mu.Unlock()
+
+		if sleepContext(ctx, scaling.Interval) != nil {
+			return
+		}
+
+		// Tempo médio dos lotes terminados no intervalo; zero se nenhum terminou
+		batches, busy := wp.stats.batches.Load(), wp.stats.busy.Load()
+		var latency time.Duration
+		if n := batches - lastBatches; n > 0 {
+			latency = time.Duration((busy - lastBusy) / n)
+		}
+		lastBatches, lastBusy = batches, busy
+
+		if !scaling.Autoscale {
+			continue
+		}
+		depth, err := wp.handler.Queue.Depth(ctx)
+		if err != nil {
+			log.Printf("Error reading the %s queue depth for autoscaling: %v", wp.Type(), err)
+			continue
+		}
+
+		wp.mu.Lock()
+		current := wp.workerCount
+		desired := desiredWorkers(current, depth, wp.settings.Load().batchSize, latency, wp.scaling)
+		if wp.scaling.Autoscale && desired != current {
+			log.Printf("Autoscaling %s worker pool from %d to %d workers (queue depth %d, batch latency %s)", wp.Type(), current, desired, depth, latency.Round(time.Millisecond))
+			wp.resizeLocked(desired)
+		}
+		wp.mu.Unlock()
+	}
+}
+
+// desiredWorkers calcula o tamanho do pool para a próxima janela. Um worker processa
+// batchSize jobs a cada latency, então a fila é esvaziada em um intervalo com
+// depth * latency / (batchSize * interval) workers. Sem lotes no intervalo (latency
+// zero), o pool cresce um worker se a fila tem mais que um lote por worker. Crescer é
+// imediato; encolher é um worker por intervalo, para não oscilar com rajadas.
+func desiredWorkers(current int, depth int64, batchSize int, latency time.Duration, scaling WorkerPoolScaling) int {
+	desired := current
+	switch {
+	case depth == 0:
+		desired = current - 1
+	case latency > 0:
+		needed := int(math.Ceil(float64(depth) * float64(latency) / (float64(batchSize) * float64(scaling.Interval))))
+		if needed > current {
+			desired = needed
+		} else if needed < current {
+			desired = current - 1
+		}
+	case depth > int64(current*batchSize):
+		desired = current + 1
+	}
+	return min(max(desired, scaling.Min), scaling.Max)
+}
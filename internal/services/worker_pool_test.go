@@ -0,0 +1,453 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lucas/go-rest-api-mongo/internal/dto"
+	"github.com/lucas/go-rest-api-mongo/internal/events"
+	"github.com/lucas/go-rest-api-mongo/internal/messaging"
+	"github.com/lucas/go-rest-api-mongo/internal/models"
+	"github.com/lucas/go-rest-api-mongo/internal/repositories"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	testTopic       = "user-registration"
+	testEventsTopic = "user-events"
+)
+
+// newTestEmitter monta o emitter como em cmd/app.go: registros vão para os dois tópicos.
+func newTestEmitter(bus messaging.EventBus, deadLetters repositories.DeadLetterStore) *EventEmitter {
+	encoder, _ := events.NewEncoder(events.ModeBinary, "/test", "http://localhost/schemas/events", nil)
+	return NewEventEmitter(
+		events.NewPublisher(bus, encoder, testTopic, testEventsTopic),
+		events.NewPublisher(bus, encoder, testEventsTopic),
+		deadLetters,
+	)
+}
+
+// flakyBus falha com err nas primeiras failures publicações, depois de deixar passar
+// as primeiras passes.
+type flakyBus struct {
+	*messaging.MemoryBus
+	mu       sync.Mutex
+	passes   int
+	failures int
+	err      error
+}
+
+func newFlakyBus(failures int, err error) *flakyBus {
+	return &flakyBus{MemoryBus: messaging.NewMemoryBus(0), failures: failures, err: err}
+}
+
+// failAfter deixa passar passes publicações e falha todas as seguintes.
+func (b *flakyBus) failAfter(passes int, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.passes, b.failures, b.err = passes, math.MaxInt, err
+}
+
+func (b *flakyBus) heal() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.passes, b.failures = 0, 0
+}
+
+func (b *flakyBus) PublishBatch(ctx context.Context, messages []messaging.Message) error {
+	b.mu.Lock()
+	if b.passes > 0 {
+		b.passes--
+		b.mu.Unlock()
+		return b.MemoryBus.PublishBatch(ctx, messages)
+	}
+	if b.failures > 0 {
+		b.failures--
+		b.mu.Unlock()
+		return b.err
+	}
+	b.mu.Unlock()
+	return b.MemoryBus.PublishBatch(ctx, messages)
+}
+
+func (b *flakyBus) Publish(ctx context.Context, topic, key string, headers map[string]string, payload []byte) error {
+	return b.PublishBatch(ctx, []messaging.Message{{Topic: topic, Key: key, Headers: headers, Payload: payload}})
+}
+
+var testRetryPolicy = RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond}
+
+// newTestRegistrationPool monta o pool de registros como em cmd/app.go.
+func newTestRegistrationPool(userService *UserService, emitter *EventEmitter, deadLetters repositories.DeadLetterStore, store repositories.JobStore) (*WorkerPool[*models.User], *RegistrationWorker) {
+	worker := NewRegistrationWorker(userService, emitter, deadLetters, testRetryPolicy)
+	pool := NewWorkerPool(JobHandler[*models.User]{
+		Queue:        NewQueue[*models.User](store, RegistrationJobType),
+		Handle:       worker.Handle,
+		DeadLetter:   worker.DeadLetter,
+		BatchSize:    10,
+		Timeout:      time.Second,
+		PollInterval: 10 * time.Millisecond,
+		Concurrency:  1,
+		RetryPolicy:  testRetryPolicy,
+	}, deadLetters)
+	return pool, worker
+}
+
+// registrationJobs embrulha os usuários em jobs, como se tivessem vindo da fila.
+func registrationJobs(users []*models.User) []Job[*models.User] {
+	jobs := make([]Job[*models.User], len(users))
+	for i, user := range users {
+		jobs[i] = Job[*models.User]{ID: primitive.NewObjectID(), Attempts: 1, Payload: user}
+	}
+	return jobs
+}
+
+// prepareUsers prepara cada registro separadamente, como RegistrationSubmitter faz.
+func prepareUsers(t *testing.T, userService *UserService, reqs ...*dto.RegisterRequest) []*models.User {
+	t.Helper()
+	users := make([]*models.User, len(reqs))
+	for i, req := range reqs {
+		result := userService.PrepareBatch([]*dto.RegisterRequest{req})[0]
+		if result.Err != nil {
+			t.Fatalf("PrepareBatch() error = %v", result.Err)
+		}
+		users[i] = result.User
+	}
+	return users
+}
+
+func TestRegistrationWorkerPublishesEvents(t *testing.T) {
+	userService, _ := newTestUserService()
+	bus := messaging.NewMemoryBus(0)
+	deadLetters := repositories.NewMemoryDeadLetterRepository()
+	worker := NewRegistrationWorker(userService, newTestEmitter(bus, deadLetters), deadLetters, testRetryPolicy)
+
+	errs := worker.Handle(context.Background(), registrationJobs(prepareUsers(t, userService,
+		&dto.RegisterRequest{Name: "A", Email: "a@example.com", Password: "secret123"},
+		&dto.RegisterRequest{Name: "B", Email: "b@example.com", Password: "secret123"},
+		&dto.RegisterRequest{Name: "B", Email: "b@example.com", Password: "secret123"},
+	)))
+	for i, err := range errs {
+		if err != nil {
+			t.Errorf("Handle() error %d = %v, want a duplicate email to be no job error", i, err)
+		}
+	}
+	if stats := worker.Stats(); stats.Registered != 2 || stats.Duplicates != 1 || stats.EventsPublished != 4 {
+		t.Errorf("stats = %+v, want 2 registered, 1 duplicate and 4 events", stats)
+	}
+
+	messages := bus.Messages(testTopic)
+	if len(messages) != 2 {
+		t.Fatalf("published %d events, want 2", len(messages))
+	}
+	if got := len(bus.Messages(testEventsTopic)); got != 2 {
+		t.Errorf("published %d events to %s, want 2", got, testEventsTopic)
+	}
+
+	ce, err := events.Decode(messages[0])
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	var event events.UserRegistered
+	if err := ce.DataAs(&event); err != nil {
+		t.Fatalf("DataAs() error = %v", err)
+	}
+	if ce.Type != events.TypeUserRegistered || event.Email != "a@example.com" {
+		t.Errorf("event = %s %+v, want user registered for a@example.com", ce.Type, event)
+	}
+	if messages[0].Key != event.UserID || ce.Subject != event.UserID {
+		t.Errorf("message key = %q, subject = %q, want the user id %s", messages[0].Key, ce.Subject, event.UserID)
+	}
+
+	letters, _ := deadLetters.List(context.Background(), "", 10, 0)
+	if len(letters) != 0 {
+		t.Errorf("got %d dead letters, want 0", len(letters))
+	}
+}
+
+func TestRegistrationWorkerRetriesTransientPublishErrors(t *testing.T) {
+	userService, _ := newTestUserService()
+	bus := newFlakyBus(2, context.DeadlineExceeded)
+	deadLetters := repositories.NewMemoryDeadLetterRepository()
+	worker := NewRegistrationWorker(userService, newTestEmitter(bus, deadLetters), deadLetters, testRetryPolicy)
+
+	worker.Handle(context.Background(), registrationJobs(prepareUsers(t, userService, &dto.RegisterRequest{Name: "A", Email: "a@example.com", Password: "secret123"})))
+
+	if got := len(bus.Messages(testTopic)); got != 1 {
+		t.Fatalf("published %d events after retries, want 1", got)
+	}
+}
+
+func TestRegistrationWorkerDeadLettersAndReplay(t *testing.T) {
+	userService, _ := newTestUserService()
+	bus := newFlakyBus(testRetryPolicy.MaxAttempts, context.DeadlineExceeded)
+	deadLetters := repositories.NewMemoryDeadLetterRepository()
+	worker := NewRegistrationWorker(userService, newTestEmitter(bus, deadLetters), deadLetters, testRetryPolicy)
+	ctx := context.Background()
+
+	worker.Handle(ctx, registrationJobs(prepareUsers(t, userService, &dto.RegisterRequest{Name: "A", Email: "a@example.com", Password: "secret123"})))
+
+	// Uma dead letter por tópico de destino
+	letters, _ := deadLetters.List(ctx, "", 10, 0)
+	if len(letters) != 2 {
+		t.Fatalf("got %d dead letters, want one per topic", len(letters))
+	}
+	topics := make(map[string]bool)
+	for _, letter := range letters {
+		if letter.Kind != models.DeadLetterKindEvent || letter.Attempts != testRetryPolicy.MaxAttempts {
+			t.Errorf("dead letter = %+v, want an event letter after %d attempts", letter, testRetryPolicy.MaxAttempts)
+		}
+		if letter.Key == "" || letter.Headers["ce_type"] != events.TypeUserRegistered {
+			t.Errorf("dead letter = %+v, want the message key and headers", letter)
+		}
+		topics[letter.Topic] = true
+	}
+	if !topics[testTopic] || !topics[testEventsTopic] {
+		t.Errorf("dead letter topics = %v, want %s and %s", topics, testTopic, testEventsTopic)
+	}
+
+	service := NewDeadLetterService(deadLetters, userService, newTestEmitter(bus, deadLetters), repositories.NewMemoryJobRepository(0))
+	for _, letter := range letters {
+		if err := service.Replay(ctx, letter.ID); err != nil {
+			t.Fatalf("Replay() error = %v", err)
+		}
+		if stored, _ := deadLetters.FindByID(ctx, letter.ID); stored != nil {
+			t.Error("Replay() must remove the dead letter on success")
+		}
+	}
+	if got := len(bus.Messages(testTopic)); got != 1 {
+		t.Errorf("published %d events to %s after replay, want 1", got, testTopic)
+	}
+	if got := len(bus.Messages(testEventsTopic)); got != 1 {
+		t.Errorf("published %d events to %s after replay, want 1", got, testEventsTopic)
+	}
+}
+
+func TestDeadLetterReplayRegistrationPublishesToEveryTopic(t *testing.T) {
+	userService, _ := newTestUserService()
+	bus := messaging.NewMemoryBus(0)
+	deadLetters := repositories.NewMemoryDeadLetterRepository()
+	ctx := context.Background()
+
+	user := userService.PrepareBatch([]*dto.RegisterRequest{{Name: "A", Email: "a@example.com", Password: "secret123"}})[0].User
+	letter := newRegistrationDeadLetter(user, context.DeadlineExceeded, 1)
+	if err := deadLetters.CreateMany(ctx, []*models.DeadLetter{letter}); err != nil {
+		t.Fatalf("CreateMany() error = %v", err)
+	}
+
+	service := NewDeadLetterService(deadLetters, userService, newTestEmitter(bus, deadLetters), repositories.NewMemoryJobRepository(0))
+	if err := service.Replay(ctx, letter.ID); err != nil {
+		t.Fatalf("Replay() error = %v", err)
+	}
+	if len(bus.Messages(testTopic)) != 1 || len(bus.Messages(testEventsTopic)) != 1 {
+		t.Errorf("published %d/%d events, want one per topic", len(bus.Messages(testTopic)), len(bus.Messages(testEventsTopic)))
+	}
+	if remaining, _ := deadLetters.List(ctx, "", 10, 0); len(remaining) != 0 {
+		t.Errorf("got %d dead letters after replay, want 0", len(remaining))
+	}
+}
+
+// failingSerializer não consegue codificar os eventos do tipo eventType, ou nenhum
+// evento se eventType é vazio.
+type failingSerializer struct {
+	events.Serializer
+	eventType string
+	err       error
+}
+
+func (s failingSerializer) Marshal(event events.Event) ([]byte, error) {
+	if s.eventType == "" || event.EventType() == s.eventType {
+		return nil, s.err
+	}
+	return s.Serializer.Marshal(event)
+}
+
+// newFailingEmitter monta o emitter de newTestEmitter com um failingSerializer.
+func newFailingEmitter(bus messaging.EventBus, deadLetters repositories.DeadLetterStore, eventType string, err error) *EventEmitter {
+	registry, _ := events.NewFileSchemaRegistry("", events.CompatibilityBackward)
+	serializer, _ := events.NewSerializer(events.FormatJSON, registry)
+	encoder, _ := events.NewEncoder(events.ModeBinary, "/test", "http://localhost/schemas/events", failingSerializer{serializer, eventType, err})
+	return NewEventEmitter(
+		events.NewPublisher(bus, encoder, testTopic, testEventsTopic),
+		events.NewPublisher(bus, encoder, testEventsTopic),
+		deadLetters,
+	)
+}
+
+func TestEventEmitterDeadLettersEncodingFailures(t *testing.T) {
+	bus := messaging.NewMemoryBus(0)
+	deadLetters := repositories.NewMemoryDeadLetterRepository()
+	emitter := newFailingEmitter(bus, deadLetters, "", errors.New("no schema"))
+	ctx := context.Background()
+
+	event := &events.UserLoggedIn{UserID: "65a1b2c3d4e5f60718293a4b", Email: "a@example.com", LoggedInAt: time.Now().UTC().Truncate(time.Millisecond)}
+	emitter.Emit(ctx, event)
+
+	letters, _ := deadLetters.List(ctx, "", 10, 0)
+	if len(letters) != 1 {
+		t.Fatalf("got %d dead letters, want the event that failed to encode", len(letters))
+	}
+	letter := letters[0]
+	if letter.Kind != models.DeadLetterKindUnencoded || letter.EventType != events.TypeUserLoggedIn || letter.Payload == "" || !strings.Contains(letter.Error, "no schema") {
+		t.Fatalf("dead letter = %+v, want the event in JSON with the encoding error", letter)
+	}
+
+	// Com o serializer corrigido, o replay codifica e publica o evento
+	service := NewDeadLetterService(deadLetters, nil, newTestEmitter(bus, deadLetters), repositories.NewMemoryJobRepository(0))
+	if err := service.Replay(ctx, letter.ID); err != nil {
+		t.Fatalf("Replay() error = %v", err)
+	}
+	published := bus.Messages(testEventsTopic)
+	if len(published) != 1 || published[0].Key != event.UserID {
+		t.Fatalf("published %+v, want the replayed event", published)
+	}
+	if remaining, _ := deadLetters.List(ctx, "", 10, 0); len(remaining) != 0 {
+		t.Errorf("got %d dead letters after replay, want 0", len(remaining))
+	}
+}
+
+func TestWorkerPoolConsumesTheSharedQueue(t *testing.T) {
+	userService, repo := newTestUserService()
+	bus := messaging.NewMemoryBus(0)
+	deadLetters := repositories.NewMemoryDeadLetterRepository()
+	store := repositories.NewMemoryJobRepository(0)
+	pool, worker := newTestRegistrationPool(userService, newTestEmitter(bus, deadLetters), deadLetters, store)
+	submitter := NewRegistrationSubmitter(userService, NewQueue[*models.User](store, RegistrationJobType))
+	ctx := context.Background()
+
+	for _, email := range []string{"a@example.com", "b@example.com", "b@example.com"} {
+		if err := submitter.Submit(ctx, &dto.RegisterRequest{Name: "X", Email: email, Password: "secret123"}, EnqueueOptions{}); err != nil {
+			t.Fatalf("Submit() error = %v", err)
+		}
+	}
+
+	// Um worker que caiu depois de reservar o primeiro job, sem confirmar
+	if _, err := store.Dequeue(ctx, RegistrationJobType, models.JobLaneNormal, "crashed-worker", 1, time.Millisecond); err != nil {
+		t.Fatalf("Dequeue() error = %v", err)
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	poolCtx, cancel := context.WithCancel(ctx)
+	pool.Start(poolCtx)
+	waitFor(t, func() bool {
+		depth, _ := store.Depth(ctx, RegistrationJobType, "")
+		return depth == 0
+	})
+	cancel()
+	pool.Wait()
+
+	stats, err := pool.Stats(ctx)
+	if err != nil {
+		t.Fatalf("Stats() error = %v", err)
+	}
+	if stats.Type != RegistrationJobType || stats.Jobs != 3 || stats.Succeeded != 3 || stats.Redelivered != 1 {
+		t.Errorf("stats = %+v, want 3 registration jobs succeeded and 1 redelivered", stats)
+	}
+	if registrations := worker.Stats(); registrations.Registered != 2 || registrations.Duplicates != 1 {
+		t.Errorf("registration stats = %+v, want 2 registered and 1 duplicate", registrations)
+	}
+	if user, _ := repo.FindByEmail(ctx, "a@example.com"); user == nil || user.Password == "secret123" {
+		t.Error("the queued user must be stored with a hashed password")
+	}
+	if got := len(bus.Messages(testTopic)); got != 2 {
+		t.Errorf("published %d events, want 2", got)
+	}
+	if err := pool.Healthy(); err != nil {
+		t.Errorf("Healthy() error = %v", err)
+	}
+
+	// Fila cheia
+	full := NewRegistrationSubmitter(userService, NewQueue[*models.User](repositories.NewMemoryJobRepository(1), RegistrationJobType))
+	full.Submit(ctx, &dto.RegisterRequest{Name: "X", Email: "c@example.com", Password: "secret123"}, EnqueueOptions{})
+	if err := full.Submit(ctx, &dto.RegisterRequest{Name: "X", Email: "d@example.com", Password: "secret123"}, EnqueueOptions{}); !errors.Is(err, ErrQueueFull) {
+		t.Errorf("Submit() on a full queue error = %v, want ErrQueueFull", err)
+	}
+}
+
+func TestDesiredWorkers(t *testing.T) {
+	scaling := WorkerPoolScaling{Min: 2, Max: 10, Interval: 10 * time.Second}
+	tests := []struct {
+		name    string
+		current int
+		depth   int64
+		latency time.Duration
+		want    int
+	}{
+		{"empty queue shrinks one worker", 5, 0, time.Second, 4},
+		{"never below the min", 2, 0, 0, 2},
+		{"grows to drain the queue in one interval", 2, 500, time.Second, 5},
+		{"never above the max", 2, 5000, time.Second, 10},
+		{"spare capacity shrinks one worker", 8, 100, time.Second, 7},
+		{"enough workers keeps the size", 5, 500, time.Second, 5},
+		{"no finished batch grows one worker on a backlog", 3, 31, 0, 4},
+		{"no finished batch keeps the size on a short queue", 3, 30, 0, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := desiredWorkers(tt.current, tt.depth, 10, tt.latency, scaling); got != tt.want {
+				t.Errorf("desiredWorkers() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWorkerPoolResize(t *testing.T) {
+	userService, _ := newTestUserService()
+	bus := messaging.NewMemoryBus(0)
+	deadLetters := repositories.NewMemoryDeadLetterRepository()
+	store := repositories.NewMemoryJobRepository(0)
+	pool, _ := newTestRegistrationPool(userService, newTestEmitter(bus, deadLetters), deadLetters, store)
+	queue := NewQueue[*models.User](store, RegistrationJobType)
+	pool.SetScaling(WorkerPoolScaling{Min: 1, Max: 4})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	pool.Start(ctx)
+	defer func() {
+		cancel()
+		pool.Wait()
+	}()
+
+	running := func() int {
+		pool.mu.Lock()
+		defer pool.mu.Unlock()
+		return len(pool.workers)
+	}
+
+	if err := pool.Resize(4); err != nil {
+		t.Fatalf("Resize(4) error = %v", err)
+	}
+	if pool.Size() != 4 || running() != 4 {
+		t.Fatalf("after growing: Size() = %d with %d workers, want 4", pool.Size(), running())
+	}
+	if err := pool.Resize(5); !errors.Is(err, ErrPoolSizeOutOfRange) {
+		t.Errorf("Resize(5) error = %v, want ErrPoolSizeOutOfRange", err)
+	}
+	if err := pool.Resize(2); err != nil || running() != 2 {
+		t.Fatalf("Resize(2) = %v with %d workers, want 2", err, running())
+	}
+
+	// Limites novos ajustam o tamanho atual
+	pool.SetScaling(WorkerPoolScaling{Min: 3, Max: 6, Autoscale: true})
+	stats, _ := pool.Stats(ctx)
+	if stats.Workers != 3 || running() != 3 || stats.MinWorkers != 3 || stats.MaxWorkers != 6 || !stats.Autoscale {
+		t.Errorf("after SetScaling: stats = %+v with %d workers", stats, running())
+	}
+
+	// Os workers que sobraram continuam consumindo a fila
+	for _, user := range prepareUsers(t, userService, &dto.RegisterRequest{Name: "A", Email: "a@example.com", Password: "secret123"}) {
+		if err := queue.Enqueue(ctx, user, EnqueueOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for len(bus.Messages(testTopic)) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("the resized pool did not process the queued registration")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
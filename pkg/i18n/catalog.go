@@ -0,0 +1,80 @@
+package i18n
+
+// catalog traduz as mensagens da API a partir do texto em inglês, que é o idioma de
+// origem. Textos sem entrada são devolvidos em inglês.
+var catalog = map[string]map[string]string{
+	PortugueseBR: {
+		// Títulos HTTP
+		"Bad Request":           "Requisição inválida",
+		"Unauthorized":          "Não autorizado",
+		"Forbidden":             "Acesso negado",
+		"Not Found":             "Não encontrado",
+		"Conflict":              "Conflito",
+		"Too Many Requests":     "Muitas requisições",
+		"Internal Server Error": "Erro interno do servidor",
+		"Service Unavailable":   "Serviço indisponível",
+
+		// Erros de serviço (services/errors.go)
+		"email already exists":                              "e-mail já cadastrado",
+		"invalid credentials":                               "credenciais inválidas",
+		"user not found":                                    "usuário não encontrado",
+		"current password is incorrect":                     "a senha atual está incorreta",
+		"user is disabled":                                  "usuário desativado",
+		"dead letter not found":                             "dead letter não encontrada",
+		"worker pool size is outside the configured bounds": "o tamanho do worker pool está fora dos limites configurados",
+		"job type not found":                                "tipo de job não encontrado",
+		"unknown priority lane":                             "lane de prioridade desconhecida",
+		"scheduled job not found":                           "job agendado não encontrado",
+		"scheduled job is already running":                  "o job agendado já está rodando",
+
+		// Body e validação
+		"request body has invalid fields": "o corpo da requisição tem campos inválidos",
+		"request body is empty":           "o corpo da requisição está vazio",
+		"request body is not valid JSON":  "o corpo da requisição não é um JSON válido",
+		"%s must be of type %s":           "%s deve ser do tipo %s",
+		"invalid request body":            "corpo da requisição inválido",
+		"invalid ID":                      "ID inválido",
+		"invalid user ID":                 "ID de usuário inválido",
+		"invalid limit":                   "limit inválido",
+		"invalid offset":                  "offset inválido",
+
+		// Autenticação
+		"missing authorization header": "header Authorization ausente",
+		"invalid authorization format": "formato do header Authorization inválido",
+		"invalid token":                "token inválido",
+		"invalid token claims":         "claims do token inválidas",
+		"user not authenticated":       "usuário não autenticado",
+		"insufficient permissions":     "permissões insuficientes",
+		"failed to check user":         "falha ao verificar o usuário",
+
+		// Idempotência e limites
+		"idempotency key is too long":                                  "a chave de idempotência é muito longa",
+		"idempotency key was already used with a different request":    "a chave de idempotência já foi usada com outra requisição",
+		"a request with this idempotency key is still being processed": "uma requisição com esta chave de idempotência ainda está em processamento",
+		"failed to process idempotency key":                            "falha ao processar a chave de idempotência",
+		"rate limit exceeded":                                          "limite de requisições excedido",
+		"too many requests":                                            "muitas requisições",
+		"route not found":                                              "rota não encontrada",
+		"event bus does not report stats":                              "o event bus não expõe métricas",
+		"worker pools run in the worker processes":                     "os worker pools rodam nos processos da role worker",
+		"scheduled jobs run in the worker processes":                   "os jobs agendados rodam nos processos da role worker",
+
+		// Falhas internas
+		"failed to register user":           "falha ao registrar usuário",
+		"failed to login":                   "falha ao fazer login",
+		"failed to retrieve user profile":   "falha ao buscar o perfil do usuário",
+		"failed to update user profile":     "falha ao atualizar o perfil do usuário",
+		"failed to change password":         "falha ao trocar a senha",
+		"failed to delete user":             "falha ao excluir o usuário",
+		"failed to list dead letters":       "falha ao listar dead letters",
+		"failed to retrieve dead letter":    "falha ao buscar a dead letter",
+		"failed to replay dead letter":      "falha ao reprocessar a dead letter",
+		"failed to discard dead letter":     "falha ao descartar a dead letter",
+		"failed to queue registration":      "falha ao enfileirar o registro",
+		"failed to resize worker pool":      "falha ao redimensionar o worker pool",
+		"failed to list scheduled jobs":     "falha ao listar os jobs agendados",
+		"failed to update scheduled job":    "falha ao atualizar o job agendado",
+		"failed to trigger scheduled job":   "falha ao disparar o job agendado",
+		"failed to list scheduled job runs": "falha ao listar as execuções do job agendado",
+	},
+}
@@ -0,0 +1,128 @@
+package i18n
+
+import (
+	"fmt"
+
+	"github.com/go-playground/locales"
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/pt_BR"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	en_translations "github.com/go-playground/validator/v10/translations/en"
+	pt_BR_translations "github.com/go-playground/validator/v10/translations/pt_BR"
+	"golang.org/x/text/language"
+)
+
+const (
+	English      = "en"
+	PortugueseBR = "pt-BR"
+)
+
+// Supported são os locales com catálogo e traduções de validação.
+var Supported = []string{English, PortugueseBR}
+
+type localeInfo struct {
+	tag        language.Tag
+	translator locales.Translator
+	register   func(*validator.Validate, ut.Translator) error
+}
+
+var localeInfos = map[string]localeInfo{
+	English:      {language.English, en.New(), en_translations.RegisterDefaultTranslations},
+	PortugueseBR: {language.BrazilianPortuguese, pt_BR.New(), pt_BR_translations.RegisterDefaultTranslations},
+}
+
+// Translator resolve o locale de uma requisição e traduz mensagens da API e do validator.
+// As mensagens do catálogo são indexadas pelo próprio texto em inglês.
+type Translator struct {
+	defaultLocale string
+	locales       []string
+	matcher       language.Matcher
+	universal     *ut.UniversalTranslator
+}
+
+func New(defaultLocale string) (*Translator, error) {
+	if _, ok := localeInfos[defaultLocale]; !ok {
+		return nil, fmt.Errorf("unsupported locale %q", defaultLocale)
+	}
+
+	// O primeiro locale do matcher é usado quando nada combina
+	ordered := []string{defaultLocale}
+	for _, locale := range Supported {
+		if locale != defaultLocale {
+			ordered = append(ordered, locale)
+		}
+	}
+
+	tags := make([]language.Tag, len(ordered))
+	translators := make([]locales.Translator, len(ordered))
+	for i, locale := range ordered {
+		tags[i] = localeInfos[locale].tag
+		translators[i] = localeInfos[locale].translator
+	}
+
+	return &Translator{
+		defaultLocale: defaultLocale,
+		locales:       ordered,
+		matcher:       language.NewMatcher(tags),
+		universal:     ut.New(translators[0], translators...),
+	}, nil
+}
+
+// RegisterValidator registra as mensagens padrão do validator em todos os locales.
+func (t *Translator) RegisterValidator(v *validator.Validate) error {
+	for _, locale := range t.locales {
+		if err := localeInfos[locale].register(v, t.universalTranslator(locale)); err != nil {
+			return fmt.Errorf("registering %s validation messages: %w", locale, err)
+		}
+	}
+	return nil
+}
+
+// Resolve escolhe o locale: a preferência do usuário, se suportada, vence o
+// Accept-Language; sem nenhum dos dois, vale o locale padrão.
+func (t *Translator) Resolve(preference, acceptLanguage string) string {
+	if _, ok := localeInfos[preference]; ok {
+		return preference
+	}
+
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(tags) == 0 {
+		return t.defaultLocale
+	}
+
+	_, index, confidence := t.matcher.Match(tags...)
+	if confidence == language.No {
+		return t.defaultLocale
+	}
+	return t.locales[index]
+}
+
+// T traduz msg, que é o próprio texto em inglês.
+func (t *Translator) T(locale, msg string) string {
+	if translated, ok := catalog[locale][msg]; ok {
+		return translated
+	}
+	return msg
+}
+
+// Tf traduz format e aplica args com fmt.Sprintf.
+func (t *Translator) Tf(locale, format string, args ...interface{}) string {
+	return fmt.Sprintf(t.T(locale, format), args...)
+}
+
+// Validation traduz um erro do validator; regras sem tradução usam a mensagem do validator.
+func (t *Translator) Validation(locale string, fe validator.FieldError) string {
+	return fe.Translate(t.universalTranslator(locale))
+}
+
+// IsSupported informa se locale tem catálogo próprio.
+func IsSupported(locale string) bool {
+	_, ok := localeInfos[locale]
+	return ok
+}
+
+func (t *Translator) universalTranslator(locale string) ut.Translator {
+	trans, _ := t.universal.GetTranslator(localeInfos[locale].translator.Locale())
+	return trans
+}
@@ -0,0 +1,82 @@
+package logging
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Níveis aceitos em LOG_LEVEL, do mais para o menos verboso.
+const (
+	Debug = "debug"
+	Info  = "info"
+	Warn  = "warn"
+	Error = "error"
+)
+
+var levels = map[string]int32{Debug: 0, Info: 1, Warn: 2, Error: 3}
+
+// Writer filtra pelo nível as linhas escritas pelo pacote log. As mensagens do projeto
+// não têm um nível explícito: as que começam com "Error" são erros, as com "⚠️" são
+// avisos e as demais são informativas. Como o nível é lido do começo da mensagem, o
+// Writer escreve a data e a hora no lugar das flags do log (ver Install).
+type Writer struct {
+	mu    sync.Mutex
+	out   io.Writer
+	level atomic.Int32
+	now   func() time.Time
+}
+
+// NewWriter cria um Writer que escreve em out as linhas a partir de level.
+func NewWriter(out io.Writer, level string) *Writer {
+	w := &Writer{out: out, now: time.Now}
+	w.SetLevel(level)
+	return w
+}
+
+// SetLevel troca o nível mínimo das linhas escritas; um nível desconhecido vale info.
+func (w *Writer) SetLevel(level string) {
+	l, ok := levels[level]
+	if !ok {
+		l = levels[Info]
+	}
+	w.level.Store(l)
+}
+
+// Enabled informa se as linhas de level são escritas.
+func (w *Writer) Enabled(level string) bool {
+	l, ok := levels[level]
+	return ok && l >= w.level.Load()
+}
+
+func (w *Writer) Write(p []byte) (int, error) {
+	if !w.Enabled(levelOf(p)) {
+		return len(p), nil
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, err := io.WriteString(w.out, w.now().Format("2006/01/02 15:04:05 ")); err != nil {
+		return 0, err
+	}
+	return w.out.Write(p)
+}
+
+func levelOf(p []byte) string {
+	switch {
+	case bytes.HasPrefix(p, []byte("Error")):
+		return Error
+	case bytes.HasPrefix(p, []byte("⚠️")):
+		return Warn
+	default:
+		return Info
+	}
+}
+
+// Install faz o logger padrão do pacote log escrever em w.
+func Install(w *Writer) {
+	log.SetFlags(0)
+	log.SetOutput(w)
+}
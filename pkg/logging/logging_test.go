@@ -0,0 +1,53 @@
+package logging
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestWriterFiltersByLevel(t *testing.T) {
+	lines := []string{
+		"✅ Connected to MongoDB\n",
+		"⚠️  Keeping the previous rate limits: invalid\n",
+		"Error publishing event: broker down\n",
+	}
+	tests := []struct {
+		level string
+		want  string
+	}{
+		{Debug, "T ✅ Connected to MongoDB\nT ⚠️  Keeping the previous rate limits: invalid\nT Error publishing event: broker down\n"},
+		{Info, "T ✅ Connected to MongoDB\nT ⚠️  Keeping the previous rate limits: invalid\nT Error publishing event: broker down\n"},
+		{Warn, "T ⚠️  Keeping the previous rate limits: invalid\nT Error publishing event: broker down\n"},
+		{Error, "T Error publishing event: broker down\n"},
+		{"verbose", "T ✅ Connected to MongoDB\nT ⚠️  Keeping the previous rate limits: invalid\nT Error publishing event: broker down\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.level, func(t *testing.T) {
+			var out bytes.Buffer
+			w := NewWriter(&out, tt.level)
+			w.now = func() time.Time { return time.Time{} }
+			for _, line := range lines {
+				if n, err := w.Write([]byte(line)); err != nil || n != len(line) {
+					t.Fatalf("Write() = %d, %v", n, err)
+				}
+			}
+			want := bytes.ReplaceAll([]byte(tt.want), []byte("T "), []byte("0001/01/01 00:00:00 "))
+			if out.String() != string(want) {
+				t.Errorf("output = %q, want %q", out.String(), want)
+			}
+		})
+	}
+}
+
+func TestWriterSetLevel(t *testing.T) {
+	var out bytes.Buffer
+	w := NewWriter(&out, Error)
+	if w.Enabled(Info) {
+		t.Fatal("Enabled(info) = true at the error level")
+	}
+	w.SetLevel(Debug)
+	if !w.Enabled(Debug) || !w.Enabled(Info) {
+		t.Error("Enabled() = false after lowering the level to debug")
+	}
+}
@@ -1,10 +1,47 @@
 package utils
 
-import "github.com/gin-gonic/gin"
+import (
+	"fmt"
+	"net/http"
 
-type ErrorResponse struct {
-	Error   string `json:"error"`
-	Message string `json:"message,omitempty"`
+	"github.com/gin-gonic/gin"
+	"github.com/lucas/go-rest-api-mongo/pkg/i18n"
+)
+
+// ProblemContentType é o media type de erros definido pela RFC 9457.
+const ProblemContentType = "application/problem+json"
+
+// ProblemTypeBase é o prefixo das URIs de tipo; cada código de erro vira um documento
+// em ProblemTypeBase + código.
+var ProblemTypeBase = "/problems/"
+
+var translator *i18n.Translator
+
+// UseTranslator faz com que title, detail e mensagens de validação sejam traduzidos
+// para o locale da requisição (chave "locale" do context).
+func UseTranslator(t *i18n.Translator) {
+	translator = t
+}
+
+// Problem é o corpo de erro no formato application/problem+json (RFC 9457).
+// Code e RequestID são extensões: o código curto do erro e o ID de correlação.
+type Problem struct {
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail,omitempty"`
+	Instance  string       `json:"instance,omitempty"`
+	Code      string       `json:"code,omitempty"`
+	RequestID string       `json:"request_id,omitempty"`
+	Errors    []FieldError `json:"errors,omitempty"`
+}
+
+// FieldError descreve uma regra de validação que falhou em um campo do body.
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
 }
 
 type SuccessResponse struct {
@@ -12,11 +49,51 @@ type SuccessResponse struct {
 	Data    interface{} `json:"data,omitempty"`
 }
 
+// NewProblem monta um Problem a partir do status, do código curto e da mensagem.
+func NewProblem(code int, err string, msg string) Problem {
+	return Problem{
+		Type:   ProblemTypeBase + err,
+		Title:  http.StatusText(code),
+		Status: code,
+		Detail: msg,
+		Code:   err,
+	}
+}
+
 func SendError(c *gin.Context, code int, err string, msg string) {
-	c.JSON(code, ErrorResponse{
-		Error:   err,
-		Message: msg,
-	})
+	SendProblem(c, NewProblem(code, err, msg))
+}
+
+// SendProblem completa instance e request_id a partir da requisição, traduz os textos
+// e escreve o erro.
+func SendProblem(c *gin.Context, problem Problem) {
+	problem.Title = localize(c, problem.Title)
+	problem.Detail = localize(c, problem.Detail)
+
+	if problem.Instance == "" {
+		problem.Instance = c.Request.URL.Path
+	}
+	if problem.RequestID == "" {
+		problem.RequestID = c.GetString("request_id")
+	}
+
+	c.Header("Content-Type", ProblemContentType)
+	c.JSON(problem.Status, problem)
+}
+
+// localize traduz msg para o locale da requisição, se houver um translator configurado.
+func localize(c *gin.Context, msg string) string {
+	if translator == nil {
+		return msg
+	}
+	return translator.T(c.GetString("locale"), msg)
+}
+
+func localizef(c *gin.Context, format string, args ...interface{}) string {
+	if translator == nil {
+		return fmt.Sprintf(format, args...)
+	}
+	return translator.Tf(c.GetString("locale"), format, args...)
 }
 
 func SendSuccess(c *gin.Context, code int, data interface{}) {
@@ -0,0 +1,100 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// UseJSONFieldNames faz o validator do gin reportar os campos pelo nome da tag json,
+// que é o nome que o cliente conhece.
+func UseJSONFieldNames() {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return
+	}
+
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return field.Name
+		}
+		return name
+	})
+}
+
+// SendBindingError converte o erro de ShouldBindJSON em um Problem: erros do validator
+// viram a lista errors[], e erros de JSON viram uma mensagem legível.
+func SendBindingError(c *gin.Context, err error) {
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		problem := NewProblem(http.StatusBadRequest, "validation_error", "request body has invalid fields")
+		problem.Errors = FieldErrors(c.GetString("locale"), validationErrs)
+		SendProblem(c, problem)
+		return
+	}
+
+	SendError(c, http.StatusBadRequest, "bad_request", bindingErrorMessage(c, err))
+}
+
+// FieldErrors converte os erros do validator para o formato da API, com as mensagens no locale informado.
+func FieldErrors(locale string, validationErrs validator.ValidationErrors) []FieldError {
+	fieldErrs := make([]FieldError, 0, len(validationErrs))
+	for _, fe := range validationErrs {
+		fieldErrs = append(fieldErrs, FieldError{
+			Field:   fe.Field(),
+			Rule:    fe.Tag(),
+			Param:   fe.Param(),
+			Message: validationMessage(locale, fe),
+		})
+	}
+	return fieldErrs
+}
+
+func validationMessage(locale string, fe validator.FieldError) string {
+	if translator != nil {
+		return translator.Validation(locale, fe)
+	}
+
+	// Sem translator configurado, as mensagens saem em inglês
+	switch fe.Tag() {
+	case "required":
+		return fmt.Sprintf("%s is required", fe.Field())
+	case "email":
+		return fmt.Sprintf("%s must be a valid email address", fe.Field())
+	case "min":
+		return fmt.Sprintf("%s must be at least %s characters long", fe.Field(), fe.Param())
+	case "max":
+		return fmt.Sprintf("%s must be at most %s characters long", fe.Field(), fe.Param())
+	case "oneof":
+		return fmt.Sprintf("%s must be one of [%s]", fe.Field(), fe.Param())
+	default:
+		return fmt.Sprintf("%s failed the %s rule", fe.Field(), fe.Tag())
+	}
+}
+
+func bindingErrorMessage(c *gin.Context, err error) string {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.Is(err, io.EOF):
+		return "request body is empty"
+	case errors.As(err, &syntaxErr), errors.Is(err, io.ErrUnexpectedEOF):
+		return "request body is not valid JSON"
+	case errors.As(err, &typeErr):
+		return localizef(c, "%s must be of type %s", typeErr.Field, typeErr.Type)
+	default:
+		return "invalid request body"
+	}
+}